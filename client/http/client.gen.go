@@ -399,8 +399,12 @@ type SearchOrganisationsResponse struct {
 	HTTPResponse *http.Response
 	JSON200      *OrganisationSearchResponse
 	JSON400      *Error
+	JSON401      *Error
+	JSON429      *Error
 	JSON500      *Error
 	JSON502      *Error
+	JSON503      *Error
+	JSON504      *Error
 }
 
 // Status returns HTTPResponse.Status
@@ -423,9 +427,14 @@ type GetOrganisationByOdsCodeResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 	JSON200      *Organisation
+	JSON400      *Error
+	JSON401      *Error
 	JSON404      *Error
+	JSON429      *Error
 	JSON500      *Error
 	JSON502      *Error
+	JSON503      *Error
+	JSON504      *Error
 }
 
 // Status returns HTTPResponse.Status
@@ -490,6 +499,20 @@ func ParseSearchOrganisationsResponse(rsp *http.Response) (*SearchOrganisationsR
 		}
 		response.JSON400 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 429:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON429 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
 		var dest Error
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -504,6 +527,20 @@ func ParseSearchOrganisationsResponse(rsp *http.Response) (*SearchOrganisationsR
 		}
 		response.JSON502 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 503:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON503 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 504:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON504 = &dest
+
 	}
 
 	return response, nil
@@ -530,6 +567,20 @@ func ParseGetOrganisationByOdsCodeResponse(rsp *http.Response) (*GetOrganisation
 		}
 		response.JSON200 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
 		var dest Error
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -537,6 +588,13 @@ func ParseGetOrganisationByOdsCodeResponse(rsp *http.Response) (*GetOrganisation
 		}
 		response.JSON404 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 429:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON429 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
 		var dest Error
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -551,6 +609,20 @@ func ParseGetOrganisationByOdsCodeResponse(rsp *http.Response) (*GetOrganisation
 		}
 		response.JSON502 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 503:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON503 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 504:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON504 = &dest
+
 	}
 
 	return response, nil
@@ -72,6 +72,9 @@ type Organisation struct {
 
 	// Roles Current or historical roles assigned to the organisation.
 	Roles *[]OrganisationRole `json:"roles,omitempty"`
+
+	// Stale True when the organisation was served from cache past its freshness lifetime, because the ODS FHIR API could not be reached or the entry is being refreshed. Omitted for fresh data.
+	Stale *bool `json:"stale,omitempty"`
 }
 
 // OrganisationMetadata defines model for OrganisationMetadata.
@@ -110,19 +113,22 @@ type OrganisationSearchResponse struct {
 	// PageSize Page size.
 	PageSize int `json:"pageSize"`
 
+	// Stale True when the results were served from cache past their freshness lifetime, because the ODS FHIR API could not be reached or the entry is being refreshed. Omitted for fresh data.
+	Stale *bool `json:"stale,omitempty"`
+
 	// Total Total number of records matching the search criteria.
 	Total int `json:"total"`
 }
 
 // SearchOrganisationsParams defines parameters for SearchOrganisations.
 type SearchOrganisationsParams struct {
-	// Name Case-insensitive substring match on organisation name.
+	// Name Case-insensitive substring match on organisation name. Between 3 and 100 characters, limited to letters, digits, spaces and &()'’+-_./:@ as required by ODS.
 	Name *string `form:"name,omitempty" json:"name,omitempty"`
 
-	// City City / town.
+	// City City / town. At least 2 characters, limited to letters, digits and spaces.
 	City *string `form:"city,omitempty" json:"city,omitempty"`
 
-	// Postcode Postcode (full or prefix).
+	// Postcode Postcode (full or prefix). At least 2 characters, limited to letters, digits and spaces.
 	Postcode *string `form:"postcode,omitempty" json:"postcode,omitempty"`
 
 	// Active Filter by organisation activity status.
@@ -2,18 +2,57 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/audit"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
 )
 
 func main() {
+	configFiles := flag.String("config", os.Getenv("CONFIG_FILES"),
+		"comma-separated YAML or JSON config files, applied in order and overridden by environment variables")
+	checkConfig := flag.Bool("check-config", false, "validate the configuration, print every problem found and exit")
+	verifyAudit := flag.String("verify-audit", "", "verify the hash chain of the audit log in this directory and exit")
+	flag.Parse()
+
+	var files []string
+	if *configFiles != "" {
+		files = strings.Split(*configFiles, ",")
+	}
+	appConfig, err := config.Load(files...)
+
+	if *verifyAudit != "" {
+		// only the key of the log is used: other problems of the configuration do not prevent the verification.
+		os.Exit(runVerifyAudit(*verifyAudit, []byte(appConfig.AuditConfig.HMACKey)))
+	}
+
+	if *checkConfig {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration OK")
+		return
+	}
+
+	if err != nil {
+		// the log level may be the invalid setting.
+		elog.Init("INFO")
+		log.Panic().Err(err).Msg("invalid configuration")
+	}
+
 	ctx := context.Background()
-	elog.Init()
+	elog.Init(appConfig.LogLevel)
 
-	svc, err := service.NewService()
+	svc, err := service.NewService(appConfig, files)
 	if err != nil {
 		log.Panic().Err(err).Msg("could not create service instance")
 	}
@@ -23,3 +62,23 @@ func main() {
 		log.Panic().Err(err).Msg("could not start service")
 	}
 }
+
+// runVerifyAudit verifies the audit log in dir, keyed with key, and returns the exit code: 1 when it was tampered with or cannot be read.
+func runVerifyAudit(dir string, key []byte) int {
+	v, err := audit.Verify(dir, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "audit log verification failed after %d records: %v\n", v.Records, err)
+		return 1
+	}
+
+	fmt.Printf("audit log OK: %d records in %d files, seq %d (%s) to %d (%s)\n", v.Records, v.Files,
+		v.FirstSeq, v.FirstTime.Format(time.RFC3339), v.LastSeq, v.LastTime.Format(time.RFC3339))
+	fmt.Printf("last hash: %s\n", v.LastHash)
+	for _, line := range v.Torn {
+		fmt.Printf("torn line, not part of the chain: %s\n", line)
+	}
+	for _, seq := range v.Recovered {
+		fmt.Printf("recovered from a torn line at record %d\n", seq)
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinel kinds every application error is classified by. Callers branch on them with errors.Is,
+// the HTTP layer maps them onto status codes and error codes.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrInvalidInput        = errors.New("invalid input")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrUpstreamTimeout     = errors.New("upstream timeout")
+	ErrMapping             = errors.New("mapping failure")
+	ErrOverloaded          = errors.New("overloaded")
+	ErrRateLimited         = errors.New("rate limited")
+)
+
+// Error is a classified application error. Kind is one of the sentinels above, Err is the optional cause.
+// RetryAfter tells callers when a rejected request may be retried.
+type Error struct {
+	Kind       error
+	Message    string
+	Details    map[string]interface{}
+	RetryAfter time.Duration
+	Err        error
+}
+
+func New(kind error, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+func Wrap(kind error, err error, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// WithDetail attaches a key/value pair which is exposed to callers in the error response details.
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// WithRetryAfter sets the delay after which callers may retry the request.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	e.RetryAfter = d
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// From returns the first *Error found in the chain of err.
+func From(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,210 @@
+// Package audit records who looked up which organisation, as an append-only log of JSON lines. Each record holds the
+// hash of the record before it, so that a record changed, removed or inserted afterwards breaks the chain, which
+// Verify detects. With a key, the hashes are HMACs, which cannot be recomputed by whoever can write the log; without
+// one, the head of the chain logged by Logger is what tells records removed from its end.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+)
+
+// Actions recorded.
+const (
+	ActionGetOrganisation     = "get_organisation"
+	ActionSearchOrganisations = "search_organisations"
+	// ActionRecoverTornWrite is recorded on start when the last record of the log was only partly written, e.g. as
+	// the host crashed. The chain continues from the last complete record.
+	ActionRecoverTornWrite = "recover_torn_write"
+)
+
+// Event is a lookup made by a consumer.
+type Event struct {
+	Action    string `json:"action"`
+	Consumer  string `json:"consumer,omitempty"`
+	KeyID     string `json:"keyId,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	// ODSCode is the organisation looked up, and Criteria the parameters of a search.
+	ODSCode  string            `json:"odsCode,omitempty"`
+	Criteria map[string]string `json:"criteria,omitempty"`
+	// Outcome labels the error of the lookup as metrics.Outcome does, and Results counts the organisations found.
+	Outcome string `json:"outcome"`
+	Results *int   `json:"results,omitempty"`
+}
+
+// Record is an Event as written to the log. Hash is the HMAC-SHA256 of the record without it with the key of the
+// log, or its SHA-256 when the log has no key, and Prev the Hash of the record before, empty for the first record.
+type Record struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Event
+	Prev string `json:"prev"`
+	Hash string `json:"-"`
+}
+
+// hashField is the last field of every line, after the fields of the record it is the hash of.
+const hashField = `,"hash":"`
+
+// sum returns the hash of the record body.
+func sum(key, body []byte) string {
+	if len(key) == 0 {
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encode returns the line of r, with its hash.
+func (r Record) encode(key []byte) ([]byte, string, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := sum(key, body)
+
+	line := make([]byte, 0, len(body)+len(hashField)+len(hash)+3)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, hashField...)
+	line = append(line, hash...)
+	line = append(line, "\"}\n"...)
+	return line, hash, nil
+}
+
+// decode parses a line of the log and checks the hash it holds against the fields before it.
+func decode(line, key []byte) (Record, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	i := bytes.LastIndex(line, []byte(hashField))
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return Record{}, errors.New("record has no hash")
+	}
+	body := append(line[:i:i], '}')
+	hash := string(line[i+len(hashField) : len(line)-2])
+
+	var r Record
+	if err := json.Unmarshal(body, &r); err != nil {
+		return Record{}, fmt.Errorf("malformed record: %w", err)
+	}
+	r.Hash = hash
+
+	if !hmac.Equal([]byte(sum(key, body)), []byte(hash)) {
+		return r, fmt.Errorf("record %d does not match its hash", r.Seq)
+	}
+	return r, nil
+}
+
+// Recorder records the lookups of consumers.
+type Recorder interface {
+	Record(ctx context.Context, event Event)
+}
+
+// Discard is a Recorder which records nothing, for when auditing is disabled.
+var Discard Recorder = discard{}
+
+type discard struct{}
+
+func (discard) Record(context.Context, Event) {}
+
+// Sink stores the lines of the log, in order.
+type Sink interface {
+	// Append writes the line of the record seq. started is true when the line starts a new file.
+	Append(seq uint64, line []byte) (started bool, err error)
+	// Last returns the last complete line written, or nil when none was. torn is true when a line was only partly
+	// written after it.
+	Last() (line []byte, torn bool, err error)
+	Close() error
+}
+
+// Logger chains the events recorded and writes them to a Sink.
+type Logger struct {
+	mu   sync.Mutex
+	sink Sink
+	key  []byte
+	seq  uint64
+	prev string
+}
+
+// NewLogger returns a Logger continuing the chain of sink. It fails when the last complete record of sink does not
+// match its hash: the log must then be verified before anything is added to it. A record only partly written after
+// it is left out of the chain, and its recovery recorded. key is the key of the HMACs of the log, nil for none.
+func NewLogger(sink Sink, key []byte) (*Logger, error) {
+	last, torn, err := sink.Last()
+	if err != nil {
+		return nil, fmt.Errorf("reading the last audit record: %w", err)
+	}
+
+	l := &Logger{sink: sink, key: key}
+	if last != nil {
+		r, err := decode(last, key)
+		if err != nil {
+			return nil, fmt.Errorf("last audit record: %w", err)
+		}
+		l.seq, l.prev = r.Seq, r.Hash
+	}
+	if torn {
+		log.Warn().Uint64("seq", l.seq).Msg("the last audit record was only partly written; continuing from the one before")
+		l.Record(context.Background(), Event{Action: ActionRecoverTornWrite, Outcome: "success"})
+	}
+	return l, nil
+}
+
+// Record writes event to the log. A record which cannot be written is logged and counted, but does not fail the
+// lookup.
+func (l *Logger) Record(ctx context.Context, event Event) {
+	if err := l.record(event, time.Now()); err != nil {
+		metrics.AuditWriteErrorsTotal.Inc()
+		elog.Ctx(ctx).Err(err).Str("action", event.Action).Str("consumer", event.Consumer).
+			Msg("error writing audit record")
+	}
+}
+
+func (l *Logger) record(event Event, now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := Record{Seq: l.seq + 1, Time: now.UTC(), Event: event, Prev: l.prev}
+	line, hash, err := r.encode(l.key)
+	if err != nil {
+		return err
+	}
+	started, err := l.sink.Append(r.Seq, line)
+	if started && l.seq > 0 {
+		l.logHead("new file")
+	}
+	if err != nil {
+		return err
+	}
+	l.seq, l.prev = r.Seq, hash
+	metrics.AuditChainHeadSeq.Set(float64(l.seq))
+	return nil
+}
+
+// logHead logs the last record of the chain, for it to be kept apart from the log: records removed from the end of
+// the log can only be told against it.
+func (l *Logger) logHead(reason string) {
+	log.Info().Uint64("seq", l.seq).Str("hash", l.prev).Str("reason", reason).Msg("audit chain head")
+}
+
+// Close logs the head of the chain and closes the sink.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.seq > 0 {
+		l.logHead("close")
+	}
+	return l.sink.Close()
+}
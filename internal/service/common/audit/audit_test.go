@@ -0,0 +1,301 @@
+package audit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/audit"
+)
+
+// helper to write events with a new logger of the audit log of cfg, as a restart of the service would.
+func writeEvents(t *testing.T, cfg audit.FileConfig, events ...audit.Event) {
+	t.Helper()
+
+	sink, err := audit.NewFileSink(cfg)
+	require.NoError(t, err)
+	logger, err := audit.NewLogger(sink, nil)
+	require.NoError(t, err)
+	for _, event := range events {
+		logger.Record(context.Background(), event)
+	}
+	require.NoError(t, logger.Close())
+}
+
+// helper to rewrite a line of an audit log file, removing it when edit returns an empty line.
+func editLine(t *testing.T, name string, line int, edit func(string) string) {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	lines := strings.Split(string(data), "\n")
+	if lines[line] = edit(lines[line]); lines[line] == "" {
+		lines = append(lines[:line], lines[line+1:]...)
+	}
+	require.NoError(t, os.WriteFile(name, []byte(strings.Join(lines, "\n")), 0o600))
+}
+
+func lookup(odsCode string) audit.Event {
+	return audit.Event{Action: audit.ActionGetOrganisation, Consumer: "gp-connect", ODSCode: odsCode, Outcome: "success"}
+}
+
+func TestLogger_ChainsRecordsAcrossRestartsAndFiles(t *testing.T) {
+	t.Parallel()
+
+	cfg := audit.FileConfig{Dir: t.TempDir(), MaxSize: 400}
+	writeEvents(t, cfg, lookup("RR8"), lookup("RAE"), lookup("RYJ"))
+	writeEvents(t, cfg, audit.Event{
+		Action:   audit.ActionSearchOrganisations,
+		Consumer: "gp-connect",
+		Criteria: map[string]string{"name": "Leeds", "page": "1"},
+		Outcome:  "success",
+	})
+
+	files, err := audit.Files(cfg.Dir)
+	require.NoError(t, err)
+	assert.Greater(t, len(files), 2, "files are rotated at their size and on each start")
+
+	v, err := audit.Verify(cfg.Dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), v.Records)
+	assert.Equal(t, uint64(1), v.FirstSeq)
+	assert.Equal(t, uint64(4), v.LastSeq)
+	assert.Len(t, v.LastHash, 64)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"seq":1,`)
+	assert.Contains(t, string(data), `"consumer":"gp-connect","odsCode":"RR8","outcome":"success","prev":"","hash":"`)
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, files []string)
+		wantErr string
+	}{
+		{
+			name: "changed record",
+			tamper: func(t *testing.T, files []string) {
+				t.Helper()
+				editLine(t, files[0], 1, func(line string) string { return strings.Replace(line, "RAE", "RXX", 1) })
+			},
+			wantErr: "record 2 does not match its hash",
+		},
+		{
+			name: "removed record",
+			tamper: func(t *testing.T, files []string) {
+				t.Helper()
+				editLine(t, files[0], 1, func(string) string { return "" })
+			},
+			wantErr: "record 3 follows record 1",
+		},
+		{
+			name: "removed file",
+			tamper: func(t *testing.T, files []string) {
+				t.Helper()
+				require.NoError(t, os.Remove(files[1]))
+			},
+			wantErr: "record 5 follows record 3",
+		},
+		{
+			name: "rehashed record",
+			tamper: func(t *testing.T, files []string) {
+				t.Helper()
+				// a record made up from scratch matches its own hash, but breaks the link of the next one.
+				dir := t.TempDir()
+				writeEvents(t, audit.FileConfig{Dir: dir}, lookup("RR8"), lookup("RXX"), lookup("RYJ"))
+				forged, err := os.ReadFile(filepath.Join(dir, filepath.Base(files[0])))
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(files[0], forged, 0o600))
+			},
+			wantErr: "record 4 does not follow the hash of record 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := audit.FileConfig{Dir: t.TempDir()}
+			writeEvents(t, cfg, lookup("RR8"), lookup("RAE"), lookup("RYJ"))
+			writeEvents(t, cfg, lookup("RTH"))
+			writeEvents(t, cfg, lookup("R1H"))
+
+			files, err := audit.Files(cfg.Dir)
+			require.NoError(t, err)
+			require.Len(t, files, 3)
+			tt.tamper(t, files)
+
+			_, err = audit.Verify(cfg.Dir, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestVerify_RequiresTheKeyOfTheLog(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sink, err := audit.NewFileSink(audit.FileConfig{Dir: dir})
+	require.NoError(t, err)
+	logger, err := audit.NewLogger(sink, []byte("secret"))
+	require.NoError(t, err)
+	logger.Record(context.Background(), lookup("RR8"))
+	logger.Record(context.Background(), lookup("RAE"))
+	require.NoError(t, logger.Close())
+
+	v, err := audit.Verify(dir, []byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), v.Records)
+
+	// without the key, a log rehashed from scratch cannot be told from the original.
+	_, err = audit.Verify(dir, nil)
+	require.ErrorContains(t, err, "record 1 does not match its hash")
+	_, err = audit.Verify(dir, []byte("guessed"))
+	require.ErrorContains(t, err, "record 1 does not match its hash")
+
+	sink, err = audit.NewFileSink(audit.FileConfig{Dir: dir})
+	require.NoError(t, err)
+	_, err = audit.NewLogger(sink, nil)
+	require.ErrorContains(t, err, "does not match its hash", "the log is not continued without its key")
+}
+
+func TestNewLogger_RefusesToContinueTamperedLog(t *testing.T) {
+	t.Parallel()
+
+	cfg := audit.FileConfig{Dir: t.TempDir()}
+	writeEvents(t, cfg, lookup("RR8"))
+
+	files, err := audit.Files(cfg.Dir)
+	require.NoError(t, err)
+	editLine(t, files[0], 0, func(line string) string { return strings.Replace(line, "RR8", "RXX", 1) })
+
+	sink, err := audit.NewFileSink(cfg)
+	require.NoError(t, err)
+	_, err = audit.NewLogger(sink, nil)
+	require.ErrorContains(t, err, "does not match its hash")
+}
+
+func TestNewLogger_RecoversFromTornWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// tear writes half a line to the log in dir, whose last file is last.
+		tear      func(t *testing.T, dir, last string)
+		wantFiles int
+		wantTorn  int
+	}{
+		{
+			name: "after a record of the file",
+			tear: func(t *testing.T, _, last string) {
+				t.Helper()
+				file, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0o600)
+				require.NoError(t, err)
+				_, err = file.WriteString(`{"seq":3,"time":"2026-`)
+				require.NoError(t, err)
+				require.NoError(t, file.Close())
+			},
+			wantFiles: 3,
+			wantTorn:  1,
+		},
+		{
+			name: "as the first record of a file",
+			tear: func(t *testing.T, dir, _ string) {
+				t.Helper()
+				name := filepath.Join(dir, "audit-00000000000000000003.jsonl")
+				require.NoError(t, os.WriteFile(name, []byte(`{"seq":3,"time":"2026-`), 0o600))
+			},
+			// the torn file is moved aside for the file of the recovery.
+			wantFiles: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := audit.FileConfig{Dir: t.TempDir()}
+			writeEvents(t, cfg, lookup("RR8"))
+			writeEvents(t, cfg, lookup("RAE"))
+			files, err := audit.Files(cfg.Dir)
+			require.NoError(t, err)
+			tt.tear(t, cfg.Dir, files[len(files)-1])
+
+			writeEvents(t, cfg, lookup("RYJ"))
+
+			files, err = audit.Files(cfg.Dir)
+			require.NoError(t, err)
+			assert.Len(t, files, tt.wantFiles)
+
+			v, err := audit.Verify(cfg.Dir, nil)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(4), v.Records)
+			assert.Len(t, v.Torn, tt.wantTorn)
+			assert.Equal(t, []uint64{3}, v.Recovered)
+
+			data, err := os.ReadFile(files[len(files)-1])
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"seq":3,`)
+			assert.Contains(t, string(data), `"action":"recover_torn_write"`)
+			assert.Contains(t, string(data), `"seq":4,`)
+		})
+	}
+}
+
+func TestVerify_RequiresRecoveryAfterTornWrite(t *testing.T) {
+	t.Parallel()
+
+	cfg := audit.FileConfig{Dir: t.TempDir()}
+	writeEvents(t, cfg, lookup("RR8"), lookup("RAE"))
+	writeEvents(t, cfg, lookup("RYJ"))
+
+	files, err := audit.Files(cfg.Dir)
+	require.NoError(t, err)
+	// a line torn at the end of a file which is not the last is followed by a record, but not of its recovery.
+	file, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"seq":3,"time":"2026-`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	_, err = audit.Verify(cfg.Dir, nil)
+	require.ErrorContains(t, err, "record 3 follows a torn line without recording its recovery")
+}
+
+func TestFileSink_RemovesExpiredFiles(t *testing.T) {
+	t.Parallel()
+
+	cfg := audit.FileConfig{Dir: t.TempDir()}
+	writeEvents(t, cfg, lookup("RR8"), lookup("RAE"))
+	writeEvents(t, cfg, lookup("RYJ"))
+
+	files, err := audit.Files(cfg.Dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(files[0], old, old))
+
+	cfg.Retention = 24 * time.Hour
+	writeEvents(t, cfg, lookup("RTH"))
+
+	files, err = audit.Files(cfg.Dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "only the expired file is removed")
+
+	// the first record retained anchors the chain.
+	v, err := audit.Verify(cfg.Dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), v.FirstSeq)
+	assert.Equal(t, uint64(4), v.LastSeq)
+}
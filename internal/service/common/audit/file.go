@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".jsonl"
+	// tornSuffix is added to the name of a file moved aside by keepTorn.
+	tornSuffix = ".torn"
+	// tailSize is how much of the end of a file is read for its last line, before falling back to the whole file.
+	tailSize = 64 * 1024
+)
+
+// FileConfig configures a FileSink. Zero values disable rotation on size or age, and removal of old files.
+type FileConfig struct {
+	Dir string
+	// MaxSize is the size in bytes a file is rotated at, and RotateInterval the age.
+	MaxSize        int64
+	RotateInterval time.Duration
+	// Retention is how long files are kept after they were last written to. The file being written is always kept.
+	Retention time.Duration
+}
+
+// FileSink writes the log to files in a directory, named after the seq of their first record so that they sort in
+// the order of the log. A new file is started on each start, and whenever the current one is too large or too old.
+type FileSink struct {
+	cfg     FileConfig
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+func NewFileSink(cfg FileConfig) (*FileSink, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &FileSink{cfg: cfg}
+	if err := s.removeExpired(time.Now()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append writes line to the current file, first starting a new one named after seq when rotation is due.
+func (s *FileSink) Append(seq uint64, line []byte) (bool, error) {
+	now := time.Now()
+	started := s.file == nil || s.rotationDue(now, len(line))
+	if started {
+		if err := s.rotate(seq, now); err != nil {
+			return started, err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	return started, err
+}
+
+func (s *FileSink) rotationDue(now time.Time, next int) bool {
+	return (s.cfg.MaxSize > 0 && s.size > 0 && s.size+int64(next) > s.cfg.MaxSize) ||
+		(s.cfg.RotateInterval > 0 && now.Sub(s.started) >= s.cfg.RotateInterval)
+}
+
+func (s *FileSink) rotate(seq uint64, now time.Time) error {
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return err
+		}
+		s.file = nil
+	}
+
+	name := filepath.Join(s.cfg.Dir, fileName(seq))
+	if err := keepTorn(name); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.file, s.size, s.started = file, 0, now
+	log.Info().Str("file", name).Msg("started audit log file")
+
+	return s.removeExpired(now)
+}
+
+// keepTorn moves the file name aside when it is not empty. The records of a file named after seq can only have been
+// written by a logger which did not get to complete its first record, seq, as seq is not in the log: the partial
+// line is kept for inspection, but out of the log.
+func keepTorn(name string) error {
+	info, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.Size() == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(name, name+tornSuffix); err != nil {
+		return err
+	}
+	log.Warn().Str("file", name+tornSuffix).Msg("moved aside audit log file holding only a torn record")
+	return nil
+}
+
+// removeExpired removes the files last written to before the retention period.
+func (s *FileSink) removeExpired(now time.Time) error {
+	if s.cfg.Retention <= 0 {
+		return nil
+	}
+	files, err := Files(s.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range files {
+		if s.file != nil && name == s.file.Name() {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if now.Sub(info.ModTime()) < s.cfg.Retention {
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		log.Info().Str("file", name).Time("lastWritten", info.ModTime()).Msg("removed expired audit log file")
+	}
+	return errors.Join(errs...)
+}
+
+// Last returns the last complete line of the newest file which has one. torn is true when a line was only partly
+// written after it.
+func (s *FileSink) Last() (line []byte, torn bool, err error) {
+	files, err := Files(s.cfg.Dir)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, name := range slices.Backward(files) {
+		line, tornInFile, err := lastLine(name)
+		torn = torn || tornInFile
+		if err != nil || line != nil {
+			return line, torn, err
+		}
+	}
+	return nil, torn, nil
+}
+
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Files returns the files of the log in dir, in the order of the log.
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if _, ok := fileSeq(entry.Name()); ok && entry.Type().IsRegular() {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	// names are zero-padded, so they sort as their seq do.
+	slices.Sort(files)
+	return files, nil
+}
+
+func fileName(seq uint64) string {
+	return fmt.Sprintf("%s%020d%s", filePrefix, seq, fileSuffix)
+}
+
+// fileSeq returns the seq of the first record of the file name.
+func fileSeq(name string) (uint64, bool) {
+	digits, ok := strings.CutPrefix(name, filePrefix)
+	if !ok {
+		return 0, false
+	}
+	digits, ok = strings.CutSuffix(digits, fileSuffix)
+	if !ok {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(digits, 10, 64)
+	return seq, err == nil
+}
+
+// lastLine returns the last complete line of the file name, or nil when it has none. torn is true when the file does
+// not end with a newline: what follows the last one was only partly written, and is not a line.
+func lastLine(name string) (line []byte, torn bool, err error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	for offset := max(info.Size()-tailSize, 0); ; offset = 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return nil, false, err
+		}
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, false, err
+		}
+
+		end := bytes.LastIndexByte(data, '\n')
+		torn = end < len(data)-1
+		data = bytes.TrimRight(data[:end+1], "\n")
+		start := bytes.LastIndexByte(data, '\n')
+		switch {
+		case len(data) == 0 && offset == 0:
+			return nil, torn, nil
+		case start >= 0 || offset == 0:
+			return data[start+1:], torn, nil
+		}
+		// the last line starts before the tail: read the whole file.
+	}
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxLineSize bounds the lines Verify reads.
+const maxLineSize = 1024 * 1024
+
+// Verification is the chain verified by Verify. The first record is the anchor of the chain: records before it may
+// have been removed by retention. Nothing after the last record can be detected as removed, so LastHash should be
+// kept apart from the log, to be compared with on the next verification, as should the chain head Logger logs.
+type Verification struct {
+	Files     int
+	Records   uint64
+	FirstSeq  uint64
+	LastSeq   uint64
+	FirstTime time.Time
+	LastTime  time.Time
+	LastHash  string
+	// Torn lists the lines only partly written, as file:line, which are not records of the chain. Recovered lists the
+	// records of ActionRecoverTornWrite, written on the start after each.
+	Torn      []string
+	Recovered []uint64
+
+	// torn is set by a torn line until the record of its recovery.
+	torn bool
+}
+
+// Verify reads the log in dir, whose HMACs are keyed with key, and checks that every record matches its hash, follows the record before it and is in
+// the file named after the first record. A line only partly written must be the last of its file and be followed by
+// the record of its recovery; both are reported. It returns the first problem found, with the file and line of the record.
+func Verify(dir string, key []byte) (Verification, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return Verification{}, err
+	}
+	if len(files) == 0 {
+		return Verification{}, fmt.Errorf("no audit log files in %s", dir)
+	}
+
+	var v Verification
+	for _, name := range files {
+		if err := v.verifyFile(name, key); err != nil {
+			return v, err
+		}
+		v.Files++
+	}
+	if v.Records == 0 {
+		return v, errors.New("the audit log has no records")
+	}
+	return v, nil
+}
+
+func (v *Verification) verifyFile(name string, key []byte) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileSeq, _ := fileSeq(filepath.Base(name))
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	var torn bool
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		torn = atEOF && len(data) > 0 && bytes.IndexByte(data, '\n') < 0
+		return bufio.ScanLines(data, atEOF)
+	})
+
+	for line := 1; scanner.Scan(); line++ {
+		if torn {
+			v.Torn = append(v.Torn, fmt.Sprintf("%s:%d", name, line))
+			v.torn = true
+			break
+		}
+		r, err := decode(scanner.Bytes(), key)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		switch {
+		case line == 1 && r.Seq != fileSeq:
+			return fmt.Errorf("%s:%d: file starts with record %d, not %d", name, line, r.Seq, fileSeq)
+		case v.Records == 0 && r.Seq == 1 && r.Prev != "":
+			return fmt.Errorf("%s:%d: first record follows a hash", name, line)
+		case v.Records > 0 && r.Seq != v.LastSeq+1:
+			return fmt.Errorf("%s:%d: record %d follows record %d", name, line, r.Seq, v.LastSeq)
+		case v.Records > 0 && r.Prev != v.LastHash:
+			return fmt.Errorf("%s:%d: record %d does not follow the hash of record %d", name, line, r.Seq, v.LastSeq)
+		case v.torn && r.Action != ActionRecoverTornWrite:
+			return fmt.Errorf("%s:%d: record %d follows a torn line without recording its recovery", name, line, r.Seq)
+		}
+
+		if r.Action == ActionRecoverTornWrite {
+			v.Recovered = append(v.Recovered, r.Seq)
+			v.torn = false
+		}
+		if v.Records == 0 {
+			v.FirstSeq, v.FirstTime = r.Seq, r.Time
+		}
+		v.Records++
+		v.LastSeq, v.LastHash, v.LastTime = r.Seq, r.Hash, r.Time
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+)
+
+type consumerKey struct{}
+
+// WithConsumer returns a copy of ctx carrying the authenticated consumer.
+func WithConsumer(ctx context.Context, c Consumer) context.Context {
+	return context.WithValue(ctx, consumerKey{}, c)
+}
+
+// ConsumerFromContext returns the consumer carried by ctx, if the request was authenticated.
+func ConsumerFromContext(ctx context.Context) (Consumer, bool) {
+	c, ok := ctx.Value(consumerKey{}).(Consumer)
+	return c, ok
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// JWK is a public key of a JSON Web Key Set. Only RSA keys and EC keys on P-256 are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// N and E are the modulus and exponent of an RSA key.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Crv, X and Y are the curve and coordinates of an EC key.
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set, as served by identity providers.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// RSAPublicJWK returns the JWK of an RSA public key.
+func RSAPublicJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: AlgRS256,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// ECPublicJWK returns the JWK of an EC public key on P-256.
+func ECPublicJWK(kid string, key *ecdsa.PublicKey) JWK {
+	return JWK{
+		Kty: "EC",
+		Kid: kid,
+		Use: "sig",
+		Alg: AlgES256,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// minRSABits is the smallest RSA modulus accepted, as shorter keys can no longer be relied upon.
+const minRSABits = 2048
+
+// publicKey returns the public key of k, rejecting keys too weak to verify tokens with, and those whose alg, when
+// present, is not the algorithm tokens are verified with for their type.
+func (k JWK) publicKey() (crypto.PublicKey, error) {
+	decode := func(value string) *big.Int {
+		raw, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(raw) == 0 {
+			return nil
+		}
+		return new(big.Int).SetBytes(raw)
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, e := decode(k.N), decode(k.E)
+		if n == nil || e == nil || !e.IsInt64() {
+			return nil, fmt.Errorf("JWK %q: invalid RSA key", k.Kid)
+		}
+		if n.BitLen() < minRSABits {
+			return nil, fmt.Errorf("JWK %q: RSA key of %d bits, at least %d are required", k.Kid, n.BitLen(), minRSABits)
+		}
+		if k.Alg != "" && k.Alg != AlgRS256 {
+			return nil, fmt.Errorf("JWK %q: unsupported algorithm %q for an RSA key", k.Kid, k.Alg)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		x, y := decode(k.X), decode(k.Y)
+		if k.Crv != "P-256" || x == nil || y == nil {
+			return nil, fmt.Errorf("JWK %q: only EC keys on P-256 are supported", k.Kid)
+		}
+		if k.Alg != "" && k.Alg != AlgES256 {
+			return nil, fmt.Errorf("JWK %q: unsupported algorithm %q for an EC key", k.Kid, k.Alg)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("JWK %q: invalid EC key", k.Kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("JWK %q: unsupported key type %q", k.Kid, k.Kty)
+	}
+}
+
+// keySet caches the keys of a JWKS read from a URL or a file. The keys are read again when they are older than ttl,
+// and when a token is signed by an unknown key, as after a rotation, at most once per cooldown. Concurrent reads are
+// shared, and tokens signed by a known key are verified while the keys are read.
+type keySet struct {
+	url      string
+	file     string
+	client   *http.Client
+	ttl      time.Duration
+	cooldown time.Duration
+	group    singleflight.Group
+
+	mu   sync.Mutex
+	keys map[string]crypto.PublicKey
+	// fetched is when the keys were last read, and attempted when they last were tried to be, successfully or not.
+	fetched   time.Time
+	attempted time.Time
+}
+
+func (s *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok, due := s.lookup(kid, time.Now())
+	if due {
+		err := s.refresh(ctx)
+		if err != nil && !ok {
+			return nil, err
+		}
+		// the keys read again may have dropped kid; when they could not be read, the known key is still used.
+		if err == nil {
+			key, ok, _ = s.lookup(kid, time.Now())
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup finds the key kid, and tells whether the keys are due to be read again at now: when they are older than
+// ttl or do not have kid, and were not tried to be read within the cooldown. A token without a kid may only be
+// signed by the single key of a set.
+func (s *keySet) lookup(kid string, now time.Time) (crypto.PublicKey, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[kid]
+	if kid == "" && len(s.keys) == 1 {
+		for _, only := range s.keys {
+			key, ok = only, true
+		}
+	}
+	due := now.Sub(s.attempted) >= s.cooldown && (!ok || now.Sub(s.fetched) >= s.ttl)
+	return key, ok, due
+}
+
+// refresh reads the keys once for all the callers waiting for them. The read is detached from the cancellation of
+// the caller that started it, so that it does not fail the others, and bounded by defaultJWKSTimeout; each caller
+// still stops waiting when its own context is done.
+func (s *keySet) refresh(ctx context.Context) error {
+	ch := s.group.DoChan("", func() (interface{}, error) {
+		readCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultJWKSTimeout)
+		defer cancel()
+		return nil, s.read(readCtx)
+	})
+
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// read reads the keys, keeping the ones read before when they cannot be read.
+func (s *keySet) read(ctx context.Context) error {
+	s.mu.Lock()
+	if time.Since(s.attempted) < s.cooldown {
+		// a read which ended after this caller found the keys due has just read them.
+		s.mu.Unlock()
+		return nil
+	}
+	// retry after the cooldown rather than on every request while the JWKS is unavailable.
+	s.attempted = time.Now()
+	s.mu.Unlock()
+
+	raw, err := s.fetch(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("error reading JWKS")
+		return fmt.Errorf("error reading JWKS: %w", err)
+	}
+
+	var set JWKS
+	if err := json.Unmarshal(raw, &set); err != nil {
+		log.Warn().Err(err).Msg("error decoding JWKS")
+		return fmt.Errorf("error decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// one unsupported key must not make the others unusable.
+			log.Warn().Err(err).Msg("skipping JWK")
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys, s.fetched = keys, time.Now()
+	return nil
+}
+
+func (s *keySet) fetch(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", s.url, resp.StatusCode)
+	}
+	// a key set is small: anything bigger is not one.
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
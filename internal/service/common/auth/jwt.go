@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Signing algorithms accepted for bearer tokens.
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// ErrInvalidToken is returned for every bearer token which is rejected.
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// TokenConsumerPrefix starts the names of the consumers of bearer tokens, so that a token cannot pass as a consumer
+// of the key store, whose names may not start with it.
+const TokenConsumerPrefix = "jwt:"
+
+const (
+	defaultJWKSCacheTTL = 10 * time.Minute
+	defaultJWKSCooldown = 30 * time.Second
+	defaultJWKSTimeout  = 5 * time.Second
+)
+
+// TokenConfig configures the verification of JWT bearer tokens.
+type TokenConfig struct {
+	// JWKSURL or JWKSFile is where the public keys of the identity provider are read from.
+	JWKSURL  string
+	JWKSFile string
+	// JWKSCacheTTL is how long keys are used before they are read again. It defaults to 10m.
+	JWKSCacheTTL time.Duration
+	// JWKSCooldown is how long to wait between reads of the keys prompted by tokens signed with an unknown key.
+	// It defaults to 30s.
+	JWKSCooldown time.Duration
+	// HTTPClient reads JWKSURL. It defaults to a client with a 5s timeout.
+	HTTPClient *http.Client
+
+	// Issuer and Audience must match the iss and aud claims of tokens.
+	Issuer   string
+	Audience string
+	// Leeway allows for clock skew when checking exp and nbf.
+	Leeway time.Duration
+
+	// ConsumerClaim names the claim identifying the consumer, such as sub or azp. The consumer is named after its
+	// value, prefixed with TokenConsumerPrefix.
+	ConsumerClaim string
+	// ScopesClaim names the claim granting scopes: a space-separated string, as in scope, or a list of strings.
+	ScopesClaim string
+	// ScopeMap maps the values of ScopesClaim onto scopes. Values missing from it are taken as scopes themselves.
+	ScopeMap map[string]string
+}
+
+// TokenVerifier verifies RS256 and ES256 signed JWT bearer tokens, and maps their claims onto consumers.
+type TokenVerifier struct {
+	cfg  TokenConfig
+	keys *keySet
+}
+
+func NewTokenVerifier(cfg TokenConfig) (*TokenVerifier, error) {
+	if (cfg.JWKSURL == "") == (cfg.JWKSFile == "") {
+		return nil, errors.New("one of a JWKS URL and a JWKS file is required")
+	}
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("an issuer and an audience are required to verify tokens")
+	}
+	if cfg.JWKSCacheTTL <= 0 {
+		cfg.JWKSCacheTTL = defaultJWKSCacheTTL
+	}
+	if cfg.JWKSCooldown <= 0 {
+		cfg.JWKSCooldown = defaultJWKSCooldown
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultJWKSTimeout}
+	}
+	if cfg.ConsumerClaim == "" {
+		cfg.ConsumerClaim = "sub"
+	}
+	if cfg.ScopesClaim == "" {
+		cfg.ScopesClaim = "scope"
+	}
+
+	return &TokenVerifier{
+		cfg: cfg,
+		keys: &keySet{
+			url:      cfg.JWKSURL,
+			file:     cfg.JWKSFile,
+			client:   cfg.HTTPClient,
+			ttl:      cfg.JWKSCacheTTL,
+			cooldown: cfg.JWKSCooldown,
+		},
+	}, nil
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature and the claims of token, and returns the consumer it identifies. Every error wraps
+// ErrInvalidToken.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (Consumer, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Consumer{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Consumer{}, fmt.Errorf("%w: malformed header: %w", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Consumer{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	key, err := v.keys.key(ctx, header.Kid)
+	if err != nil {
+		return Consumer{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, digest[:], signature); err != nil {
+		return Consumer{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Consumer{}, fmt.Errorf("%w: malformed claims: %w", ErrInvalidToken, err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Consumer{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	name, _ := claims[v.cfg.ConsumerClaim].(string)
+	if name == "" {
+		return Consumer{}, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.cfg.ConsumerClaim)
+	}
+
+	return Consumer{Name: TokenConsumerPrefix + name, KeyID: "jwt:" + header.Kid, Scopes: v.scopes(claims)}, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) error {
+	switch alg {
+	case AlgRS256:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("RS256 token signed with a non-RSA key")
+		}
+		if rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) != nil {
+			return errors.New("bad signature")
+		}
+	case AlgES256:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("ES256 token signed with a non-EC key")
+		}
+		// JWS signatures are the fixed-size r and s, rather than ASN.1.
+		if len(signature) != 64 {
+			return errors.New("bad signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("bad signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	return nil
+}
+
+func (v *TokenVerifier) checkClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return errors.New("missing exp claim")
+	}
+	if !now.Before(exp.Add(v.cfg.Leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.cfg.Leeway).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !slices.Contains(stringList(claims["aud"]), v.cfg.Audience) {
+		return errors.New("token not issued for this audience")
+	}
+
+	return nil
+}
+
+func (v *TokenVerifier) scopes(claims map[string]any) []string {
+	var scopes []string
+	for _, value := range stringList(claims[v.cfg.ScopesClaim]) {
+		if scope, ok := v.cfg.ScopeMap[value]; ok {
+			value = scope
+		}
+		scopes = append(scopes, value)
+	}
+	return scopes
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// numericDate reads a JWT date, in seconds since the epoch.
+func numericDate(claim any) (time.Time, bool) {
+	seconds, ok := claim.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+// stringList reads a claim which is either a space-separated string or a list of strings.
+func stringList(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,329 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/"
+	testAudience = "ods-gateway"
+)
+
+// identityProvider stands in for an identity provider, serving a JWKS of locally generated keys.
+type identityProvider struct {
+	mu      sync.Mutex
+	jwks    auth.JWKS
+	held    chan struct{}
+	fetches atomic.Int32
+	server  *httptest.Server
+}
+
+// helper to start an identity provider serving the JWKS of keys.
+func newIdentityProvider(t *testing.T, keys ...auth.JWK) *identityProvider {
+	t.Helper()
+
+	idp := &identityProvider{jwks: auth.JWKS{Keys: keys}}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		idp.fetches.Add(1)
+		idp.mu.Lock()
+		held := idp.held
+		idp.mu.Unlock()
+		if held != nil {
+			<-held
+		}
+
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(idp.jwks)
+	}))
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// hold makes the JWKS wait for release to be called before it is served.
+func (idp *identityProvider) hold() (release func()) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.held = make(chan struct{})
+	return sync.OnceFunc(func() { close(idp.held) })
+}
+
+func (idp *identityProvider) rotate(keys ...auth.JWK) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.jwks = auth.JWKS{Keys: keys}
+}
+
+// helper to sign a token with key, which is an *rsa.PrivateKey for RS256 or an *ecdsa.PrivateKey for ES256.
+func signToken(t *testing.T, key crypto.Signer, kid string, claims map[string]any) string {
+	t.Helper()
+
+	alg := auth.AlgRS256
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = auth.AlgES256
+	}
+
+	segment := func(v any) string {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signed := segment(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   testIssuer,
+		"aud":   []string{testAudience, "other-api"},
+		"sub":   "reporting-service",
+		"scope": "ods.read diagnostics:read",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newVerifier(t *testing.T, jwksURL string) *auth.TokenVerifier {
+	t.Helper()
+
+	verifier, err := auth.NewTokenVerifier(auth.TokenConfig{
+		JWKSURL:      jwksURL,
+		JWKSCooldown: time.Nanosecond,
+		Issuer:       testIssuer,
+		Audience:     testAudience,
+		ScopeMap:     map[string]string{"ods.read": auth.ScopeOrganisationsRead},
+	})
+	require.NoError(t, err)
+	return verifier
+}
+
+func TestTokenVerifier_AcceptsRS256AndES256(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	idp := newIdentityProvider(t, auth.RSAPublicJWK("rsa-1", &rsaKey.PublicKey), auth.ECPublicJWK("ec-1", &ecKey.PublicKey))
+	verifier := newVerifier(t, idp.server.URL)
+
+	for kid, key := range map[string]crypto.Signer{"rsa-1": rsaKey, "ec-1": ecKey} {
+		consumer, err := verifier.Verify(context.Background(), signToken(t, key, kid, validClaims()))
+		require.NoError(t, err, kid)
+		assert.Equal(t, "jwt:reporting-service", consumer.Name)
+		assert.Equal(t, "jwt:"+kid, consumer.KeyID)
+		assert.True(t, consumer.HasScope(auth.ScopeOrganisationsRead), "ods.read is mapped onto organisations:read")
+		assert.True(t, consumer.HasScope(auth.ScopeDiagnosticsRead))
+		assert.False(t, consumer.HasScope(auth.ScopeExport))
+	}
+
+	assert.EqualValues(t, 1, idp.fetches.Load(), "the JWKS is cached")
+}
+
+func TestTokenVerifier_SkipsWeakKeysAndKeysOfOtherAlgorithms(t *testing.T) {
+	t.Parallel()
+
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024) //nolint:gosec // a key too weak to be accepted
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rs384 := auth.RSAPublicJWK("rsa-384", &rsaKey.PublicKey)
+	rs384.Alg = "RS384"
+	unnamed := auth.ECPublicJWK("ec-unnamed", &ecKey.PublicKey)
+	unnamed.Alg = ""
+	idp := newIdentityProvider(t, auth.RSAPublicJWK("rsa-weak", &weakKey.PublicKey), rs384, unnamed)
+	verifier := newVerifier(t, idp.server.URL)
+
+	for kid, key := range map[string]crypto.Signer{"rsa-weak": weakKey, "rsa-384": rsaKey} {
+		_, err := verifier.Verify(context.Background(), signToken(t, key, kid, validClaims()))
+		assert.ErrorIs(t, err, auth.ErrInvalidToken, kid)
+	}
+
+	// alg is optional in a JWK.
+	_, err = verifier.Verify(context.Background(), signToken(t, ecKey, "ec-unnamed", validClaims()))
+	assert.NoError(t, err)
+}
+
+func TestTokenVerifier_RejectsInvalidTokens(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := newIdentityProvider(t, auth.RSAPublicJWK("rsa-1", &key.PublicKey))
+	verifier := newVerifier(t, idp.server.URL)
+
+	with := func(name string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	valid := signToken(t, key, "rsa-1", validClaims())
+
+	for name, token := range map[string]string{
+		"expired":            signToken(t, key, "rsa-1", with("exp", time.Now().Add(-time.Hour).Unix())),
+		"without expiry":     signToken(t, key, "rsa-1", with("exp", nil)),
+		"not yet valid":      signToken(t, key, "rsa-1", with("nbf", time.Now().Add(time.Hour).Unix())),
+		"wrong issuer":       signToken(t, key, "rsa-1", with("iss", "https://evil.example.com/")),
+		"wrong audience":     signToken(t, key, "rsa-1", with("aud", "other-api")),
+		"without consumer":   signToken(t, key, "rsa-1", with("sub", nil)),
+		"signed by another":  signToken(t, other, "rsa-1", validClaims()),
+		"unknown key":        signToken(t, other, "rsa-2", validClaims()),
+		"tampered claims":    valid[:len(valid)/2] + "x" + valid[len(valid)/2+1:],
+		"unsigned":           base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + valid[len("x"):],
+		"not a token at all": "secret",
+	} {
+		_, err := verifier.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, auth.ErrInvalidToken, name)
+	}
+}
+
+func TestTokenVerifier_NamesConsumersApartFromTheKeyStore(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := newIdentityProvider(t, auth.RSAPublicJWK("rsa-1", &key.PublicKey))
+	verifier := newVerifier(t, idp.server.URL)
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{
+		{Name: "default", Scopes: []string{auth.ScopeExport}, Keys: []auth.KeyConfig{{SHA256: auth.HashKey("k")}}},
+	})
+	require.NoError(t, err)
+	owner, err := store.Authenticate("k", time.Now())
+	require.NoError(t, err)
+
+	// a subject named after a consumer of the key store does not share its limits, usage or audit trail.
+	claims := validClaims()
+	claims["sub"] = "default"
+	consumer, err := verifier.Verify(context.Background(), signToken(t, key, "rsa-1", claims))
+	require.NoError(t, err)
+	assert.Equal(t, "jwt:default", consumer.Name)
+	assert.NotEqual(t, owner.Name, consumer.Name)
+}
+
+func TestTokenVerifier_FollowsKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	idp := newIdentityProvider(t, auth.ECPublicJWK("2026-04", &oldKey.PublicKey))
+	verifier := newVerifier(t, idp.server.URL)
+
+	_, err = verifier.Verify(context.Background(), signToken(t, oldKey, "2026-04", validClaims()))
+	require.NoError(t, err)
+
+	// the identity provider publishes the new key, and signs with it from now on.
+	idp.rotate(auth.ECPublicJWK("2026-04", &oldKey.PublicKey), auth.ECPublicJWK("2026-10", &newKey.PublicKey))
+
+	_, err = verifier.Verify(context.Background(), signToken(t, newKey, "2026-10", validClaims()))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, idp.fetches.Load())
+}
+
+func TestTokenVerifier_ReadsKeysWithoutBlockingCallers(t *testing.T) {
+	t.Parallel()
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	idp := newIdentityProvider(t, auth.ECPublicJWK("2026-04", &oldKey.PublicKey))
+	verifier := newVerifier(t, idp.server.URL)
+	_, err = verifier.Verify(context.Background(), signToken(t, oldKey, "2026-04", validClaims()))
+	require.NoError(t, err)
+
+	idp.rotate(auth.ECPublicJWK("2026-04", &oldKey.PublicKey), auth.ECPublicJWK("2026-10", &newKey.PublicKey))
+	release := idp.hold()
+	t.Cleanup(release)
+
+	// a token signed by the new key waits for the keys to be read.
+	verified := make(chan error, 1)
+	go func() {
+		_, err := verifier.Verify(context.Background(), signToken(t, newKey, "2026-10", validClaims()))
+		verified <- err
+	}()
+	require.Eventually(t, func() bool { return idp.fetches.Load() == 2 }, time.Second, time.Millisecond)
+
+	// meanwhile, tokens signed by the known key are verified, and a caller giving up does not wait for the read.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = verifier.Verify(ctx, signToken(t, oldKey, "2026-04", validClaims()))
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = verifier.Verify(ctx, signToken(t, newKey, "2026-10", validClaims()))
+	require.ErrorIs(t, err, context.Canceled)
+
+	release()
+	require.NoError(t, <-verified)
+	assert.EqualValues(t, 2, idp.fetches.Load(), "the callers share the read")
+}
+
+func TestTokenVerifier_ReadsJWKSFile(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	raw, err := json.Marshal(auth.JWKS{Keys: []auth.JWK{auth.RSAPublicJWK("rsa-1", &key.PublicKey)}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	verifier, err := auth.NewTokenVerifier(auth.TokenConfig{JWKSFile: path, Issuer: testIssuer, Audience: testAudience})
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), signToken(t, key, "rsa-1", validClaims()))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scopes granted to consumers.
+const (
+	ScopeOrganisationsRead = "organisations:read"
+	ScopeDiagnosticsRead   = "diagnostics:read"
+	ScopeExport            = "export"
+	// ScopeAll grants every scope.
+	ScopeAll = "*"
+)
+
+var knownScopes = []string{ScopeOrganisationsRead, ScopeDiagnosticsRead, ScopeExport, ScopeAll}
+
+// KnownScope reports whether scope is one of the scopes above.
+func KnownScope(scope string) bool {
+	return slices.Contains(knownScopes, scope)
+}
+
+// Methods by which consumers authenticate.
+const (
+	MethodBearer      = "bearer"
+	MethodAPIKey      = "api-key"
+	MethodCertificate = "certificate"
+)
+
+var knownMethods = []string{MethodBearer, MethodAPIKey, MethodCertificate}
+
+// KnownMethod reports whether method is one of the methods above.
+func KnownMethod(method string) bool {
+	return slices.Contains(knownMethods, method)
+}
+
+var (
+	ErrUnknownKey     = errors.New("invalid api key")
+	ErrKeyNotYetValid = errors.New("api key not yet valid")
+	ErrKeyExpired     = errors.New("api key expired")
+
+	ErrUnknownCertificate = errors.New("client certificate not mapped to a consumer")
+)
+
+// ConsumerConfig declares a consumer of the API, the scopes it is granted and its keys.
+type ConsumerConfig struct {
+	Name   string      `json:"name"   yaml:"name"`
+	Scopes []string    `json:"scopes" yaml:"scopes"`
+	Keys   []KeyConfig `json:"keys"   yaml:"keys"`
+	// CertificateNames identify the consumer by a verified client certificate, instead of or as well as keys: a name
+	// matches the subject of a certificate (e.g. "CN=gp-portal,O=Example"), its common name, or one of its DNS,
+	// URI or email subject alternative names.
+	CertificateNames []string `json:"certificateNames,omitempty" yaml:"certificateNames,omitempty"`
+	// Limits override the default limits of consumers.
+	Limits *Limits `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// Limits bound the requests of a consumer, shared by all of its keys so that rotating a key does not reset them.
+// Zero values are unlimited.
+type Limits struct {
+	// RateLimit is in requests per second, with bursts of up to RateBurst requests.
+	RateLimit float64 `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	RateBurst int     `json:"rateBurst,omitempty" yaml:"rateBurst,omitempty"`
+	// DailyQuota is the number of requests allowed per day, from midnight UTC.
+	DailyQuota int `json:"dailyQuota,omitempty" yaml:"dailyQuota,omitempty"`
+}
+
+// Validate reports the first problem found in the limits.
+func (l Limits) Validate() error {
+	switch {
+	case l.RateLimit < 0 || l.RateBurst < 0 || l.DailyQuota < 0:
+		return errors.New("limits must not be negative")
+	case l.RateLimit > 0 && l.RateBurst == 0:
+		return errors.New("a rate burst is required with a rate limit")
+	}
+	return nil
+}
+
+// KeyConfig declares an API key by the hex-encoded SHA-256 hash of the key, which is never stored itself. The keys
+// of a consumer may be valid at the same time, so that a key can be rotated without downtime: the new key is added
+// before the old one expires.
+type KeyConfig struct {
+	// ID names the key in logs. It defaults to the first 8 characters of the hash.
+	ID     string `json:"id,omitempty" yaml:"id,omitempty"`
+	SHA256 string `json:"sha256"       yaml:"sha256"`
+	// NotBefore and ExpiresAt bound the validity of the key, when set.
+	NotBefore time.Time `json:"notBefore,omitempty" yaml:"notBefore,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+}
+
+// Consumer is the identity of an authenticated caller.
+type Consumer struct {
+	Name   string
+	KeyID  string
+	Scopes []string
+	// Limits are the limits of the consumer, or nil when the default limits apply.
+	Limits *Limits
+}
+
+// HasScope reports whether the consumer is granted scope.
+func (c Consumer) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope) || slices.Contains(c.Scopes, ScopeAll)
+}
+
+// KeyStore authenticates API keys against the hashes of the keys of every consumer, and client certificates
+// against their certificate names.
+type KeyStore struct {
+	keys  map[[sha256.Size]byte]storedKey
+	certs map[string]Consumer
+}
+
+type storedKey struct {
+	consumer  Consumer
+	notBefore time.Time
+	expiresAt time.Time
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of key, as declared in KeyConfig.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseKeyStore reads consumers from a YAML or JSON key store file, e.g.
+//
+//	consumers:
+//	  - name: gp-portal
+//	    scopes: [organisations:read]
+//	    keys:
+//	      - id: 2026-10
+//	        sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	        expiresAt: 2027-04-01T00:00:00Z
+func ParseKeyStore(raw []byte) ([]ConsumerConfig, error) {
+	var file struct {
+		Consumers []ConsumerConfig `yaml:"consumers"`
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return file.Consumers, nil
+}
+
+// NewKeyStore checks the consumers and indexes their keys, reporting every problem found at once.
+func NewKeyStore(consumers []ConsumerConfig) (*KeyStore, error) {
+	s := &KeyStore{keys: make(map[[sha256.Size]byte]storedKey), certs: make(map[string]Consumer)}
+
+	var errs []error
+	names := make(map[string]struct{}, len(consumers))
+	for _, consumer := range consumers {
+		if consumer.Name == "" {
+			errs = append(errs, errors.New("a consumer needs a name"))
+			continue
+		}
+		if _, ok := names[consumer.Name]; ok {
+			errs = append(errs, fmt.Errorf("duplicate consumer %q", consumer.Name))
+			continue
+		}
+		if strings.HasPrefix(consumer.Name, TokenConsumerPrefix) {
+			errs = append(errs, fmt.Errorf("consumer %q: names starting with %q are those of bearer tokens",
+				consumer.Name, TokenConsumerPrefix))
+		}
+		names[consumer.Name] = struct{}{}
+
+		for _, scope := range consumer.Scopes {
+			if !KnownScope(scope) {
+				errs = append(errs, fmt.Errorf("consumer %q: unknown scope %q", consumer.Name, scope))
+			}
+		}
+		if consumer.Limits != nil {
+			if err := consumer.Limits.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("consumer %q: %w", consumer.Name, err))
+			}
+		}
+		if len(consumer.Keys) == 0 && len(consumer.CertificateNames) == 0 {
+			errs = append(errs, fmt.Errorf("consumer %q has no keys or certificate names", consumer.Name))
+		}
+		for _, name := range consumer.CertificateNames {
+			if owner, ok := s.certs[name]; ok {
+				errs = append(errs, fmt.Errorf("consumer %q: certificate name %q is also declared by %q", consumer.Name, name, owner.Name))
+				continue
+			}
+			s.certs[name] = Consumer{Name: consumer.Name, Scopes: consumer.Scopes, Limits: consumer.Limits}
+		}
+
+		for _, key := range consumer.Keys {
+			hash, err := hex.DecodeString(key.SHA256)
+			if err != nil || len(hash) != sha256.Size {
+				errs = append(errs, fmt.Errorf("consumer %q: sha256 of key %q must be 64 hex characters", consumer.Name, key.ID))
+				continue
+			}
+			if !key.NotBefore.IsZero() && !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(key.NotBefore) {
+				errs = append(errs, fmt.Errorf("consumer %q: key %q expires before it is valid", consumer.Name, key.ID))
+			}
+
+			id := key.ID
+			if id == "" {
+				id = key.SHA256[:8]
+			}
+			if _, ok := s.keys[[sha256.Size]byte(hash)]; ok {
+				errs = append(errs, fmt.Errorf("consumer %q: key %q is declared more than once", consumer.Name, id))
+				continue
+			}
+			s.keys[[sha256.Size]byte(hash)] = storedKey{
+				consumer:  Consumer{Name: consumer.Name, KeyID: id, Scopes: consumer.Scopes, Limits: consumer.Limits},
+				notBefore: key.NotBefore,
+				expiresAt: key.ExpiresAt,
+			}
+		}
+	}
+
+	return s, errors.Join(errs...)
+}
+
+// Authenticate returns the consumer owning key, if the key is valid at now.
+func (s *KeyStore) Authenticate(key string, now time.Time) (Consumer, error) {
+	// keys are looked up by hash, so the time taken reveals nothing about the keys stored.
+	stored, ok := s.keys[sha256.Sum256([]byte(key))]
+	switch {
+	case !ok:
+		return Consumer{}, ErrUnknownKey
+	case !stored.notBefore.IsZero() && now.Before(stored.notBefore):
+		return Consumer{}, ErrKeyNotYetValid
+	case !stored.expiresAt.IsZero() && !now.Before(stored.expiresAt):
+		return Consumer{}, ErrKeyExpired
+	}
+
+	return stored.consumer, nil
+}
+
+// AuthenticateCertificate returns the consumer a verified client certificate is mapped to. Names are tried from the
+// most specific, the whole subject, to the least.
+func (s *KeyStore) AuthenticateCertificate(cert *x509.Certificate) (Consumer, error) {
+	names := []string{cert.Subject.String(), cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+
+	for _, name := range names {
+		if consumer, ok := s.certs[name]; ok && name != "" {
+			consumer.KeyID = "cert:" + cert.SerialNumber.Text(16)
+			return consumer, nil
+		}
+	}
+	return Consumer{}, ErrUnknownCertificate
+}
+
+// Empty reports whether the store holds no keys and no certificate names, so that every request would be rejected.
+func (s *KeyStore) Empty() bool {
+	return len(s.keys) == 0 && len(s.certs) == 0
+}
@@ -0,0 +1,151 @@
+package auth_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+)
+
+func TestKeyStore_RotatesOverlappingKeys(t *testing.T) {
+	t.Parallel()
+
+	rotation := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{{
+		Name:   "gp-portal",
+		Scopes: []string{auth.ScopeOrganisationsRead},
+		Keys: []auth.KeyConfig{
+			{ID: "old", SHA256: auth.HashKey("old-key"), ExpiresAt: rotation.Add(24 * time.Hour)},
+			{ID: "new", SHA256: auth.HashKey("new-key"), NotBefore: rotation},
+		},
+	}})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		key   string
+		at    time.Time
+		keyID string
+		err   error
+	}{
+		"old key before rotation": {key: "old-key", at: rotation.Add(-time.Hour), keyID: "old"},
+		"new key before rotation": {key: "new-key", at: rotation.Add(-time.Hour), err: auth.ErrKeyNotYetValid},
+		"old key during overlap":  {key: "old-key", at: rotation.Add(time.Hour), keyID: "old"},
+		"new key during overlap":  {key: "new-key", at: rotation.Add(time.Hour), keyID: "new"},
+		"old key after expiry":    {key: "old-key", at: rotation.Add(24 * time.Hour), err: auth.ErrKeyExpired},
+		"unknown key":             {key: "guess", at: rotation, err: auth.ErrUnknownKey},
+		"new key long after":      {key: "new-key", at: rotation.AddDate(1, 0, 0), keyID: "new"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			consumer, err := store.Authenticate(tc.key, tc.at)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "gp-portal", consumer.Name)
+			assert.Equal(t, tc.keyID, consumer.KeyID)
+			assert.True(t, consumer.HasScope(auth.ScopeOrganisationsRead))
+			assert.False(t, consumer.HasScope(auth.ScopeExport))
+		})
+	}
+}
+
+func TestNewKeyStore_ReportsEveryProblem(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	_, err := auth.NewKeyStore([]auth.ConsumerConfig{
+		{Name: "a", Scopes: []string{"organisations:write"}, Keys: []auth.KeyConfig{{SHA256: "not-hex"}}},
+		{Name: "a", Keys: []auth.KeyConfig{{SHA256: auth.HashKey("k")}}},
+		{Name: "b"},
+		{Name: "c", Keys: []auth.KeyConfig{{ID: "k", SHA256: auth.HashKey("k"), NotBefore: now, ExpiresAt: now}}},
+		{Name: "d", Keys: []auth.KeyConfig{{SHA256: auth.HashKey("d")}}, Limits: &auth.Limits{RateLimit: 1}},
+		{Name: "jwt:reporting-service", Keys: []auth.KeyConfig{{SHA256: auth.HashKey("e")}}},
+	})
+	require.Error(t, err)
+
+	for _, want := range []string{
+		`unknown scope "organisations:write"`,
+		"must be 64 hex characters",
+		`duplicate consumer "a"`,
+		`consumer "b" has no keys or certificate names`,
+		`key "k" expires before it is valid`,
+		`consumer "d": a rate burst is required with a rate limit`,
+		`consumer "jwt:reporting-service": names starting with "jwt:" are those of bearer tokens`,
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestKeyStore_AuthenticatesClientCertificates(t *testing.T) {
+	t.Parallel()
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{
+		{Name: "gp-portal", Scopes: []string{auth.ScopeOrganisationsRead}, CertificateNames: []string{"gp-portal.hscn.example"}},
+		{Name: "reporting", Scopes: []string{auth.ScopeExport}, CertificateNames: []string{"CN=reporting,O=Example"}},
+	})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		cert     *x509.Certificate
+		consumer string
+	}{
+		"DNS name": {
+			cert:     &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, DNSNames: []string{"gp-portal.hscn.example"}},
+			consumer: "gp-portal",
+		},
+		"common name": {cert: &x509.Certificate{Subject: pkix.Name{CommonName: "gp-portal.hscn.example"}}, consumer: "gp-portal"},
+		"subject": {
+			cert:     &x509.Certificate{Subject: pkix.Name{CommonName: "reporting", Organization: []string{"Example"}}},
+			consumer: "reporting",
+		},
+		"common name of another subject": {cert: &x509.Certificate{Subject: pkix.Name{CommonName: "reporting"}}},
+		"unmapped":                       {cert: &x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}}},
+	} {
+		tc.cert.SerialNumber = big.NewInt(0xabc)
+
+		consumer, err := store.AuthenticateCertificate(tc.cert)
+		if tc.consumer == "" {
+			assert.ErrorIs(t, err, auth.ErrUnknownCertificate, name)
+			continue
+		}
+		require.NoError(t, err, name)
+		assert.Equal(t, tc.consumer, consumer.Name, name)
+		assert.Equal(t, "cert:abc", consumer.KeyID, name)
+	}
+
+	_, err = auth.NewKeyStore([]auth.ConsumerConfig{
+		{Name: "a", CertificateNames: []string{"shared"}},
+		{Name: "b", CertificateNames: []string{"shared"}},
+	})
+	assert.ErrorContains(t, err, `certificate name "shared" is also declared by "a"`)
+}
+
+func TestParseKeyStore(t *testing.T) {
+	t.Parallel()
+
+	consumers, err := auth.ParseKeyStore([]byte(`
+consumers:
+  - name: gp-portal
+    scopes: [organisations:read, export]
+    keys:
+      - id: 2026-10
+        sha256: ` + auth.HashKey("key") + `
+        expiresAt: 2027-04-01T00:00:00Z
+`))
+	require.NoError(t, err)
+	require.Len(t, consumers, 1)
+	assert.Equal(t, []string{auth.ScopeOrganisationsRead, auth.ScopeExport}, consumers[0].Scopes)
+	assert.Equal(t, time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC), consumers[0].Keys[0].ExpiresAt)
+
+	_, err = auth.ParseKeyStore([]byte("consumers:\n  - name: gp-portal\n    key: plain-text\n"))
+	assert.ErrorContains(t, err, "field key not found")
+}
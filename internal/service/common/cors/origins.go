@@ -0,0 +1,132 @@
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// AnyOrigin allows every origin.
+	AnyOrigin = "*"
+	// RegexPrefix marks an origin given as a regular expression, e.g. `regex:https://pr-\d+\.example\.com`. The
+	// expression is anchored, so it must match the whole origin.
+	RegexPrefix = "regex:"
+)
+
+// label matches a single DNS label.
+const label = `[a-z0-9]([a-z0-9-]*[a-z0-9])?`
+
+// Origins matches the Origin header of cross-origin requests against a list of allowed origins, each one of:
+//
+//   - an exact origin, e.g. https://app.example.com,
+//   - a wildcard subdomain, e.g. https://*.example.com, matching one or more labels before example.com,
+//   - either of the above with a wildcard port, e.g. http://localhost:*, also matching the default port,
+//   - a regular expression prefixed with RegexPrefix, matched against the whole origin,
+//   - AnyOrigin.
+//
+// Exact and wildcard origins are matched regardless of case.
+type Origins struct {
+	any      bool
+	exact    map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// ParseOrigins parses allowed origins, reporting every invalid one at once.
+func ParseOrigins(origins []string) (*Origins, error) {
+	o := &Origins{exact: make(map[string]struct{})}
+
+	var errs []error
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+
+		switch {
+		case origin == AnyOrigin:
+			o.any = true
+		case strings.HasPrefix(origin, RegexPrefix):
+			re, err := regexp.Compile(`^(?:` + strings.TrimPrefix(origin, RegexPrefix) + `)$`)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid CORS origin %q: %w", origin, err))
+				continue
+			}
+			o.patterns = append(o.patterns, re)
+		case strings.Contains(origin, "*"):
+			re, err := wildcardPattern(origin)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			o.patterns = append(o.patterns, re)
+		default:
+			if err := checkOrigin(origin); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			o.exact[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+
+	return o, errors.Join(errs...)
+}
+
+// AllowsAny reports whether every origin is allowed.
+func (o *Origins) AllowsAny() bool {
+	return o.any
+}
+
+// Allows reports whether origin is allowed.
+func (o *Origins) Allows(origin string) bool {
+	if o.any {
+		return true
+	}
+	if _, ok := o.exact[strings.ToLower(origin)]; ok {
+		return true
+	}
+	for _, re := range o.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOrigin checks that origin is a scheme, a host and optionally a port, as sent in the Origin header.
+func checkOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid CORS origin %q: want scheme://host[:port]", origin)
+	}
+	return nil
+}
+
+// wildcardPattern compiles an origin with a wildcard subdomain and/or a wildcard port.
+func wildcardPattern(origin string) (*regexp.Regexp, error) {
+	invalid := fmt.Errorf("invalid CORS origin %q: only a leading *. subdomain and a :* port may be wildcards", origin)
+
+	scheme, host, ok := strings.Cut(strings.ToLower(origin), "://")
+	if !ok {
+		return nil, invalid
+	}
+
+	port := ""
+	if strings.HasSuffix(host, ":*") {
+		host = strings.TrimSuffix(host, ":*")
+		port = `(:[0-9]+)?`
+	}
+
+	subdomain := ""
+	if strings.HasPrefix(host, "*.") {
+		host = strings.TrimPrefix(host, "*.")
+		subdomain = `(` + label + `\.)+`
+	}
+
+	// what is left must be a plain origin.
+	if strings.Contains(host, "*") || checkOrigin(scheme+"://"+host) != nil {
+		return nil, invalid
+	}
+
+	return regexp.MustCompile(`(?i)^` + regexp.QuoteMeta(scheme+"://") + subdomain + regexp.QuoteMeta(host) + port + `$`), nil
+}
@@ -0,0 +1,74 @@
+package cors_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/cors"
+)
+
+func TestOrigins_Allows(t *testing.T) {
+	t.Parallel()
+
+	origins, err := cors.ParseOrigins([]string{
+		"https://integration.elevate.cleosystems.com",
+		"https://*.elevate-dev.cleosystems.com",
+		"http://localhost:*",
+		`regex:^https://pr-[0-9]+\.preview\.example\.com$`,
+		`regex:https://pr-[0-9]+\.staging\.example\.com`,
+	})
+	require.NoError(t, err)
+	assert.False(t, origins.AllowsAny())
+
+	for origin, allowed := range map[string]bool{
+		"https://integration.elevate.cleosystems.com":        true,
+		"https://INTEGRATION.elevate.cleosystems.com":        true,
+		"https://integration.elevate.cleosystems.com:444":    false,
+		"http://integration.elevate.cleosystems.com":         false,
+		"https://app.elevate-dev.cleosystems.com":            true,
+		"https://a.b.elevate-dev.cleosystems.com":            true,
+		"https://elevate-dev.cleosystems.com":                false,
+		"https://evil.com/.elevate-dev.cleosystems.com":      false,
+		"https://app.elevate-dev.cleosystems.com.evil":       false,
+		"https://app.elevate-dev.cleosystems.com:8443":       false,
+		"http://localhost":                                   true,
+		"http://localhost:3000":                              true,
+		"http://localhost.evil.com":                          false,
+		"https://pr-42.preview.example.com":                  true,
+		"https://pr-x.preview.example.com":                   false,
+		"https://pr-1.staging.example.com":                   true,
+		"https://pr-1.staging.example.com.evil.net":          false,
+		"https://evil.net/?https://pr-1.staging.example.com": false,
+	} {
+		assert.Equal(t, allowed, origins.Allows(origin), origin)
+	}
+}
+
+func TestParseOrigins_AllowsAny(t *testing.T) {
+	t.Parallel()
+
+	origins, err := cors.ParseOrigins([]string{cors.AnyOrigin})
+	require.NoError(t, err)
+	assert.True(t, origins.AllowsAny())
+	assert.True(t, origins.Allows("https://anything.example"))
+}
+
+func TestParseOrigins_ReportsEveryInvalidOrigin(t *testing.T) {
+	t.Parallel()
+
+	invalid := []string{
+		"app.example.com",
+		"https://app.example.com/path",
+		"https://app.*.example.com",
+		"ftp://*.example.com",
+		"regex:^https://(",
+	}
+
+	_, err := cors.ParseOrigins(append([]string{"https://app.example.com"}, invalid...))
+	require.Error(t, err)
+	for _, origin := range invalid {
+		assert.ErrorContains(t, err, origin)
+	}
+}
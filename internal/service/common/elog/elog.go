@@ -1,40 +1,150 @@
 package elog
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-func Init() {
+// Init sets up the global logger, given a level accepted by ParseLevel.
+func Init(level string) {
 	if os.Getenv("APP_ENV") == "local" {
-		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr}) // good-looking logger in local env
+		// good-looking logger in local env
+		log.Logger = log.Output(zerolog.MultiLevelWriter(zerolog.ConsoleWriter{Out: os.Stderr}, SeverityHook{}))
 	} else {
 		zerolog.TimeFieldFormat = time.RFC3339
-		log.Logger = log.Hook(SeverityHook{})
+		log.Logger = log.Output(zerolog.MultiLevelWriter(os.Stderr, SeverityHook{}))
 	}
 
-	logLevel := os.Getenv("LOG_LEVEL")
-	switch logLevel {
-	case "DEBUG":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case "INFO":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	case "WARN":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
-	case "ERROR":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
-	default:
-		log.Panic().Msgf("Unrecognised LOG_LEVEL set: %s", logLevel)
+	if err := SetLevel(level); err != nil {
+		log.Panic().Msgf("Unrecognised LOG_LEVEL set: %s", level)
 	}
 }
 
+// levels are the log levels which can be set, by LOG_LEVEL or at runtime.
+var levels = map[string]zerolog.Level{
+	"DEBUG": zerolog.DebugLevel,
+	"INFO":  zerolog.InfoLevel,
+	"WARN":  zerolog.WarnLevel,
+	"ERROR": zerolog.ErrorLevel,
+}
+
+// ParseLevel parses one of DEBUG, INFO, WARN or ERROR, in any case.
+func ParseLevel(name string) (zerolog.Level, error) {
+	level, ok := levels[strings.ToUpper(name)]
+	if !ok {
+		return zerolog.NoLevel, fmt.Errorf("unrecognised log level %q", name)
+	}
+	return level, nil
+}
+
+// SetLevel changes the level of every logger, given a level accepted by ParseLevel.
+func SetLevel(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+
+	zerolog.SetGlobalLevel(level)
+	return nil
+}
+
+// Level returns the name of the current log level, as accepted by SetLevel.
+func Level() string {
+	return strings.ToUpper(zerolog.GlobalLevel().String())
+}
+
+// Ctx returns the request-scoped logger attached to ctx, or the global logger outside of a request.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log.Logger
+}
+
+// tagFields are the fields of a log event sent as tags of its error report, rather than as extra data.
+var tagFields = map[string]struct{}{
+	"requestId":     {},
+	"correlationId": {},
+	"traceId":       {},
+	"route":         {},
+	"apiKeyId":      {},
+	"consumer":      {},
+	"code":          {},
+}
+
+// fatalFlushTimeout bounds how long a fatal event waits for its report to be sent before the process exits.
+const fatalFlushTimeout = 2 * time.Second
+
+// SeverityHook reports error, fatal and panic log events to the current Reporter. It is a zerolog.LevelWriter rather
+// than a zerolog.Hook, because hooks cannot see the fields of an event: it is installed next to the log output with
+// zerolog.MultiLevelWriter, and decodes each event it reports.
 type SeverityHook struct{}
 
-func (h SeverityHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
-	// if level == zerolog.ErrorLevel || level == zerolog.FatalLevel {
-	//TODO: report to e.g. rollbar here
-	// }
+var _ zerolog.LevelWriter = SeverityHook{}
+
+func (h SeverityHook) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (h SeverityHook) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.ErrorLevel || level > zerolog.PanicLevel {
+		return len(p), nil
+	}
+
+	reporter := CurrentReporter()
+	reporter.Report(newEvent(level, p))
+
+	if level >= zerolog.FatalLevel {
+		ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+		defer cancel()
+		_ = reporter.Flush(ctx)
+	}
+
+	return len(p), nil
+}
+
+// newEvent decodes a JSON log event into the event reported.
+func newEvent(level zerolog.Level, p []byte) Event {
+	event := Event{Time: time.Now(), Level: level}
+
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		event.Message = string(p)
+		return event
+	}
+
+	for key, value := range fields {
+		switch key {
+		case zerolog.LevelFieldName, zerolog.TimestampFieldName:
+		case zerolog.MessageFieldName:
+			event.Message = fmt.Sprint(value)
+		case zerolog.ErrorFieldName:
+			event.Error = fmt.Sprint(value)
+		case ErrorTypeField:
+			event.ErrorType = fmt.Sprint(value)
+		case StackField:
+			event.Stack = fmt.Sprint(value)
+		default:
+			if _, ok := tagFields[key]; ok {
+				if event.Tags == nil {
+					event.Tags = make(map[string]string)
+				}
+				event.Tags[key] = fmt.Sprint(value)
+				continue
+			}
+			if event.Extra == nil {
+				event.Extra = make(map[string]any)
+			}
+			event.Extra[key] = value
+		}
+	}
+
+	return event
 }
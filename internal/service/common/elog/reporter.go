@@ -0,0 +1,179 @@
+package elog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+)
+
+// Fields with a special meaning to error reports.
+const (
+	// ErrorTypeField names the type of the error logged, which reports are grouped by.
+	ErrorTypeField = "errorType"
+	// StackField holds the stack trace of a recovered panic.
+	StackField = "stack"
+)
+
+// Event is an error log event to be reported.
+type Event struct {
+	Time      time.Time
+	Level     zerolog.Level
+	Message   string
+	Error     string
+	ErrorType string
+	Stack     string
+	// Tags are short, searchable values such as the request ID; Extra holds every other field of the log event.
+	Tags  map[string]string
+	Extra map[string]any
+}
+
+// Fingerprint groups events of the same error type logged with the same message.
+func (e Event) Fingerprint() []string {
+	errorType := e.ErrorType
+	if errorType == "" {
+		errorType = "unknown"
+	}
+	return []string{errorType, e.Message}
+}
+
+// Reporter sends error events to an error tracking service.
+type Reporter interface {
+	// Report queues an event without blocking.
+	Report(event Event)
+	// Flush waits until the queued events are sent or ctx is done.
+	Flush(ctx context.Context) error
+}
+
+// NopReporter drops every event. It is used until another reporter is configured.
+type NopReporter struct{}
+
+func (NopReporter) Report(Event) {}
+
+func (NopReporter) Flush(context.Context) error { return nil }
+
+var current atomic.Pointer[Reporter]
+
+// SetReporter makes r the reporter of error log events.
+func SetReporter(r Reporter) {
+	current.Store(&r)
+}
+
+// CurrentReporter returns the reporter of error log events.
+func CurrentReporter() Reporter {
+	if r := current.Load(); r != nil {
+		return *r
+	}
+	return NopReporter{}
+}
+
+// ErrorType names the type of the root cause of err, e.g. "*net.OpError", to group reports by. Application errors
+// are named by their kind, followed by the type of their cause, e.g. "upstream unavailable: *url.Error".
+func ErrorType(err error) string {
+	if err == nil {
+		return ""
+	}
+	if appErr, ok := apperrors.From(err); ok {
+		if appErr.Err == nil {
+			return appErr.Kind.Error()
+		}
+		return appErr.Kind.Error() + ": " + ErrorType(appErr.Err)
+	}
+
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			break
+		}
+		err = next
+	}
+	return reflect.TypeOf(err).String()
+}
+
+// maxFingerprints bounds the memory held by Deduplicate.
+const maxFingerprints = 1000
+
+type deduplicator struct {
+	next   Reporter
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]*seenFingerprint
+}
+
+type seenFingerprint struct {
+	reported   time.Time
+	suppressed int
+}
+
+// Deduplicate reports an event to next at most once per window for each fingerprint. The number of duplicates
+// suppressed in between is added to the next report as the "suppressed" extra.
+func Deduplicate(next Reporter, window time.Duration) Reporter {
+	if window <= 0 {
+		return next
+	}
+
+	return &deduplicator{
+		next:   next,
+		window: window,
+		now:    time.Now,
+		seen:   make(map[string]*seenFingerprint),
+	}
+}
+
+func (d *deduplicator) Report(event Event) {
+	key := fmt.Sprintf("%q", event.Fingerprint())
+	now := d.now()
+
+	d.mu.Lock()
+	seen, ok := d.seen[key]
+	if ok && now.Sub(seen.reported) < d.window {
+		seen.suppressed++
+		d.mu.Unlock()
+		return
+	}
+
+	suppressed := 0
+	if ok {
+		suppressed = seen.suppressed
+	}
+	if len(d.seen) >= maxFingerprints {
+		d.forgetExpired(now)
+	}
+	// when every fingerprint is still within the window, new ones are reported without being tracked.
+	if ok || len(d.seen) < maxFingerprints {
+		d.seen[key] = &seenFingerprint{reported: now}
+	}
+	d.mu.Unlock()
+
+	if suppressed > 0 {
+		extra := make(map[string]any, len(event.Extra)+1)
+		for k, v := range event.Extra {
+			extra[k] = v
+		}
+		extra["suppressed"] = suppressed
+		event.Extra = extra
+	}
+	d.next.Report(event)
+}
+
+// forgetExpired drops the fingerprints outside the window. It must be called with d.mu held.
+func (d *deduplicator) forgetExpired(now time.Time) {
+	for key, seen := range d.seen {
+		if now.Sub(seen.reported) >= d.window {
+			delete(d.seen, key)
+		}
+	}
+}
+
+func (d *deduplicator) Flush(ctx context.Context) error {
+	return d.next.Flush(ctx)
+}
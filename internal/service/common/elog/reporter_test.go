@@ -0,0 +1,123 @@
+package elog_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+)
+
+type recordingReporter struct {
+	mu      sync.Mutex
+	events  []elog.Event
+	flushes int
+}
+
+func (r *recordingReporter) Report(event elog.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes++
+	return nil
+}
+
+func (r *recordingReporter) Events() []elog.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]elog.Event(nil), r.events...)
+}
+
+func TestErrorType(t *testing.T) {
+	t.Parallel()
+
+	opErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	assert.Equal(t, "*errors.errorString", elog.ErrorType(errors.New("boom")))
+	assert.Equal(t, "*errors.errorString", elog.ErrorType(fmt.Errorf("calling ODS: %w", opErr)))
+	assert.Equal(t, "not found", elog.ErrorType(apperrors.New(apperrors.ErrNotFound, "organisation X26 not found")))
+	assert.Equal(t, "upstream unavailable: *errors.errorString",
+		elog.ErrorType(apperrors.Wrap(apperrors.ErrUpstreamUnavailable, opErr, "ODS API unavailable")))
+	assert.Empty(t, elog.ErrorType(nil))
+}
+
+func TestDeduplicate_SuppressesDuplicatesWithinWindow(t *testing.T) {
+	t.Parallel()
+
+	next := &recordingReporter{}
+	reporter := elog.Deduplicate(next, 50*time.Millisecond)
+
+	boom := elog.Event{Message: "request failed", ErrorType: "*url.Error"}
+	for range 3 {
+		reporter.Report(boom)
+	}
+	reporter.Report(elog.Event{Message: "request failed", ErrorType: "*net.OpError"})
+	require.Len(t, next.Events(), 2)
+
+	time.Sleep(60 * time.Millisecond)
+	reporter.Report(boom)
+
+	events := next.Events()
+	require.Len(t, events, 3)
+	assert.Equal(t, 2, events[2].Extra["suppressed"])
+
+	require.NoError(t, reporter.Flush(context.Background()))
+	assert.Equal(t, 1, next.flushes)
+}
+
+func TestDeduplicate_ZeroWindowReportsEverything(t *testing.T) {
+	t.Parallel()
+
+	next := &recordingReporter{}
+	reporter := elog.Deduplicate(next, 0)
+
+	reporter.Report(elog.Event{Message: "request failed"})
+	reporter.Report(elog.Event{Message: "request failed"})
+
+	assert.Len(t, next.Events(), 2)
+}
+
+// not parallel: the test installs the global reporter.
+func TestSeverityHook_ReportsErrorEvents(t *testing.T) {
+	next := &recordingReporter{}
+	elog.SetReporter(next)
+	t.Cleanup(func() { elog.SetReporter(elog.NopReporter{}) })
+
+	logger := zerolog.New(zerolog.MultiLevelWriter(io.Discard, elog.SeverityHook{})).With().Timestamp().Logger()
+
+	logger.Warn().Msg("not reported")
+	logger.Error().
+		Err(errors.New("boom")).
+		Str(elog.ErrorTypeField, "*errors.errorString").
+		Str("requestId", "req-1").
+		Str("route", "/Organization/:id").
+		Int("status", 502).
+		Msg("request failed")
+
+	events := next.Events()
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, zerolog.ErrorLevel, event.Level)
+	assert.Equal(t, "request failed", event.Message)
+	assert.Equal(t, "boom", event.Error)
+	assert.Equal(t, []string{"*errors.errorString", "request failed"}, event.Fingerprint())
+	assert.Equal(t, map[string]string{"requestId": "req-1", "route": "/Organization/:id"}, event.Tags)
+	assert.Equal(t, map[string]any{"status": float64(502)}, event.Extra)
+	assert.Zero(t, next.flushes)
+}
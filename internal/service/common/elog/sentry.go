@@ -0,0 +1,212 @@
+package elog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const sentryClient = "ods-fhir-gateway/1.0"
+
+// SentryConfig configures a SentryReporter.
+type SentryConfig struct {
+	// DSN is the client key of the project, e.g. https://<key>@o0.ingest.sentry.io/<project>.
+	DSN         string
+	Environment string
+	Release     string
+	ServerName  string
+	// QueueSize is how many events may wait to be sent before further events are dropped.
+	QueueSize  int
+	HTTPClient *http.Client
+}
+
+// SentryReporter sends events to Sentry, or any service speaking its store protocol, from a background goroutine.
+// Events are dropped rather than block logging when the service is slow.
+type SentryReporter struct {
+	cfg      SentryConfig
+	storeURL string
+	auth     string
+	client   *http.Client
+
+	queue   chan Event
+	pending sync.WaitGroup
+}
+
+var _ Reporter = (*SentryReporter)(nil)
+
+func NewSentryReporter(cfg SentryConfig) (*SentryReporter, error) {
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil || dsn.User == nil || dsn.User.Username() == "" || dsn.Host == "" {
+		return nil, errors.New("invalid Sentry DSN")
+	}
+
+	path := strings.Trim(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	prefix, project := "", path
+	if slash >= 0 {
+		prefix, project = "/"+path[:slash], path[slash+1:]
+	}
+	if project == "" {
+		return nil, errors.New("invalid Sentry DSN: no project ID")
+	}
+
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	r := &SentryReporter{
+		cfg:      cfg,
+		storeURL: fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, prefix, project),
+		auth: fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s",
+			sentryClient, dsn.User.Username()),
+		client: client,
+		queue:  make(chan Event, cfg.QueueSize),
+	}
+	go r.run()
+
+	return r, nil
+}
+
+func (r *SentryReporter) Report(event Event) {
+	r.pending.Add(1)
+	select {
+	case r.queue <- event:
+	default:
+		r.pending.Done()
+	}
+}
+
+func (r *SentryReporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *SentryReporter) run() {
+	for event := range r.queue {
+		// the reporter must not log errors of its own: they would be reported in turn.
+		_ = r.send(event)
+		r.pending.Done()
+	}
+}
+
+func (r *SentryReporter) send(event Event) error {
+	body, err := json.Marshal(r.sentryEvent(event))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, r.storeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("sentry responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// sentryEvent is the event payload of the Sentry store protocol.
+//
+//nolint:tagliatelle // field names are defined by the Sentry protocol.
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	Message     string            `json:"message"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Fingerprint []string          `json:"fingerprint"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Exception   *sentryExceptions `json:"exception,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (r *SentryReporter) sentryEvent(event Event) sentryEvent {
+	level := "error"
+	if event.Level >= zerolog.FatalLevel {
+		level = "fatal"
+	}
+
+	extra := event.Extra
+	if event.Stack != "" {
+		extra = make(map[string]any, len(event.Extra)+1)
+		for k, v := range event.Extra {
+			extra[k] = v
+		}
+		extra[StackField] = event.Stack
+	}
+
+	se := sentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "zerolog",
+		Message:     event.Message,
+		Environment: r.cfg.Environment,
+		Release:     r.cfg.Release,
+		ServerName:  r.cfg.ServerName,
+		Fingerprint: event.Fingerprint(),
+		Tags:        event.Tags,
+		Extra:       extra,
+	}
+	if event.Error != "" {
+		se.Exception = &sentryExceptions{Values: []sentryException{{Type: event.ErrorType, Value: event.Error}}}
+	}
+
+	return se
+}
+
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,93 @@
+package elog_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+)
+
+type storedEvent struct {
+	auth string
+	path string
+	body map[string]any
+}
+
+// helper to start a stand-in for the Sentry store endpoint, returning the DSN to use and the events received.
+func newSentryStandIn(t *testing.T) (string, <-chan storedEvent) {
+	t.Helper()
+
+	events := make(chan storedEvent, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		events <- storedEvent{auth: r.Header.Get("X-Sentry-Auth"), path: r.URL.Path, body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.Replace(srv.URL, "://", "://public-key@", 1) + "/42", events
+}
+
+func TestSentryReporter_SendsEvents(t *testing.T) {
+	t.Parallel()
+
+	dsn, events := newSentryStandIn(t)
+	reporter, err := elog.NewSentryReporter(elog.SentryConfig{DSN: dsn, Environment: "test", Release: "1.2.3"})
+	require.NoError(t, err)
+
+	reporter.Report(elog.Event{
+		Time:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Level:     zerolog.ErrorLevel,
+		Message:   "request failed",
+		Error:     "dial tcp: connection refused",
+		ErrorType: "upstream unavailable: *net.OpError",
+		Stack:     "goroutine 1 [running]:",
+		Tags:      map[string]string{"requestId": "req-1"},
+		Extra:     map[string]any{"status": 502},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, reporter.Flush(ctx))
+
+	got := <-events
+	assert.Equal(t, "/api/42/store/", got.path)
+	assert.Contains(t, got.auth, "sentry_version=7")
+	assert.Contains(t, got.auth, "sentry_key=public-key")
+
+	assert.Len(t, got.body["event_id"], 32)
+	assert.Equal(t, "2024-05-01T12:00:00Z", got.body["timestamp"])
+	assert.Equal(t, "error", got.body["level"])
+	assert.Equal(t, "request failed", got.body["message"])
+	assert.Equal(t, "test", got.body["environment"])
+	assert.Equal(t, "1.2.3", got.body["release"])
+	assert.Equal(t, []any{"upstream unavailable: *net.OpError", "request failed"}, got.body["fingerprint"])
+	assert.Equal(t, map[string]any{"requestId": "req-1"}, got.body["tags"])
+	assert.Equal(t, map[string]any{"status": float64(502), "stack": "goroutine 1 [running]:"}, got.body["extra"])
+	assert.Equal(t, map[string]any{"values": []any{map[string]any{
+		"type":  "upstream unavailable: *net.OpError",
+		"value": "dial tcp: connection refused",
+	}}}, got.body["exception"])
+}
+
+func TestNewSentryReporter_RejectsInvalidDSN(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range []string{"", "https://sentry.example.com/42", "https://key@sentry.example.com/"} {
+		_, err := elog.NewSentryReporter(elog.SentryConfig{DSN: dsn})
+		assert.Error(t, err, dsn)
+	}
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the outcome of a check, or of all checks together.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	// StatusWarn reports that a non-critical check failed: the service is still ready.
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusDraining reports that the service is shutting down and takes no new traffic.
+	StatusDraining Status = "draining"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 10 * time.Second
+)
+
+// CheckFunc checks a dependency, returning an error when it is unhealthy.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency check.
+type Check struct {
+	Name string
+	// Critical checks fail readiness when they fail; others are only reported.
+	Critical bool
+	// Timeout bounds each run of the check. It defaults to 2s.
+	Timeout time.Duration
+	// CacheTTL is how long a result is reused before the check runs again. It defaults to 10s.
+	CacheTTL time.Duration
+	Run      CheckFunc
+}
+
+// Result is the latest outcome of a check.
+type Result struct {
+	Name      string    `json:"name"`
+	Critical  bool      `json:"critical"`
+	Status    Status    `json:"status"`
+	LatencyMS float64   `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report is the health of the service and of each of its dependencies.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs a fixed set of checks. Results are cached per check, and concurrent callers share a single run, so
+// that probes do not multiply the traffic sent to dependencies.
+type Checker struct {
+	checks   []*cachedCheck
+	draining atomic.Bool
+	now      func() time.Time
+}
+
+type cachedCheck struct {
+	Check
+
+	mu     sync.Mutex
+	result Result
+	ran    bool
+}
+
+func NewChecker(checks ...Check) (*Checker, error) {
+	c := &Checker{now: time.Now}
+
+	seen := make(map[string]struct{}, len(checks))
+	for _, check := range checks {
+		if check.Name == "" || check.Run == nil {
+			return nil, errors.New("a health check needs a name and a func")
+		}
+		if _, ok := seen[check.Name]; ok {
+			return nil, fmt.Errorf("duplicate health check %q", check.Name)
+		}
+		seen[check.Name] = struct{}{}
+
+		if check.Timeout <= 0 {
+			check.Timeout = defaultTimeout
+		}
+		if check.CacheTTL <= 0 {
+			check.CacheTTL = defaultCacheTTL
+		}
+		c.checks = append(c.checks, &cachedCheck{Check: check})
+	}
+
+	return c, nil
+}
+
+// Drain marks the service as shutting down: readiness fails from now on, while checks are still reported.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready reports whether the service should receive traffic: it is not draining and no critical check fails.
+func (c *Checker) Ready(ctx context.Context) bool {
+	status := c.Report(ctx).Status
+	return status == StatusPass || status == StatusWarn
+}
+
+// Report runs the checks whose cached result has expired, concurrently, and aggregates the results in the order the
+// checks were given.
+func (c *Checker) Report(ctx context.Context) Report {
+	return c.report(ctx, false)
+}
+
+// Probe runs every check now, regardless of cached results, and caches the new results.
+func (c *Checker) Probe(ctx context.Context) Report {
+	return c.report(ctx, true)
+}
+
+func (c *Checker) report(ctx context.Context, fresh bool) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.result(ctx, check, fresh)
+		}()
+	}
+	wg.Wait()
+
+	status := StatusPass
+	for _, result := range results {
+		if result.Status != StatusFail {
+			continue
+		}
+		if result.Critical {
+			status = StatusFail
+			break
+		}
+		status = StatusWarn
+	}
+	if c.draining.Load() {
+		status = StatusDraining
+	}
+
+	return Report{Status: status, Checks: results}
+}
+
+func (c *Checker) result(ctx context.Context, check *cachedCheck, fresh bool) Result {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	if !fresh && check.ran && c.now().Sub(check.result.CheckedAt) < check.CacheTTL {
+		return check.result
+	}
+
+	// the result is shared with other callers, so the check must not be cut short by the caller that runs it.
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), check.Timeout)
+	defer cancel()
+
+	start := c.now()
+	err := check.Run(runCtx)
+	if err == nil && runCtx.Err() != nil {
+		err = runCtx.Err()
+	}
+
+	result := Result{
+		Name:      check.Name,
+		Critical:  check.Critical,
+		Status:    StatusPass,
+		LatencyMS: float64(c.now().Sub(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	check.result = result
+	check.ran = true
+
+	return result
+}
@@ -0,0 +1,106 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
+)
+
+func TestChecker_AggregatesCriticalChecks(t *testing.T) {
+	t.Parallel()
+
+	var upstreamErr atomic.Pointer[error]
+	checker, err := health.NewChecker(
+		health.Check{Name: "config", Critical: true, Run: func(context.Context) error { return nil }},
+		health.Check{Name: "upstream", Critical: true, CacheTTL: time.Nanosecond, Run: func(context.Context) error {
+			if err := upstreamErr.Load(); err != nil {
+				return *err
+			}
+			return nil
+		}},
+		health.Check{Name: "optional", Run: func(context.Context) error { return errors.New("degraded") }},
+	)
+	require.NoError(t, err)
+
+	report := checker.Report(context.Background())
+	assert.Equal(t, health.StatusWarn, report.Status, "a failing non-critical check is only a warning")
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, "config", report.Checks[0].Name)
+	assert.Equal(t, health.StatusPass, report.Checks[0].Status)
+	assert.Equal(t, health.StatusFail, report.Checks[2].Status)
+	assert.Equal(t, "degraded", report.Checks[2].Error)
+	assert.True(t, checker.Ready(context.Background()))
+
+	down := errors.New("connection refused")
+	upstreamErr.Store(&down)
+
+	report = checker.Report(context.Background())
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+	assert.False(t, checker.Ready(context.Background()))
+}
+
+func TestChecker_CachesResults(t *testing.T) {
+	t.Parallel()
+
+	var runs atomic.Int32
+	checker, err := health.NewChecker(health.Check{Name: "upstream", CacheTTL: time.Hour, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	require.NoError(t, err)
+
+	for range 3 {
+		checker.Report(context.Background())
+	}
+	assert.Equal(t, int32(1), runs.Load())
+}
+
+func TestChecker_TimesOutChecks(t *testing.T) {
+	t.Parallel()
+
+	checker, err := health.NewChecker(health.Check{
+		Name:     "upstream",
+		Critical: true,
+		Timeout:  10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	report := checker.Report(context.Background())
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestChecker_FailsReadinessWhileDraining(t *testing.T) {
+	t.Parallel()
+
+	checker, err := health.NewChecker(health.Check{Name: "config", Run: func(context.Context) error { return nil }})
+	require.NoError(t, err)
+	require.True(t, checker.Ready(context.Background()))
+
+	checker.Drain()
+
+	report := checker.Report(context.Background())
+	assert.Equal(t, health.StatusDraining, report.Status)
+	assert.Equal(t, health.StatusPass, report.Checks[0].Status, "checks are still reported")
+	assert.False(t, checker.Ready(context.Background()))
+}
+
+func TestNewChecker_RejectsDuplicateNames(t *testing.T) {
+	t.Parallel()
+
+	check := health.Check{Name: "config", Run: func(context.Context) error { return nil }}
+	_, err := health.NewChecker(check, check)
+	assert.Error(t, err)
+}
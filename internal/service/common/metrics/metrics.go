@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+)
+
+const namespace = "ods_gateway"
+
+// Registry holds every metric of the gateway, together with the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	ConsumerRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "consumer_requests_total",
+		Help:      "Authenticated HTTP requests served, by consumer (jwt:* for every bearer token), route and status code.",
+	}, []string{"consumer", "route", "status"})
+
+	QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "query_duration_seconds",
+		Help:      "Time taken by query handlers, by query and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query", "outcome"})
+
+	ConsumerRateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "consumer_rate_limited_total",
+		Help:      "Requests rejected by the limits of their consumer, by consumer (jwt:* for every bearer token) and limit (rate or quota).",
+	}, []string{"consumer", "limit"})
+
+	ResponseValidationCheckedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "response_validation_checked_total",
+		Help:      "Responses checked against the OpenAPI contract, by operationId.",
+	}, []string{"operation"})
+
+	ResponseValidationViolationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "response_validation_violations_total",
+		Help:      "Responses violating the OpenAPI contract, by operationId.",
+	}, []string{"operation"})
+
+	AuditWriteErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_write_errors_total",
+		Help:      "Audit records which could not be written.",
+	})
+
+	AuditChainHeadSeq = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "audit_chain_head_seq",
+		Help:      "Seq of the last audit record written: it going back tells records removed from the end of the log.",
+	})
+
+	UpstreamRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time taken by each call to the ODS API, by operationId and status code (\"error\" without a response).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "status"})
+
+	ConfigReloadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Configuration reloads, by trigger (signal or file) and result.",
+	}, []string{"trigger", "result"})
+
+	ConfigLastReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Unix time of the last configuration reload which was applied.",
+	})
+
+	TLSCertificateExpiry = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tls_certificate_expiry_timestamp_seconds",
+		Help:      "Unix time the TLS certificate served on the API port expires at.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		ConsumerRequestsTotal,
+		ConsumerRateLimitedTotal,
+		ResponseValidationCheckedTotal,
+		ResponseValidationViolationsTotal,
+		AuditWriteErrorsTotal,
+		AuditChainHeadSeq,
+		QueryDuration,
+		UpstreamRequestDuration,
+		ConfigReloadsTotal,
+		ConfigLastReloadSuccess,
+		TLSCertificateExpiry,
+	)
+}
+
+// Handler serves the metrics of Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveQuery records the duration of a query handler which started at start and returned err.
+func ObserveQuery(query string, start time.Time, err error) {
+	QueryDuration.WithLabelValues(query, Outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+var outcomes = []struct {
+	kind  error
+	label string
+}{
+	{kind: apperrors.ErrInvalidInput, label: "invalid_input"},
+	{kind: apperrors.ErrNotFound, label: "not_found"},
+	{kind: apperrors.ErrUpstreamTimeout, label: "upstream_timeout"},
+	{kind: apperrors.ErrUpstreamUnavailable, label: "upstream_unavailable"},
+	{kind: apperrors.ErrMapping, label: "mapping_error"},
+	{kind: apperrors.ErrOverloaded, label: "overloaded"},
+}
+
+// Outcome labels err by its apperrors kind: "success" when nil and "error" when it has no known kind.
+func Outcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+	for _, o := range outcomes {
+		if errors.Is(err, o.kind) {
+			return o.label
+		}
+	}
+	return "error"
+}
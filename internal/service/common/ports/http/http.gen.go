@@ -86,6 +86,9 @@ type Organisation struct {
 
 	// Roles Current or historical roles assigned to the organisation.
 	Roles *[]OrganisationRole `json:"roles,omitempty"`
+
+	// Stale True when the organisation was served from cache past its freshness lifetime, because the ODS FHIR API could not be reached or the entry is being refreshed. Omitted for fresh data.
+	Stale *bool `json:"stale,omitempty"`
 }
 
 // OrganisationMetadata defines model for OrganisationMetadata.
@@ -124,19 +127,22 @@ type OrganisationSearchResponse struct {
 	// PageSize Page size.
 	PageSize int `json:"pageSize"`
 
+	// Stale True when the results were served from cache past their freshness lifetime, because the ODS FHIR API could not be reached or the entry is being refreshed. Omitted for fresh data.
+	Stale *bool `json:"stale,omitempty"`
+
 	// Total Total number of records matching the search criteria.
 	Total int `json:"total"`
 }
 
 // SearchOrganisationsParams defines parameters for SearchOrganisations.
 type SearchOrganisationsParams struct {
-	// Name Case-insensitive substring match on organisation name.
+	// Name Case-insensitive substring match on organisation name. Between 3 and 100 characters, limited to letters, digits, spaces and &()'’+-_./:@ as required by ODS.
 	Name *string `form:"name,omitempty" json:"name,omitempty"`
 
-	// City City / town.
+	// City City / town. At least 2 characters, limited to letters, digits and spaces.
 	City *string `form:"city,omitempty" json:"city,omitempty"`
 
-	// Postcode Postcode (full or prefix).
+	// Postcode Postcode (full or prefix). At least 2 characters, limited to letters, digits and spaces.
 	Postcode *string `form:"postcode,omitempty" json:"postcode,omitempty"`
 
 	// Active Filter by organisation activity status.
@@ -548,8 +554,12 @@ type SearchOrganisationsResponse struct {
 	HTTPResponse *http.Response
 	JSON200      *OrganisationSearchResponse
 	JSON400      *Error
+	JSON401      *Error
+	JSON429      *Error
 	JSON500      *Error
 	JSON502      *Error
+	JSON503      *Error
+	JSON504      *Error
 }
 
 // Status returns HTTPResponse.Status
@@ -572,9 +582,14 @@ type GetOrganisationByOdsCodeResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
 	JSON200      *Organisation
+	JSON400      *Error
+	JSON401      *Error
 	JSON404      *Error
+	JSON429      *Error
 	JSON500      *Error
 	JSON502      *Error
+	JSON503      *Error
+	JSON504      *Error
 }
 
 // Status returns HTTPResponse.Status
@@ -639,6 +654,20 @@ func ParseSearchOrganisationsResponse(rsp *http.Response) (*SearchOrganisationsR
 		}
 		response.JSON400 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 429:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON429 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
 		var dest Error
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -653,6 +682,20 @@ func ParseSearchOrganisationsResponse(rsp *http.Response) (*SearchOrganisationsR
 		}
 		response.JSON502 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 503:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON503 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 504:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON504 = &dest
+
 	}
 
 	return response, nil
@@ -679,6 +722,20 @@ func ParseGetOrganisationByOdsCodeResponse(rsp *http.Response) (*GetOrganisation
 		}
 		response.JSON200 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 401:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON401 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
 		var dest Error
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -686,6 +743,13 @@ func ParseGetOrganisationByOdsCodeResponse(rsp *http.Response) (*GetOrganisation
 		}
 		response.JSON404 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 429:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON429 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
 		var dest Error
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -700,6 +764,20 @@ func ParseGetOrganisationByOdsCodeResponse(rsp *http.Response) (*GetOrganisation
 		}
 		response.JSON502 = &dest
 
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 503:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON503 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 504:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON504 = &dest
+
 	}
 
 	return response, nil
@@ -855,44 +933,57 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/+xZ/0/jOBb/V6zcSYCUlqQwezP9rVeYoRJsUdvZEzeHTiZ+bb3r2FnbAToj/veTvyRN",
-	"UgPlNLO/7EpINOC87/68z3v9FmUiLwQHrlU0/BapbA05th9HhEhQ9mMhRQFSU7BPGdUb8xsecV4wiIbR",
-	"JQBRURzpTWEelZaUr6KnOMpEybXsnD7nK4Y5CZ1nlDsdBFQmaaGp4NGwMgXZf8eIciQkAdmP4q3YL9F4",
-	"8stkjC5Gl5dRHI1Hl7+czy7Pb9B8MTs/X0S3cUQ15Fb6jl7/Bywl3pjnQiiN2VgQ6Dg6T1H6ebZr+laG",
-	"uPsVMm2EnEspZCB8XmrHxaJgNMPmqacKyOiSZgiMBGReaLkafb42To2u/ns+m01noUAS0Jgyqw8TQo1Y",
-	"zK4bdmhZQtyxYVq4c16vl9GPAr7loBReBfy4KHPMexIwwXcMvCR/uu3ER0wZEKQFyjBjaHo2Rx8vJjM0",
-	"up4E4yvh95JKICbVNoZbK24DFk4LkNj7DZIKspsJgjUs7HtdLz5KgJ6GR43MGWSEo8OlkMibH6ODhvyD",
-	"GB1cwgqzg6MYYYVUaZIJBN1tjFv9//CW4403ozjiJWMmVFVKdlIJnOwa2JCBgBNrZozoEmG+aYd5kKQn",
-	"veSkd5JGcbQUMsc6GlrX91GuNJb6ZfX2iIvT4c3NzU3v6qp3dnbUtiL98D7pJWkvCVnxcq6dCcEUyxXm",
-	"VGFnVNfGOc0LRpcmD6JxEN1TeEAEJL0HgpZS5NvScwK/2nPG/na54C0g/l3CMhpGfzveouexh87jCjef",
-	"4ogGMjfhGqSJGyXAtbFPosNSlZixDVI4B1NBgigDPp0gDtJB6K5TNco0vYeQLmIwBRR6WINeg0R6De1o",
-	"UIXENplsg7CVhbC2ZzXNAYml/cyE+K0sXDV7I+6EYIC5QwSNCdb4tfA0k3ZVvfMURxznAQ+ap5E50o6I",
-	"bTxoTPUGjUXJM8pCAfLRDEg/m7ejsQu1z8RchNDlRbd3XrBlnglJxgwrFTbOHUCZOdEBoIv5eCpX9vdo",
-	"bn/9C0tiP8zLu6lcHXVxx70R8kYKFmq741JK4BoJidZUaSFphhmyhxFWiq64w+9uUZkI1r1231qYCQa7",
-	"rbiDBdSwhiqdvmbaYWxch0ZNvoYeV43ibV96hpX+XBigCtzlS6w0KgvXI2gOSuO86GDKoTGi3xDTvdTJ",
-	"IOklp73kZJEkQ/vz7y5G9ozwV4GyaeprDtto70lNbCEKBoHbkZ6mQfZBVcHw5lV2YKU2TnQu93Q8ukSj",
-	"z4uL6WyyuPlx17CQNMcyYO5ClmCaql5TZZDSFLo/bG3vB5FQaazLwHUyMUfun9ZRXuYmbXW1TrjD3kby",
-	"XqY/VZi3HtTKX8v/HLDM1jNQheAqUAn19e0UPFXatIPmbVfI4JIJTebxovBc780YEKTiQZo5bqhCvMzv",
-	"TBdNe3dYdS9YWoukXMMKZCV0Tr8GBF8bgYp+bRf6uyQkRQuNWaBqzJ8ro8TSg7hCOdbZmvKVjZWyCUCZ",
-	"pBokxW2TBye76jol4HT78DQcqqK+WwBGAuVL8QxTArTEGSaAxL3nCT9fzFusPEbwWAhlPMAoM9UOpFcW",
-	"7Q6aCwIMPVC9rhoFJ8gzJ7RkWGswXYNyLRBGCnNAZ4upJxVUO4J8Nketxv8Ja3jAGz8a3INUzvC0n/QT",
-	"jwMcFzQaRif9pH9iA6LXtvaOW7Vq/rKCAKN1F6JT2KV3VpV3CmzhF1jiHDRIk86iMNyS+w5Yh+q4SSOr",
-	"RAMnhaBcO09r3JqQWve0ZafxoFIVDb/s1D9W0KNcAVfU8jVjogULV2ZI8HZeLHeyuql5//cSLFw41lU1",
-	"UncnAxPyU7xjgOFcx0iLB9fuA0LtnuBNQq+F0gbb0OGyZMzwjkLCkj4ePaei8C+8Tc1HyjRIM521QmTR",
-	"17i1xeiQTlzh9Y7Guge8pLLupN2BMj1NDyyUMmFoFi71WkiqNzE6mE3T9x/cPz9do0IaEzI4ej6fRsn4",
-	"zXGZLJEdA5HgbNO5Cg9rkGDrvIYx6wlVCLd6IsISkARdSg7keQv9K6YnTjlr1wmBJS6ZjoZLzBTEe4R3",
-	"ZvV1TGZbdkbcfUB4qS24UeUH1sl82nv/U5LaxxcC2uBWH6XIW+a+Os4Ge0yoaQUD5QB+i/1uUg9EK42j",
-	"nHKaG1qRhhrIs70OHeb4EQ2S5EUrfH/ZwxLTLnP86EwZJMkrht0aoY6JWIAeJIljo1wDt1iNt9ux41+V",
-	"m/b9zVGtDd23SJW5I3MtvqMqHJagjIlxdI9ZCQ2q86U14LsdZ73YrPeYjeWl31XusXdsbhPrFaJfD/gB",
-	"czvDu6A2p+nWCBKeF+r5OTwR1wOw1xakzdttWGdDZRdQvGSsXgc11zndMXY7Zvqp8ks1V/hpoR4OAgT/",
-	"OxtWE3tfqZ6YV5T76dbciTrrTpJNyiy9eGtSTnup0bxIB8OT0+G7n3aTsgDsgPNCqIJqzJSlWAtZKt1K",
-	"ktP+HWLxIbWVsk+Sbs0tdFw7bfLjQU1z08GJ5ZDb+74vre8MG1ZKkH25y2lXZ6d7YcB+trhFfEDthN9j",
-	"RkmFDQ3G9RRH7/4ICz5zeCwgMx0K3BmjePAHKC6UloDzFsOvbDBZrmE0xIztkTazPv7mC/ipwbHbPPcT",
-	"6GZZ/HMzrTc5L5Ld4JquQ58G6SBGs/QiRqP3J0ny/qhqZGYQ2Pax7e7o+Ta2P09yNEehO6HX9eKUm+nG",
-	"P9jb1UeTJbJUxlAQkVOtgXiS1Ty3J3OiPGMlgWpbMLMX+E306Qf122DrqdvsX831T9Zc49q0k3+0LLs6",
-	"X8ym19PLyWL0MzqbzBezyXjxPexzX3Wddu3z1yDQ/f+/dhaC09a6YilKTlwTO/3xSN7+ikTorfq/OpgD",
-	"pk+g2/3DfS9r24iTrEDeh3vPtRSkzOxDHJWSRcNorXWhhsfHgqjeym2m+htRSiJyTHmfr1W//C0KtA0z",
-	"8LgKfklWj3Idknf79L8AAAD//zjOttStIQAA",
+	"H4sIAAAAAAAC/+xaD2/bNhb/KoRuQFJMciQn67U+HHBekjYGkjmw3e26Jjcw0rPNjSJVkmriDgHua9zX",
+	"u09yeKRsSzKduMNa7O4CFI0tU+8/33u/R/4apDIvpABhdND7NZgDzUDZjyNq4JzlzET2f3yUgU4VKwyT",
+	"IugFI3hfgjaaUM7lLWTkZkHMHAjH5STlUoM2xEhyA0zMCNylABlknSAMdDqHnCJJsygg6AVMGJiBCu7v",
+	"wxrjS8lZutjkPFly0UROLc9UCl3moEICNJ0Tqu1TUeY3oHCNastKRUbw/VsmMnlLmCAaUikyHRLozDrk",
+	"Kkjiv9z+NQlJEscxfnzx/CiOr4IOGebMGMjIVKoVXyRk5rI0lVydK+FTUxvFxKyl5QhyygQ+325itVxD",
+	"bmAqFRAzpxUvwvRvse0INHicOnZWIKUwjLe4KHzlERb3y19tDPWzTIG2HwslC1CGgf2WMmP9Cnc0LziS",
+	"OAfIdBC2LRUGqSyFUa3Vp2LGqch86zkToDcVq0Qh9ucQHS5VBgrVWZF9FxwPvh8ck7P++XkQBsf98+9P",
+	"R+enb8l4Mjo9nQTXYcAM5Nrj0ZUgVCm6wO+F1IbyY5lBS9FxQpI3o03R1zTkzc+QGiRyqpRUHvNVVFsq",
+	"FgVnKcVvkS4gZVOWEkAKBF9oqBq8uUSl+hc/nY5Gw5HPkBkYyrjlR7OMIVnKL2tyGFVC2JJhWLh1Fd+K",
+	"Rifw6JaD1nTm0eOszKmIFNCM3nCoKFWrm0q8ooxDhjkmpZyT4cmYvDobjEj/cuC1L6YBpiBDV1sbrqW4",
+	"9kg4LEDRSm9QTGabnsiogcmi8GjxSgFEBu4MwTUEiZN9zBmV+CHZq9HfC8neOcwo33sWYvrSJTrTJdXh",
+	"ydjlk7XitTeDMBAl52iqpUs2XAki2xSwRoOAyKyYIWFTQsWiaeZunBxG8WF0mARhMJUqpyboWdV3Ya4N",
+	"VeZh9naJs9P+27dv30YXF9HJybOmFMnLF3EUJ1Hsk+JhXzsRvC5WMyqYpk6ojWzI8oKzKfpB1haSDwxu",
+	"SQaKfcA6oGS+Dj1H8KNdh/I3w4WuE+JXCqZBL/jTwboCH1Sp82CZN+/DgHk8NxAGFNqNZSAMyqfIfqlL",
+	"yvmCaJoDRpDMNCaflhG7Sde315nup4Z9AB+vDHMKaHI7BzMHZStrwxpME7l2Jl8QamkRauxaw3JY1mku",
+	"5S9l4aK5EuJGSg5UuIxgaEYNfcw8daddLN+5DwNBc48G9dUElzQtYgsPOWZmQY5lKVLGfQaqrOmhfjJu",
+	"WmMz1W6xufRllwfV3njBhnkqVXbMqdZ+4dwCkuKKVgI6Gx8P1cz+7Y/tnx+oyuyHcXkzVLNn7bzj3vBp",
+	"oyT3ld3jUikQhkhF5kwbqVhKObGLCdWazYTL3+2g6gS1WrtrLIwkB18pxkLscd1ElYBRLTZD+hZTMKjV",
+	"9k5pOgdSUG1s1zhVoOfCtRNTwAAPyQ2ktNRgadULEUllyTMipCE3QBT2pzad2IWArQ1uINcjK7CUIWt2",
+	"mfYhpkfq3zmtbMeyYB2w1a5oBkptw9d23WP58aK2PZtpjVNt3hSYij3Z6hytVhauCrIctKF50cqa+yhE",
+	"p0amnbbibhzFR1F8OInjnv33Y7sKREj80VJQF/UxhW087dh82a0mOXj2f3KUePsrpgtOF4/2P5ZqbUUr",
+	"fQ2P++ek/2ZyNhwNJm8/X6IpFMupWmzZRgzzO9MYyhjX1WIre8eb67WhpvQkDLQ5cT9aRUWZo9tW0ToQ",
+	"rroE1xua+hu8pZnXGqyYP+b/MVCVzkegCym0JxJWCaoV8EwbLHj1jKLtRrZQtcqIRdXNfnKW84INbyN9",
+	"XGO1BMP7SXRDdXuDJeEGlHNEx+yjh/AlEtTsYzPQv4l9VHZKvgp0yRGOg4JtidfMgak/WuoNAyMN5R79",
+	"8HFjAoHpV5OcmnSODFEGbQOMpIoZUIw2XdI9DH0Qvh7ijnfl/prDllG1GeBIgYmp3NLrApnSlGZA5Ieq",
+	"0/vubNywaUjgrpAaNaAkRSNAFpVFs3zmMgNuRyLLUi8yUvW+ZMqpMYB1nwkjCSWaCiAnk2FlYWYcxDkZ",
+	"k0br9poauKWLCtx9AKWd4Ekn7sRVnhO0YEEvOOzEnUNrEDO3e+ugsRfxycw//bD+aG7cslJWlzca7MYu",
+	"qKI5GFDozqLAmBJVD7My1UEdCCwdDSIrJBPGabrKy4NsxXvYkDMM1qyC3ruN/U01RExoEJrZjhtFtMnQ",
+	"hRmRoukX2/2Sb8HcAghyaN2SxDFJ51TRFLmEbtzjmjIOxj3L2IwZHRJd0LTy5lUZx93n+8/2/v3Pf30d",
+	"/dQ56P0NUccyOhu4laGw70uwudc16e5PfZSU07tzEDMzD3pJHIdBzsTy+6H1pAGFdP7xrh/9SKOPcfSy",
+	"IcPVVSXF9ddf+bqADeNhx39AjLwVHdI3hAMmme6OtrA2cObobNHQzrgaGq416m7RaFfhL6U2WN/I/rTk",
+	"HFNaoWDK7p59HlWKit3nUucV4wYUxkwjXG2lRzet+wGfdHTZG2zMJWsd8naWq66tPZ5JjpI9WwS4RNBC",
+	"SzOXiplFSPZGw+TFS/fj60tSoJVZCs+2hzsyOZaZV8rtdhlMiR2qECn4opWWbuegXMlblRSrCdOENvov",
+	"QhUWP1MqAdl2CatXsP8aCt6M3AymtOQm6E0p1xDuYN6R5dcSma+RQOZyE6FTYwsN09X4ZzAeRi+ex4n9",
+	"+oBBa338KyXzhriPDoe8/YyvQfIayhXbdR22LvJZK7FZjOVl7u2w7sOtfRXZz+kd6cbxg1JUtX4HSbA1",
+	"y+mdE6Vbpdftgl0jUdf12mLZjWOHfIQBYesmXc+aD37WbnZW7Rxd+4wfdZk74NDorfWyJrrWDys65SXU",
+	"2up3jXGZOzFYHROsTgVqRwHV5H+HKX59Nr8ayFfDtmpcs56IOaPWZ1MNuOvHpqtplH++tBonVdy8EG09",
+	"W27Ne+04F+euq+FqfTjaHgqthzbVjObdEsNWyHQFRD1g8ncWbAUiq0itQOAS3t1f34d1ry9dMkrOPtUl",
+	"R1GCfCdJt3d41Pvm+aZLJkBd2jyTumCGcm2b3YkqtWm4yHH/HSzxMrFxsouLrnEPOlSX1JFYdwU4ku5h",
+	"7bjtUwBkC9ZaKt4+uEJlQfjIubCPcfXGQXv5lgPe3UhU67cfn+5GZv2K/0h0Vyq4/D4MfqDKf3w7Bnv+",
+	"vZckMYnIVbA0OdboMcLiq2DPYeDUQVOM5oemjLW55OMnzH+PTqihUd83GejPoHbmvTpFr0lRoXInL7K9",
+	"KRk3FpSHRFvFZu48wMK8ygaPnQ7fh8HRTrVkt6h2x6OeAB6ID5SzbFlj1iiqQ/DyQHUsudTbnTBypo27",
+	"PiCnUxAZpobVi6QUGSiyN2XAM72H1re6JJ9flwumLQKVirBKLZxt/AJ2Ixx1X35+EdBmiprl3Y5qgpJR",
+	"xhfkfSkNbd/DqF9KeEofD6ePERi1iPrYCG+/j+H65Ns5S+fV1rQ3Q0hOF262ZRR79P4HOvabL7H93gi4",
+	"KyDFNh/cGmTc/QKMC20U0Lw5BqzJcPgFNouUJKdiUbt0pIC8L6GsZortMWVrg3zhcDj6Mumj4ZCMuams",
+	"KzA4CLQnQ7ZsreGCbxpnlzSneQe/Vq3afW2u15ytvQZTb4C+XQxXp2MPDti8h7utMUE36YZklJyFpP/i",
+	"MI5fPFsCNhw+rvHa+jxuO1zbfR7g4LwmN9LMV8ft1pDVF9tHdshgSixkx5Qt3Vi7GibU1+04IWAi5WUG",
+	"yxMYnBToTxsTfCZc6YVYKzj5BCL/z0BkuBLt8M8NyS5OJ6Ph5fB8MOl/R04G48locDz5PeRzF6SO2vJV",
+	"28CDcn8bcPPl1vrvZCpL8dRvPcG1/1a4tmxjakX5D4KyvkSb1LyoJk21nZ9A3hPIewJ5TyDvfxPkvQbT",
+	"hFju4oBFWo6NLaBeeHapZFam9ksYlIoHvWBuTKF7Bwcy09HMXRjpLGSpMonbuSPmulP+EniQFRpM0cdo",
+	"RXh5w0Pv+v4/AwB+CTCHSjUAAA==",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
@@ -1,39 +1,70 @@
 package server
 
 import (
+	"errors"
+	"strconv"
+	"time"
+
 	"github.com/labstack/echo/v4"
 	openapi_types "github.com/oapi-codegen/runtime/types"
-	"github.com/rs/zerolog/log"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/audit"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/domain"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
 )
 
 type ODSGatewayServer struct {
-	app app.ODSGatewayApp
+	app     app.ODSGatewayApp
+	auditor audit.Recorder
 }
 
-func NewODSGateway(gwApp app.ODSGatewayApp) (*ODSGatewayServer, error) {
-	return &ODSGatewayServer{gwApp}, nil
+// NewODSGateway returns the server of gwApp, which records every lookup with auditor. Every query handler and the
+// auditor are required: audit.Discard records nothing.
+func NewODSGateway(gwApp app.ODSGatewayApp, auditor audit.Recorder) (*ODSGatewayServer, error) {
+	var errs []error
+	if gwApp.Queries.GetOrganisationByODSCode == nil {
+		errs = append(errs, errors.New("a GetOrganisationByODSCode query handler is required"))
+	}
+	if gwApp.Queries.SearchOrganisations == nil {
+		errs = append(errs, errors.New("a SearchOrganisations query handler is required"))
+	}
+	if auditor == nil {
+		errs = append(errs, errors.New("an auditor is required"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return &ODSGatewayServer{app: gwApp, auditor: auditor}, nil
 }
 
 func (s *ODSGatewayServer) SearchOrganisations(ctx echo.Context, params http.SearchOrganisationsParams) error {
-	result, err := s.app.Queries.SearchOrganisations.Handle(ctx.Request().Context(), queries.SearchOrganisationsQuery{
+	reqCtx, freshness := common.WithFreshness(ctx.Request().Context())
+
+	result, err := s.app.Queries.SearchOrganisations.Handle(reqCtx, queries.SearchOrganisationsQuery{
 		Name:            params.Name,
 		City:            params.City,
 		Postcode:        params.Postcode,
 		RoleCode:        params.RoleCode,
 		Active:          params.Active,
 		PrimaryRoleOnly: params.PrimaryRoleOnly,
+		LastUpdatedFrom: mapDate(params.LastUpdatedFrom),
 		PageSize:        params.PageSize,
 		Page:            params.Page,
 	})
+	event := audit.Event{Action: audit.ActionSearchOrganisations, Criteria: searchCriteria(params)}
+	if err == nil {
+		event.Results = utils.Ref(len(result.Organisations))
+	}
+	s.audit(ctx, event, err)
 	if err != nil {
-		log.Err(err).Msg("error searching organisations")
-		return ctx.JSON(500, err.Error())
+		return err
 	}
 
 	items := make([]http.Organisation, 0)
@@ -47,6 +78,9 @@ func (s *ODSGatewayServer) SearchOrganisations(ctx echo.Context, params http.Sea
 		Total:    result.TotalCount,
 		Items:    items,
 	}
+	if markStale(ctx, freshness) {
+		searchResult.Stale = utils.Ref(true)
+	}
 
 	return ctx.JSON(200, searchResult)
 }
@@ -54,20 +88,86 @@ func (s *ODSGatewayServer) SearchOrganisations(ctx echo.Context, params http.Sea
 func (s *ODSGatewayServer) GetOrganisationByOdsCode(
 	ctx echo.Context,
 	odsCode string,
-	_ http.GetOrganisationByOdsCodeParams,
+	params http.GetOrganisationByOdsCodeParams,
 ) error {
+	reqCtx, freshness := common.WithFreshness(ctx.Request().Context())
+
 	result, err := s.app.Queries.GetOrganisationByODSCode.Handle(
-		ctx.Request().Context(),
+		reqCtx,
 		queries.GetOrganisationByODSCodeQuery{
-			ODSCode: odsCode,
+			ODSCode:              odsCode,
+			IncludeInactiveRoles: utils.Deref(params.IncludeInactiveRoles),
 		},
 	)
+	s.audit(ctx, audit.Event{Action: audit.ActionGetOrganisation, ODSCode: odsCode}, err)
 	if err != nil {
-		log.Err(err).Msg("error getting organisation by ODS code")
-		return ctx.JSON(500, err.Error())
+		return err
+	}
+
+	organisation := mapGetOrganisationResponse(result)
+	if markStale(ctx, freshness) {
+		organisation.Stale = utils.Ref(true)
+	}
+
+	return ctx.JSON(200, organisation)
+}
+
+// audit records event for the consumer of the request, with the outcome of err.
+func (s *ODSGatewayServer) audit(ctx echo.Context, event audit.Event, err error) {
+	if consumer, ok := auth.ConsumerFromContext(ctx.Request().Context()); ok {
+		event.Consumer, event.KeyID = consumer.Name, consumer.KeyID
+	}
+	event.RequestID = ctx.Response().Header().Get(echo.HeaderXRequestID)
+	event.Outcome = metrics.Outcome(err)
+	s.auditor.Record(ctx.Request().Context(), event)
+}
+
+// searchCriteria returns the parameters of a search which were set.
+func searchCriteria(params http.SearchOrganisationsParams) map[string]string {
+	criteria := map[string]string{
+		"page":     strconv.Itoa(params.Page),
+		"pageSize": strconv.Itoa(params.PageSize),
+	}
+	for name, value := range map[string]*string{
+		"name":     params.Name,
+		"city":     params.City,
+		"postcode": params.Postcode,
+		"roleCode": params.RoleCode,
+	} {
+		if value != nil {
+			criteria[name] = *value
+		}
+	}
+	if params.Active != nil {
+		criteria["active"] = strconv.FormatBool(*params.Active)
+	}
+	if params.PrimaryRoleOnly != nil {
+		criteria["primaryRoleOnly"] = strconv.FormatBool(*params.PrimaryRoleOnly)
+	}
+	if params.LastUpdatedFrom != nil {
+		criteria["lastUpdatedFrom"] = params.LastUpdatedFrom.String()
+	}
+	return criteria
+}
+
+// markStale sets the Warning and X-Data-Age headers when stale cached data was served.
+func markStale(ctx echo.Context, freshness *common.Freshness) bool {
+	stale, storedAt := freshness.Stale()
+	if !stale {
+		return false
 	}
 
-	return ctx.JSON(200, mapGetOrganisationResponse(result))
+	age := max(int(time.Since(storedAt).Seconds()), 0)
+	ctx.Response().Header().Set("Warning", `110 - "Response is Stale"`)
+	ctx.Response().Header().Set("X-Data-Age", strconv.Itoa(age))
+	return true
+}
+
+func mapDate(date *openapi_types.Date) *time.Time {
+	if date == nil {
+		return nil
+	}
+	return utils.Ref(date.Time)
 }
 
 func mapGetOrganisationResponse(org domain.Organisation) http.Organisation {
@@ -90,17 +190,16 @@ func mapOperationalPeriod(period *domain.OperationalPeriod) *http.OperationalPer
 	if period == nil {
 		return nil
 	}
-	end := &openapi_types.Date{}
+	operationalPeriod := &http.OperationalPeriod{
+		Start: openapi_types.Date{Time: period.Start},
+	}
 	if period.End != nil {
-		end = &openapi_types.Date{
-			Time: *period.End,
-		}
+		operationalPeriod.End = &openapi_types.Date{Time: *period.End}
 	}
-	return &http.OperationalPeriod{
-		DateType: utils.Ref(period.DateType),
-		End:      end,
-		Start:    openapi_types.Date{Time: period.Start},
+	if period.DateType != "" {
+		operationalPeriod.DateType = utils.Ref(period.DateType)
 	}
+	return operationalPeriod
 }
 
 func mapOrganisationRoles(roles []domain.OrganisationRole) *[]http.OrganisationRole {
@@ -110,7 +209,7 @@ func mapOrganisationRoles(roles []domain.OrganisationRole) *[]http.OrganisationR
 			Code:    role.Code,
 			Display: role.Display,
 			Primary: role.Primary,
-			Status:  http.OrganisationRoleStatus(role.Status),
+			Status:  mapRoleStatus(role),
 		}
 		if role.OperationalPeriod != nil {
 			orgRole.OperationalPeriod = mapOperationalPeriod(role.OperationalPeriod)
@@ -120,6 +219,14 @@ func mapOrganisationRoles(roles []domain.OrganisationRole) *[]http.OrganisationR
 	return &orgRoles
 }
 
+// mapRoleStatus maps the status of the role, worked out by the queries, onto the enum of the contract.
+func mapRoleStatus(role domain.OrganisationRole) http.OrganisationRoleStatus {
+	if role.Status == queries.RoleStatusInactive {
+		return http.Inactive
+	}
+	return http.Active
+}
+
 func mapOrganisationAddress(address domain.Address) *http.Address {
 	return &http.Address{
 		City:       address.City,
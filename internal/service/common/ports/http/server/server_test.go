@@ -0,0 +1,168 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/audit"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http/server"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/domain"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
+)
+
+type stubGetOrganisation struct {
+	organisation domain.Organisation
+}
+
+func (s stubGetOrganisation) Handle(context.Context, queries.GetOrganisationByODSCodeQuery) (domain.Organisation, error) {
+	return s.organisation, nil
+}
+
+type stubSearchOrganisations struct{}
+
+func (stubSearchOrganisations) Handle(context.Context, queries.SearchOrganisationsQuery) (queries.SearchOrganisationsResponse, error) {
+	return queries.SearchOrganisationsResponse{}, errors.New("ODS API unavailable")
+}
+
+type recordedEvents []audit.Event
+
+func (r *recordedEvents) Record(_ context.Context, event audit.Event) {
+	*r = append(*r, event)
+}
+
+// helper to serve a lookup of organisation through the gateway server and decode the raw JSON response.
+func getOrganisation(t *testing.T, organisation domain.Organisation) map[string]any {
+	t.Helper()
+
+	srv, err := server.NewODSGateway(app.ODSGatewayApp{
+		Queries: app.Queries{
+			GetOrganisationByODSCode: stubGetOrganisation{organisation: organisation},
+			SearchOrganisations:      stubSearchOrganisations{},
+		},
+	}, audit.Discard)
+	require.NoError(t, err)
+
+	e := echo.New()
+	svcHTTP.RegisterHandlers(e, srv)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/organisations/"+organisation.ODSCode, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	return body
+}
+
+func TestGetOrganisationByOdsCode_OpenEndedPeriodHasNullEnd(t *testing.T) {
+	t.Parallel()
+
+	body := getOrganisation(t, domain.Organisation{
+		ID:                "RR8",
+		ODSCode:           "RR8",
+		OperationalPeriod: &domain.OperationalPeriod{Start: time.Date(1998, 4, 1, 0, 0, 0, 0, time.UTC)},
+	})
+
+	period, ok := body["operationalPeriod"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "1998-04-01", period["start"])
+	assert.Contains(t, period, "end")
+	assert.Nil(t, period["end"])
+	assert.Nil(t, period["dateType"])
+}
+
+func TestGetOrganisationByOdsCode_RoleStatusMappedToEnum(t *testing.T) {
+	t.Parallel()
+
+	body := getOrganisation(t, domain.Organisation{
+		ID:      "RR8",
+		ODSCode: "RR8",
+		Roles: []domain.OrganisationRole{
+			{Code: "R1", Status: queries.RoleStatusActive},
+			{Code: "R2", Status: queries.RoleStatusInactive},
+			{Code: "R3"},
+		},
+	})
+
+	roles, ok := body["roles"].([]any)
+	require.True(t, ok)
+
+	statuses := make([]any, 0, len(roles))
+	for _, r := range roles {
+		role, ok := r.(map[string]any)
+		require.True(t, ok)
+		statuses = append(statuses, role["status"])
+	}
+	assert.Equal(t, []any{"Active", "Inactive", "Active"}, statuses)
+}
+
+func TestNewODSGateway_RequiresEveryQueryHandlerAndAnAuditor(t *testing.T) {
+	t.Parallel()
+
+	_, err := server.NewODSGateway(app.ODSGatewayApp{
+		Queries: app.Queries{GetOrganisationByODSCode: stubGetOrganisation{}},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a SearchOrganisations query handler is required")
+	assert.Contains(t, err.Error(), "an auditor is required")
+	assert.NotContains(t, err.Error(), "GetOrganisationByODSCode")
+}
+
+func TestODSGatewayServer_RecordsLookupsOfConsumers(t *testing.T) {
+	t.Parallel()
+
+	var events recordedEvents
+	srv, err := server.NewODSGateway(app.ODSGatewayApp{
+		Queries: app.Queries{
+			GetOrganisationByODSCode: stubGetOrganisation{organisation: domain.Organisation{ID: "RR8", ODSCode: "RR8"}},
+			SearchOrganisations:      stubSearchOrganisations{},
+		},
+	}, &events)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+			consumer := auth.Consumer{Name: "gp-connect", KeyID: "k1"}
+			c.SetRequest(c.Request().WithContext(auth.WithConsumer(c.Request().Context(), consumer)))
+			return next(c)
+		}
+	})
+	svcHTTP.RegisterHandlers(e, srv)
+
+	for _, target := range []string{"/organisations/RR8", "/organisations?name=Leeds&active=true&page=2&pageSize=10"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	require.Len(t, events, 2)
+	assert.Equal(t, audit.Event{
+		Action:    audit.ActionGetOrganisation,
+		Consumer:  "gp-connect",
+		KeyID:     "k1",
+		RequestID: "req-1",
+		ODSCode:   "RR8",
+		Outcome:   "success",
+	}, events[0])
+	assert.Equal(t, audit.Event{
+		Action:    audit.ActionSearchOrganisations,
+		Consumer:  "gp-connect",
+		KeyID:     "k1",
+		RequestID: "req-1",
+		Criteria:  map[string]string{"name": "Leeds", "active": "true", "page": "2", "pageSize": "10"},
+		Outcome:   "error",
+	}, events[1])
+}
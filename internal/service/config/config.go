@@ -1,29 +1,276 @@
 package config
 
 import (
+	"strings"
 	"time"
 
-	"github.com/caarlos0/env/v6"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
 )
 
+// Config is the configuration of the service. Settings tagged `reload:"true"` are applied on a reload, changes to
+// any other setting need a restart.
 type Config struct {
 	Account        string        `env:"ACCOUNT"`
 	HTTPPort       string        `env:"PORT" envDefault:"8080"`
-	APIKey         string        `env:"API_KEY"`
+	APIKey         string        `env:"API_KEY" secret:"true" reload:"true"`
 	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"30s"`
-	LogLevel       string        `env:"LOG_LEVEL" envDefault:"INFO"`
+	LogLevel       string        `env:"LOG_LEVEL" envDefault:"INFO" reload:"true"`
+
+	// APIKeyStore is a YAML or JSON file of the consumers of the API, their scopes and the hashes of their keys
+	// (see auth.ParseKeyStore). APIConsumers is read from it by Load.
+	APIKeyStore  string                `env:"API_KEY_STORE" reload:"true"`
+	APIConsumers []auth.ConsumerConfig `loadedFrom:"API_KEY_STORE" reload:"true"`
+
+	// AuthMethods restricts the methods consumers may authenticate by on some routes, as route=method|method
+	// entries, e.g. /diagnostics/usage=api-key|certificate. Routes missing from it accept every method.
+	AuthMethods []string `env:"AUTH_METHODS" envSeparator:"," reload:"true"`
+
+	// AccessLogRedactQuery lists the query parameters whose values are hidden in the access log, or "*" for all.
+	AccessLogRedactQuery []string `env:"ACCESS_LOG_REDACT_QUERY" envDefault:"name,city,postcode" envSeparator:","`
+
+	// MetricsPort is where /metrics is served, apart from the API. Metrics are not served when it is empty.
+	MetricsPort string `env:"METRICS_PORT" envDefault:"9090"`
+
+	// AdminHost and AdminPort are where the admin endpoints (pprof, log level, config dump...) are served. They
+	// have no authentication, so the host defaults to the loopback interface. Not served when AdminPort is empty.
+	AdminHost string `env:"ADMIN_HOST" envDefault:"127.0.0.1"`
+	AdminPort string `env:"ADMIN_PORT" envDefault:"6060"`
+
+	// ResponseValidation is one of off, log or fail: whether responses are checked against the OpenAPI contract.
+	ResponseValidation string `env:"RESPONSE_VALIDATION" envDefault:"off"`
+
+	TLSConfig      TLSConfig
+	JWTConfig      JWTConfig
+	ConsumerLimits ConsumerLimitsConfig
+	CORSConfig     CORSConfig
 	ODSConfig      ODSConfig
+	CacheConfig    CacheConfig
+	TracingConfig  TracingConfig
+	AuditConfig    AuditConfig
+
+	ErrorReportingConfig ErrorReportingConfig
+	HealthConfig         HealthConfig
+
+	// ConfigWatchInterval is how often config and secret files are checked for changes to reload; 0 disables it.
+	// A reload can also be triggered with SIGHUP.
+	ConfigWatchInterval time.Duration `env:"CONFIG_WATCH_INTERVAL" envDefault:"30s"`
+}
+
+// RouteAuthMethods returns AuthMethods as a map of route templates to methods, skipping malformed entries, which
+// Validate reports.
+func (c Config) RouteAuthMethods() map[string][]string {
+	methods := make(map[string][]string, len(c.AuthMethods))
+	for _, entry := range c.AuthMethods {
+		if route, list, ok := strings.Cut(entry, "="); ok {
+			for _, method := range strings.Split(list, "|") {
+				methods[strings.TrimSpace(route)] = append(methods[strings.TrimSpace(route)], strings.TrimSpace(method))
+			}
+		}
+	}
+	return methods
+}
+
+// ConsumerLimitsConfig are the limits of consumers without limits of their own in the key store, such as those
+// authenticated by bearer tokens. Zero values are unlimited.
+type ConsumerLimitsConfig struct {
+	RateLimit  float64 `env:"CONSUMER_RATE_LIMIT" envDefault:"0" reload:"true"`
+	RateBurst  int     `env:"CONSUMER_RATE_BURST" envDefault:"0" reload:"true"`
+	DailyQuota int     `env:"CONSUMER_DAILY_QUOTA" envDefault:"0" reload:"true"`
+}
+
+// Limits returns the limits as the key store declares them.
+func (c ConsumerLimitsConfig) Limits() auth.Limits {
+	return auth.Limits{RateLimit: c.RateLimit, RateBurst: c.RateBurst, DailyQuota: c.DailyQuota}
+}
+
+// TLSConfig enables TLS on the API port when a certificate and key are set. The files are read again when they
+// change, so that certificates can be renewed without a restart.
+type TLSConfig struct {
+	CertFile string `env:"TLS_CERT_FILE"`
+	KeyFile  string `env:"TLS_KEY_FILE"`
+	// ClientCAFile is a PEM file of the CAs client certificates are verified against. ClientAuth is optional, to
+	// accept API keys and bearer tokens from clients without a certificate, or require.
+	ClientCAFile string `env:"TLS_CLIENT_CA_FILE"`
+	ClientAuth   string `env:"TLS_CLIENT_AUTH" envDefault:"optional"`
+	// ReloadInterval is how often the files are checked for changes.
+	ReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" envDefault:"30s"`
+}
+
+// Client authentication modes of TLSConfig.
+const (
+	TLSClientAuthOptional = "optional"
+	TLSClientAuthRequire  = "require"
+)
+
+// Enabled reports whether the API is served over TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// AuditConfig enables the audit log of the organisations looked up by consumers when Dir is set. Files are rotated
+// at MaxFileSize bytes or after RotateInterval, and removed Retention after they were last written to; 0 disables
+// each of them. HMACKey keys the hashes of the records, so that they cannot be recomputed by whoever can write the
+// log; it must not change for the life of the log.
+type AuditConfig struct {
+	Dir            string        `env:"AUDIT_DIR"`
+	HMACKey        string        `env:"AUDIT_HMAC_KEY" secret:"true"`
+	MaxFileSize    int64         `env:"AUDIT_MAX_FILE_SIZE" envDefault:"104857600"`
+	RotateInterval time.Duration `env:"AUDIT_ROTATE_INTERVAL" envDefault:"24h"`
+	Retention      time.Duration `env:"AUDIT_RETENTION" envDefault:"0s"`
+}
+
+// JWTConfig enables JWT bearer tokens, accepted alongside API keys, when a JWKS URL or file is set.
+type JWTConfig struct {
+	JWKSURL      string        `env:"JWT_JWKS_URL"`
+	JWKSFile     string        `env:"JWT_JWKS_FILE"`
+	JWKSCacheTTL time.Duration `env:"JWT_JWKS_CACHE_TTL" envDefault:"10m"`
+	Issuer       string        `env:"JWT_ISSUER"`
+	Audience     string        `env:"JWT_AUDIENCE"`
+	Leeway       time.Duration `env:"JWT_LEEWAY" envDefault:"1m"`
+
+	// ConsumerClaim identifies the consumer, and ScopesClaim grants its scopes, e.g. "scope" or "permissions".
+	ConsumerClaim string `env:"JWT_CONSUMER_CLAIM" envDefault:"sub"`
+	ScopesClaim   string `env:"JWT_SCOPES_CLAIM" envDefault:"scope"`
+	// ScopeMap maps the values of ScopesClaim onto scopes, e.g. ods.read=organisations:read. Values missing from it
+	// are taken as scopes themselves.
+	ScopeMap []string `env:"JWT_SCOPE_MAP" envSeparator:","`
+}
+
+// Enabled reports whether bearer tokens are accepted.
+func (c JWTConfig) Enabled() bool {
+	return c.JWKSURL != "" || c.JWKSFile != ""
+}
+
+// Scopes returns ScopeMap as a map, skipping malformed entries, which Validate reports.
+func (c JWTConfig) Scopes() map[string]string {
+	scopes := make(map[string]string, len(c.ScopeMap))
+	for _, entry := range c.ScopeMap {
+		if value, scope, ok := strings.Cut(entry, "="); ok {
+			scopes[strings.TrimSpace(value)] = strings.TrimSpace(scope)
+		}
+	}
+	return scopes
+}
+
+type CORSConfig struct {
+	// AllowOrigins lists the origins allowed to call the API from a browser: exact origins, wildcard subdomains and
+	// ports such as https://*.example.com:*, regular expressions prefixed with "regex:", or "*" for all. Load sets
+	// it to DefaultCORSOrigins of the account when it is empty.
+	AllowOrigins     []string      `env:"CORS_ALLOW_ORIGINS" envSeparator:"," reload:"true"`
+	AllowMethods     []string      `env:"CORS_ALLOW_METHODS" envDefault:"GET,POST,PUT,PATCH,DELETE,OPTIONS" envSeparator:"," reload:"true"`
+	AllowHeaders     []string      `env:"CORS_ALLOW_HEADERS" envDefault:"Content-Type,Authorization,X-API-Key,x-correlation-id,X-Request-Priority,traceparent,tracestate" envSeparator:"," reload:"true"` //nolint:lll // default list
+	ExposeHeaders    []string      `env:"CORS_EXPOSE_HEADERS" envDefault:"X-Request-Id,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy" envSeparator:"," reload:"true"`  //nolint:lll // default list
+	AllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" envDefault:"false" reload:"true"`
+	MaxAge           time.Duration `env:"CORS_MAX_AGE" envDefault:"10m" reload:"true"`
+}
+
+// DefaultCORSOrigins returns the origins allowed when CORS_ALLOW_ORIGINS is not set: localhost, and the front ends
+// of account.
+func DefaultCORSOrigins(account string) []string {
+	origins := []string{"http://localhost:*"}
+	domain := map[string]string{
+		"dev":        "elevate-dev.cleosystems.com",
+		"staging":    "elevate-stg.cleosystems.com",
+		"production": "elevate.cleosystems.com",
+	}[account]
+	if domain != "" {
+		origins = append(origins, "https://*."+domain, "https://*."+domain+":*", "https://integration."+domain)
+	}
+	return origins
 }
 
 type ODSConfig struct {
 	ServerURL string `env:"ODS_FHIR_API_SERVER_URL"`
+
+	// RateLimit and RateBurst apply to all calls this instance makes to the ODS API, in requests per second.
+	// Up to MaxQueue calls wait for the limit before calls are rejected with 503.
+	RateLimit float64 `env:"ODS_RATE_LIMIT" envDefault:"5"`
+	RateBurst int     `env:"ODS_RATE_BURST" envDefault:"5"`
+	MaxQueue  int     `env:"ODS_MAX_QUEUE" envDefault:"100"`
+
+	// Timeouts bound each attempt of a call, failed attempts are retried up to MaxRetries times with backoff.
+	OrganisationTimeout time.Duration `env:"ODS_ORGANISATION_TIMEOUT" envDefault:"5s" reload:"true"`
+	SearchTimeout       time.Duration `env:"ODS_SEARCH_TIMEOUT" envDefault:"10s" reload:"true"`
+	DefaultTimeout      time.Duration `env:"ODS_DEFAULT_TIMEOUT" envDefault:"10s" reload:"true"`
+	MaxRetries          int           `env:"ODS_MAX_RETRIES" envDefault:"2"`
+	RetryBaseDelay      time.Duration `env:"ODS_RETRY_BASE_DELAY" envDefault:"200ms"`
+	RetryMaxDelay       time.Duration `env:"ODS_RETRY_MAX_DELAY" envDefault:"5s"`
+
+	// BreakerFailureThreshold consecutive failures stop calls to the ODS API for BreakerOpenDuration.
+	BreakerFailureThreshold int           `env:"ODS_BREAKER_FAILURE_THRESHOLD" envDefault:"5"`
+	BreakerOpenDuration     time.Duration `env:"ODS_BREAKER_OPEN_DURATION" envDefault:"30s"`
+
+	// ProxyURL overrides HTTPS_PROXY for calls to the ODS API, CABundleFile adds trusted CAs (e.g. of an
+	// intercepting egress proxy) and ClientCertFile/ClientKeyFile enable mutual TLS.
+	ProxyURL       string `env:"ODS_PROXY_URL" secret:"true"`
+	CABundleFile   string `env:"ODS_CA_BUNDLE_FILE"`
+	ClientCertFile string `env:"ODS_CLIENT_CERT_FILE"`
+	ClientKeyFile  string `env:"ODS_CLIENT_KEY_FILE"`
+
+	MaxIdleConns          int           `env:"ODS_MAX_IDLE_CONNS" envDefault:"100"`
+	MaxIdleConnsPerHost   int           `env:"ODS_MAX_IDLE_CONNS_PER_HOST" envDefault:"10"`
+	MaxConnsPerHost       int           `env:"ODS_MAX_CONNS_PER_HOST" envDefault:"0"`
+	IdleConnTimeout       time.Duration `env:"ODS_IDLE_CONN_TIMEOUT" envDefault:"90s"`
+	DisableHTTP2          bool          `env:"ODS_DISABLE_HTTP2" envDefault:"false"`
+	DialTimeout           time.Duration `env:"ODS_DIAL_TIMEOUT" envDefault:"5s"`
+	TLSHandshakeTimeout   time.Duration `env:"ODS_TLS_HANDSHAKE_TIMEOUT" envDefault:"5s"`
+	ResponseHeaderTimeout time.Duration `env:"ODS_RESPONSE_HEADER_TIMEOUT" envDefault:"0s"`
+
+	// DriftSampleRate is the fraction of ODS API responses checked against the vendored spec, from 0 to 1.
+	DriftSampleRate float64 `env:"ODS_DRIFT_SAMPLE_RATE" envDefault:"0.05"`
 }
 
-func NewConfigFromEnv() (Config, error) {
-	var cfg Config
-	if err := env.Parse(&cfg); err != nil {
-		return cfg, err
-	}
+type CacheConfig struct {
+	// Backend is one of none, memory or redis.
+	Backend         string        `env:"CACHE_BACKEND" envDefault:"memory"`
+	MaxEntries      int           `env:"CACHE_MAX_ENTRIES" envDefault:"10000"`
+	OrganisationTTL time.Duration `env:"CACHE_ORGANISATION_TTL" envDefault:"1h"`
+	SearchTTL       time.Duration `env:"CACHE_SEARCH_TTL" envDefault:"5m"`
+	NotFoundTTL     time.Duration `env:"CACHE_NOT_FOUND_TTL" envDefault:"1m"`
+
+	// StaleWhileRevalidate is how long past its TTL an entry is served while being refreshed in the background,
+	// StaleIfError how long past its TTL an entry is served when the ODS API is unavailable.
+	StaleWhileRevalidate time.Duration `env:"CACHE_STALE_WHILE_REVALIDATE" envDefault:"1m"`
+	StaleIfError         time.Duration `env:"CACHE_STALE_IF_ERROR" envDefault:"72h"`
+
+	RedisAddr      string `env:"CACHE_REDIS_ADDR"`
+	RedisUsername  string `env:"CACHE_REDIS_USERNAME"`
+	RedisPassword  string `env:"CACHE_REDIS_PASSWORD" secret:"true"`
+	RedisDB        int    `env:"CACHE_REDIS_DB" envDefault:"0"`
+	RedisKeyPrefix string `env:"CACHE_REDIS_KEY_PREFIX" envDefault:"ods-gateway:"`
+}
 
-	return cfg, nil
+type TracingConfig struct {
+	// OTLPEndpoint is the OTLP/HTTP collector spans are exported to, e.g. http://otel-collector:4318.
+	// Tracing context is still propagated when it is empty, but no spans are exported.
+	OTLPEndpoint string  `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName  string  `env:"OTEL_SERVICE_NAME" envDefault:"ods-fhir-gateway"`
+	SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
 }
+
+type ErrorReportingConfig struct {
+	// SentryDSN is the Sentry project error logs and recovered panics are reported to. Nothing is reported when it
+	// is empty.
+	SentryDSN string `env:"SENTRY_DSN" secret:"true"`
+	// SentryEnvironment defaults to the account.
+	SentryEnvironment string `env:"SENTRY_ENVIRONMENT"`
+	SentryRelease     string `env:"SENTRY_RELEASE"`
+	// DuplicateWindow is how long an error of the same type and message is reported only once; 0 reports every one.
+	DuplicateWindow time.Duration `env:"ERROR_REPORT_DUPLICATE_WINDOW" envDefault:"1m"`
+}
+
+type HealthConfig struct {
+	// CacheTTL is how long the result of a dependency check is reused by /readiness and /health/details.
+	CacheTTL            time.Duration `env:"HEALTH_CHECK_CACHE_TTL" envDefault:"10s"`
+	MetadataTimeout     time.Duration `env:"HEALTH_ODS_METADATA_TIMEOUT" envDefault:"3s"`
+	ReachabilityTimeout time.Duration `env:"HEALTH_UPSTREAM_REACHABILITY_TIMEOUT" envDefault:"2s"`
+	// DrainDelay is how long readiness fails before the server stops accepting connections on shutdown, for load
+	// balancers to stop routing traffic to the instance.
+	DrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5s"`
+}
+
+const (
+	CacheBackendNone   = "none"
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
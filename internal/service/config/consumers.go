@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+)
+
+// DefaultConsumer is the consumer owning API_KEY, granted every scope.
+const DefaultConsumer = "default"
+
+// Consumers returns the consumers of the API: those of the key store, and DefaultConsumer when API_KEY is set.
+func (c Config) Consumers() []auth.ConsumerConfig {
+	consumers := append([]auth.ConsumerConfig(nil), c.APIConsumers...)
+	if c.APIKey != "" {
+		consumers = append(consumers, auth.ConsumerConfig{
+			Name:   DefaultConsumer,
+			Scopes: []string{auth.ScopeAll},
+			Keys:   []auth.KeyConfig{{SHA256: auth.HashKey(c.APIKey)}},
+		})
+	}
+	return consumers
+}
+
+func readKeyStore(path string) ([]auth.ConsumerConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("API_KEY_STORE: %w", err)
+	}
+
+	consumers, err := auth.ParseKeyStore(raw)
+	if err != nil {
+		return nil, fmt.Errorf("API_KEY_STORE %s: %w", path, err)
+	}
+	return consumers, nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Diff returns the names of the settings which differ from one configuration to the other, split into those
+// applied by a reload and those which need a restart.
+func Diff(from, to Config) (reloadable, restart []string) {
+	diff(reflect.ValueOf(from), reflect.ValueOf(to), func(name string, reload bool) {
+		if reload {
+			reloadable = append(reloadable, name)
+		} else {
+			restart = append(restart, name)
+		}
+	})
+	return reloadable, restart
+}
+
+func diff(from, to reflect.Value, changed func(name string, reload bool)) {
+	t := from.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			diff(from.Field(i), to.Field(i), changed)
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			// settings read from files are named after the setting giving the file.
+			name, ok = field.Tag.Lookup("loadedFrom")
+		}
+		if !ok || reflect.DeepEqual(from.Field(i).Interface(), to.Field(i).Interface()) {
+			continue
+		}
+		changed(name, field.Tag.Get("reload") == "true")
+	}
+}
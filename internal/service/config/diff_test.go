@@ -0,0 +1,32 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+)
+
+func TestDiff_SplitsReloadableSettings(t *testing.T) {
+	t.Parallel()
+
+	from := config.Config{APIKey: "old", HTTPPort: "8080", LogLevel: "INFO"}
+	from.CORSConfig.AllowOrigins = []string{"https://a.example.com"}
+	from.ODSConfig.SearchTimeout = time.Second
+
+	to := from
+	to.APIKey = "new"
+	to.HTTPPort = "9090"
+	to.CORSConfig.AllowOrigins = []string{"https://a.example.com", "https://b.example.com"}
+	to.ODSConfig.SearchTimeout = 2 * time.Second
+
+	reloadable, restart := config.Diff(from, to)
+	assert.ElementsMatch(t, []string{"API_KEY", "CORS_ALLOW_ORIGINS", "ODS_SEARCH_TIMEOUT"}, reloadable)
+	assert.Equal(t, []string{"PORT"}, restart)
+
+	reloadable, restart = config.Diff(from, from)
+	assert.Empty(t, reloadable)
+	assert.Empty(t, restart)
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// SecretFileSuffix names the variant of a secret setting which holds the path of a file to read the secret from,
+// e.g. API_KEY_FILE=/run/secrets/api-key.
+const SecretFileSuffix = "_FILE"
+
+// Load builds the configuration from layers of settings, each overriding the ones before it:
+//
+//  1. the defaults of Config,
+//  2. each of files, in order,
+//  3. environment variables.
+//
+// Files are YAML or JSON maps keyed by the names of the environment variables, e.g. `ODS_RATE_LIMIT: 5`, with lists
+// for list settings. A secret, such as API_KEY, may instead be read from the file named by API_KEY_FILE; setting
+// either one in a layer overrides both in the layers before it.
+//
+// Load reports every problem found, in the files or by Validate, at once.
+func Load(files ...string) (Config, error) {
+	known := knownKeys()
+	settings := make(map[string]string)
+
+	var errs []error
+	for _, file := range files {
+		layer, err := readFile(file, known)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := overlay(settings, layer); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+		}
+	}
+	if err := overlay(settings, environ(known)); err != nil {
+		errs = append(errs, fmt.Errorf("environment: %w", err))
+	}
+
+	for _, secret := range secretKeys() {
+		path, ok := settings[secret+SecretFileSuffix]
+		if !ok {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: %w", secret, SecretFileSuffix, err))
+			continue
+		}
+		settings[secret] = strings.TrimRight(string(content), "\r\n")
+	}
+
+	var cfg Config
+	if err := env.Parse(&cfg, env.Options{Environment: settings}); err != nil {
+		errs = append(errs, err)
+	}
+	if len(cfg.CORSConfig.AllowOrigins) == 0 {
+		cfg.CORSConfig.AllowOrigins = DefaultCORSOrigins(cfg.Account)
+	}
+	if cfg.APIKeyStore != "" {
+		consumers, err := readKeyStore(cfg.APIKeyStore)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		cfg.APIConsumers = consumers
+	}
+	// settings which failed to parse are left empty, and may be reported again here.
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// overlay copies layer onto settings. A secret set in layer replaces the secret file set in settings, and the other
+// way round.
+func overlay(settings, layer map[string]string) error {
+	for _, secret := range secretKeys() {
+		_, value := layer[secret]
+		_, file := layer[secret+SecretFileSuffix]
+		switch {
+		case value && file:
+			return fmt.Errorf("only one of %s and %s%s may be set", secret, secret, SecretFileSuffix)
+		case value:
+			delete(settings, secret+SecretFileSuffix)
+		case file:
+			delete(settings, secret)
+		}
+	}
+
+	for key, value := range layer {
+		settings[key] = value
+	}
+	return nil
+}
+
+// readFile reads a YAML or JSON config file, rejecting keys which are not settings.
+func readFile(path string, known map[string]struct{}) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is a subset of YAML.
+	var values map[string]any
+	if err := yaml.Unmarshal(raw, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	layer := make(map[string]string, len(values))
+	var errs []error
+	for key, value := range values {
+		if _, ok := known[key]; !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %s", path, key))
+			continue
+		}
+
+		switch v := value.(type) {
+		case nil:
+			layer[key] = ""
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			layer[key] = strings.Join(items, ",")
+		case map[string]any:
+			errs = append(errs, fmt.Errorf("%s: %s must be a value or a list", path, key))
+		default:
+			layer[key] = fmt.Sprint(v)
+		}
+	}
+	// keys are visited in map order: sort the problems to report them consistently.
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+
+	return layer, errors.Join(errs...)
+}
+
+// environ returns the environment variables which are settings.
+func environ(known map[string]struct{}) map[string]string {
+	layer := make(map[string]string)
+	for key := range known {
+		if value, ok := os.LookupEnv(key); ok {
+			layer[key] = value
+		}
+	}
+	return layer
+}
+
+// knownKeys returns the names of every setting, including the file variants of secrets.
+func knownKeys() map[string]struct{} {
+	known := make(map[string]struct{})
+	eachSetting(reflect.TypeOf(Config{}), func(name string, secret bool) {
+		known[name] = struct{}{}
+		if secret {
+			known[name+SecretFileSuffix] = struct{}{}
+		}
+	})
+	return known
+}
+
+// secretKeys returns the names of the settings tagged `secret:"true"`.
+func secretKeys() []string {
+	var secrets []string
+	eachSetting(reflect.TypeOf(Config{}), func(name string, secret bool) {
+		if secret {
+			secrets = append(secrets, name)
+		}
+	})
+	return secrets
+}
+
+func eachSetting(t reflect.Type, fn func(name string, secret bool)) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			eachSetting(field.Type, fn)
+			continue
+		}
+		if name, ok := field.Tag.Lookup("env"); ok {
+			fn(name, field.Tag.Get("secret") == "true")
+		}
+	}
+}
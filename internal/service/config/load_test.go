@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+)
+
+// helper to write a config file into a test temp dir and return its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+const validConfig = `
+API_KEY: from-file
+ODS_FHIR_API_SERVER_URL: https://ods.example.com/STU3
+`
+
+// not parallel: the test sets environment variables.
+func TestLoad_LayersFilesAndEnvironment(t *testing.T) {
+	base := writeConfig(t, "base.yml", validConfig+`
+REQUEST_TIMEOUT: 20s
+ODS_RATE_LIMIT: 2.5
+ACCESS_LOG_REDACT_QUERY: [name, postcode]
+LOG_LEVEL: DEBUG
+`)
+	override := writeConfig(t, "override.json", `{"REQUEST_TIMEOUT": "15s", "ODS_MAX_RETRIES": 4}`)
+	t.Setenv("ODS_MAX_RETRIES", "1")
+
+	cfg, err := config.Load(base, override)
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-file", cfg.APIKey)
+	assert.Equal(t, 15*time.Second, cfg.RequestTimeout, "later files override earlier ones")
+	assert.Equal(t, 1, cfg.ODSConfig.MaxRetries, "the environment overrides files")
+	assert.InDelta(t, 2.5, cfg.ODSConfig.RateLimit, 0)
+	assert.Equal(t, []string{"name", "postcode"}, cfg.AccessLogRedactQuery)
+	assert.Equal(t, "DEBUG", cfg.LogLevel)
+	assert.Equal(t, "8080", cfg.HTTPPort, "defaults apply to settings left unset")
+}
+
+// not parallel: the test sets environment variables.
+func TestLoad_ReadsSecretsFromFiles(t *testing.T) {
+	secret := writeConfig(t, "api-key", "from-secret-file\n")
+	file := writeConfig(t, "config.yml", validConfig)
+
+	t.Setenv("API_KEY_FILE", secret)
+	cfg, err := config.Load(file)
+	require.NoError(t, err)
+	assert.Equal(t, "from-secret-file", cfg.APIKey, "a secret file set in a later layer replaces the secret")
+
+	t.Setenv("API_KEY", "from-env")
+	_, err = config.Load(file)
+	assert.ErrorContains(t, err, "only one of API_KEY and API_KEY_FILE may be set")
+}
+
+func TestLoad_DefaultsCORSOriginsToThoseOfTheAccount(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load(writeConfig(t, "config.yml", validConfig+"ACCOUNT: staging\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"http://localhost:*",
+		"https://*.elevate-stg.cleosystems.com",
+		"https://*.elevate-stg.cleosystems.com:*",
+		"https://integration.elevate-stg.cleosystems.com",
+	}, cfg.CORSConfig.AllowOrigins)
+
+	cfg, err = config.Load(writeConfig(t, "config.yml", validConfig+"ACCOUNT: staging\nCORS_ALLOW_ORIGINS: [https://app.example.com]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com"}, cfg.CORSConfig.AllowOrigins)
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	t.Parallel()
+
+	file := writeConfig(t, "config.yml", `
+ODS_FHIR_API_SERVER_URL: ods.example.com
+LOG_LEVEL: VERBOSE
+REQUEST_TIMEOUT: soon
+CACHE_BACKEND: redis
+AUTH_METHODS: [/organisations=bearer|password]
+`)
+	typo := writeConfig(t, "typo.yml", `
+PROT: 8080
+ODS_CLIENT_CERT_FILE:
+  path: /certs/client.pem
+`)
+
+	_, err := config.Load(file, typo)
+	require.Error(t, err)
+
+	for _, problem := range []string{
+		"unknown setting PROT",
+		"ODS_CLIENT_CERT_FILE must be a value or a list",
+		`invalid duration "soon"`,
+		"ODS_FHIR_API_SERVER_URL must be an http(s) URL",
+		"API_KEY, API_KEY_STORE or JWT_JWKS_URL is required",
+		"LOG_LEVEL must be one of DEBUG, INFO, WARN or ERROR",
+		"CACHE_REDIS_ADDR is required for the redis cache backend",
+		`AUTH_METHODS entries must be route=method|method with methods among bearer, api-key and certificate`,
+	} {
+		assert.ErrorContains(t, err, problem)
+	}
+}
+
+func TestLoad_ReadsKeyStore(t *testing.T) {
+	t.Parallel()
+
+	store := writeConfig(t, "api-keys.yml", `
+consumers:
+  - name: gp-portal
+    scopes: [organisations:read]
+    keys:
+      - sha256: `+auth.HashKey("gp-portal-key")+`
+`)
+	file := writeConfig(t, "config.yml", `
+ODS_FHIR_API_SERVER_URL: https://ods.example.com/STU3
+API_KEY_STORE: `+store+`
+`)
+
+	cfg, err := config.Load(file)
+	require.NoError(t, err)
+	require.Len(t, cfg.Consumers(), 1)
+	assert.Equal(t, "gp-portal", cfg.Consumers()[0].Name)
+
+	// a change to the key store is reloadable, though its path is the same.
+	rotated := cfg
+	rotated.APIConsumers = append([]auth.ConsumerConfig(nil), cfg.APIConsumers...)
+	rotated.APIConsumers[0].Keys = append(rotated.APIConsumers[0].Keys, auth.KeyConfig{SHA256: auth.HashKey("next-key")})
+	reloadable, restart := config.Diff(cfg, rotated)
+	assert.Equal(t, []string{"API_KEY_STORE"}, reloadable)
+	assert.Empty(t, restart)
+
+	invalid := writeConfig(t, "invalid.yml", "consumers:\n  - name: gp-portal\n    scopes: [organisations:write]\n")
+	_, err = config.Load(file, writeConfig(t, "override.yml", "API_KEY_STORE: "+invalid))
+	assert.ErrorContains(t, err, `unknown scope "organisations:write"`)
+}
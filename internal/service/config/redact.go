@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// Redacted returns the configuration keyed by environment variable, with the values of fields tagged
+// `secret:"true"` replaced, so that it can be shown to operators. The ODS proxy URL counts as a secret, as it may
+// carry credentials. Secrets left empty are shown as empty, to tell them apart from those set.
+func (c Config) Redacted() map[string]any {
+	out := make(map[string]any)
+	redact(reflect.ValueOf(c), out)
+	return out
+}
+
+func redact(v reflect.Value, out map[string]any) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			redact(value, out)
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+
+		switch {
+		case field.Tag.Get("secret") == "true" && !value.IsZero():
+			out[name] = redacted
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			out[name] = time.Duration(value.Int()).String()
+		default:
+			out[name] = value.Interface()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/cors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+)
+
+// responseValidationModes are the modes of the runtime response validator.
+var responseValidationModes = []string{"off", "log", "fail"}
+
+// Validate reports every problem found in the configuration, including certificate files that cannot be read.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	u, err := url.Parse(c.ODSConfig.ServerURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"ODS_FHIR_API_SERVER_URL must be an http(s) URL, got %q", c.ODSConfig.ServerURL)
+	if _, err := auth.NewKeyStore(c.Consumers()); err != nil {
+		errs = append(errs, fmt.Errorf("API_KEY_STORE: %w", err))
+	}
+
+	_, err = elog.ParseLevel(c.LogLevel)
+	check(err == nil, "LOG_LEVEL must be one of DEBUG, INFO, WARN or ERROR, got %q", c.LogLevel)
+	check(slices.Contains(responseValidationModes, c.ResponseValidation),
+		"RESPONSE_VALIDATION must be one of off, log or fail, got %q", c.ResponseValidation)
+
+	check(validPort(c.HTTPPort), "PORT must be a port number, got %q", c.HTTPPort)
+	check(c.MetricsPort == "" || validPort(c.MetricsPort), "METRICS_PORT must be a port number, got %q", c.MetricsPort)
+	check(c.AdminPort == "" || validPort(c.AdminPort), "ADMIN_PORT must be a port number, got %q", c.AdminPort)
+
+	for _, timeout := range []struct {
+		env   string
+		value time.Duration
+	}{
+		{env: "REQUEST_TIMEOUT", value: c.RequestTimeout},
+		{env: "ODS_ORGANISATION_TIMEOUT", value: c.ODSConfig.OrganisationTimeout},
+		{env: "ODS_SEARCH_TIMEOUT", value: c.ODSConfig.SearchTimeout},
+		{env: "ODS_DEFAULT_TIMEOUT", value: c.ODSConfig.DefaultTimeout},
+	} {
+		check(timeout.value > 0, "%s must be positive, got %s", timeout.env, timeout.value)
+	}
+	check(c.ODSConfig.MaxRetries >= 0, "ODS_MAX_RETRIES must not be negative, got %d", c.ODSConfig.MaxRetries)
+	check(c.ODSConfig.RateLimit <= 0 || c.ODSConfig.RateBurst > 0,
+		"ODS_RATE_BURST must be positive when ODS_RATE_LIMIT is set, got %d", c.ODSConfig.RateBurst)
+	check((c.ODSConfig.ClientCertFile == "") == (c.ODSConfig.ClientKeyFile == ""),
+		"ODS_CLIENT_CERT_FILE and ODS_CLIENT_KEY_FILE must be set together")
+	check(c.ODSConfig.DriftSampleRate >= 0 && c.ODSConfig.DriftSampleRate <= 1,
+		"ODS_DRIFT_SAMPLE_RATE must be between 0 and 1, got %v", c.ODSConfig.DriftSampleRate)
+	check(c.TracingConfig.SampleRatio >= 0 && c.TracingConfig.SampleRatio <= 1,
+		"TRACING_SAMPLE_RATIO must be between 0 and 1, got %v", c.TracingConfig.SampleRatio)
+
+	if c.JWTConfig.Enabled() {
+		check(c.JWTConfig.JWKSURL == "" || c.JWTConfig.JWKSFile == "", "only one of JWT_JWKS_URL and JWT_JWKS_FILE may be set")
+		jwks, err := url.Parse(c.JWTConfig.JWKSURL)
+		check(c.JWTConfig.JWKSURL == "" || (err == nil && (jwks.Scheme == "http" || jwks.Scheme == "https") && jwks.Host != ""),
+			"JWT_JWKS_URL must be an http(s) URL, got %q", c.JWTConfig.JWKSURL)
+		check(c.JWTConfig.Issuer != "", "JWT_ISSUER is required to accept bearer tokens")
+		check(c.JWTConfig.Audience != "", "JWT_AUDIENCE is required to accept bearer tokens")
+		check(c.JWTConfig.JWKSCacheTTL > 0, "JWT_JWKS_CACHE_TTL must be positive, got %s", c.JWTConfig.JWKSCacheTTL)
+		check(c.JWTConfig.Leeway >= 0, "JWT_LEEWAY must not be negative, got %s", c.JWTConfig.Leeway)
+		for _, entry := range c.JWTConfig.ScopeMap {
+			_, scope, ok := strings.Cut(entry, "=")
+			check(ok && auth.KnownScope(strings.TrimSpace(scope)),
+				"JWT_SCOPE_MAP entries must be claim=scope with a known scope, got %q", entry)
+		}
+	}
+	check((c.TLSConfig.CertFile == "") == (c.TLSConfig.KeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	check(c.TLSConfig.ClientCAFile == "" || c.TLSConfig.Enabled(), "TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+	check(slices.Contains([]string{TLSClientAuthOptional, TLSClientAuthRequire}, c.TLSConfig.ClientAuth),
+		"TLS_CLIENT_AUTH must be one of optional or require, got %q", c.TLSConfig.ClientAuth)
+	check(c.TLSConfig.ClientAuth != TLSClientAuthRequire || c.TLSConfig.ClientCAFile != "",
+		"TLS_CLIENT_CA_FILE is required when TLS_CLIENT_AUTH is require")
+	check(!c.TLSConfig.Enabled() || c.TLSConfig.ReloadInterval > 0,
+		"TLS_RELOAD_INTERVAL must be positive, got %s", c.TLSConfig.ReloadInterval)
+	if err := c.ConsumerLimits.Limits().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("CONSUMER_RATE_LIMIT, CONSUMER_RATE_BURST, CONSUMER_DAILY_QUOTA: %w", err))
+	}
+	check(len(c.Consumers()) > 0 || c.JWTConfig.Enabled(), "API_KEY, API_KEY_STORE or JWT_JWKS_URL is required")
+	for _, entry := range c.AuthMethods {
+		route, list, ok := strings.Cut(entry, "=")
+		ok = ok && strings.HasPrefix(strings.TrimSpace(route), "/")
+		for _, method := range strings.Split(list, "|") {
+			ok = ok && auth.KnownMethod(strings.TrimSpace(method))
+		}
+		check(ok, "AUTH_METHODS entries must be route=method|method with methods among bearer, api-key and certificate, got %q",
+			entry)
+	}
+
+	origins, err := cors.ParseOrigins(c.CORSConfig.AllowOrigins)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("CORS_ALLOW_ORIGINS: %w", err))
+	}
+	check(!c.CORSConfig.AllowCredentials || !origins.AllowsAny(),
+		"CORS_ALLOW_CREDENTIALS must not be set when CORS_ALLOW_ORIGINS allows any origin")
+	check(c.CORSConfig.MaxAge >= 0, "CORS_MAX_AGE must not be negative, got %s", c.CORSConfig.MaxAge)
+
+	check(slices.Contains([]string{CacheBackendNone, CacheBackendMemory, CacheBackendRedis}, c.CacheConfig.Backend),
+		"CACHE_BACKEND must be one of none, memory or redis, got %q", c.CacheConfig.Backend)
+	check(c.CacheConfig.Backend != CacheBackendRedis || c.CacheConfig.RedisAddr != "",
+		"CACHE_REDIS_ADDR is required for the redis cache backend")
+
+	check(c.AuditConfig.MaxFileSize >= 0, "AUDIT_MAX_FILE_SIZE must not be negative, got %d", c.AuditConfig.MaxFileSize)
+	check(c.AuditConfig.RotateInterval >= 0, "AUDIT_ROTATE_INTERVAL must not be negative, got %s", c.AuditConfig.RotateInterval)
+	check(c.AuditConfig.Retention >= 0, "AUDIT_RETENTION must not be negative, got %s", c.AuditConfig.Retention)
+
+	for _, file := range []struct{ env, path string }{
+		{env: "ODS_CA_BUNDLE_FILE", path: c.ODSConfig.CABundleFile},
+		{env: "ODS_CLIENT_CERT_FILE", path: c.ODSConfig.ClientCertFile},
+		{env: "ODS_CLIENT_KEY_FILE", path: c.ODSConfig.ClientKeyFile},
+		{env: "JWT_JWKS_FILE", path: c.JWTConfig.JWKSFile},
+		{env: "TLS_CERT_FILE", path: c.TLSConfig.CertFile},
+		{env: "TLS_KEY_FILE", path: c.TLSConfig.KeyFile},
+		{env: "TLS_CLIENT_CA_FILE", path: c.TLSConfig.ClientCAFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.env, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
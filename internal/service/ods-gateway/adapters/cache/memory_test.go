@@ -0,0 +1,68 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/cache"
+)
+
+func TestMemory_SetGet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := cache.NewMemory(10)
+
+	require.NoError(t, c.Set(ctx, "RR8", []byte("value"), time.Minute))
+
+	got, found, err := c.Get(ctx, "RR8")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("value"), got)
+
+	_, found, err = c.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestMemory_ExpiresEntries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := cache.NewMemory(10)
+
+	require.NoError(t, c.Set(ctx, "RR8", []byte("value"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	_, found, err := c.Get(ctx, "RR8")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := cache.NewMemory(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("b"), time.Minute))
+
+	// touch "a" so "b" becomes the least recently used entry
+	_, _, _ = c.Get(ctx, "a")
+	require.NoError(t, c.Set(ctx, "c", []byte("c"), time.Minute))
+
+	assert.Equal(t, 2, c.Len())
+
+	_, found, _ := c.Get(ctx, "b")
+	assert.False(t, found)
+	_, found, _ = c.Get(ctx, "a")
+	assert.True(t, found)
+	_, found, _ = c.Get(ctx, "c")
+	assert.True(t, found)
+}
@@ -0,0 +1,63 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/cache"
+)
+
+func newRedisWithStandIn(t *testing.T) (*cache.Redis, *miniredis.Miniredis) {
+	t.Helper()
+
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return cache.NewRedis(client, "ods-gateway:"), srv
+}
+
+func TestRedis_SetGet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, srv := newRedisWithStandIn(t)
+
+	require.NoError(t, c.Set(ctx, "RR8", []byte("value"), time.Minute))
+	assert.True(t, srv.Exists("ods-gateway:RR8"))
+
+	got, found, err := c.Get(ctx, "RR8")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("value"), got)
+}
+
+func TestRedis_ExpiresEntries(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, srv := newRedisWithStandIn(t)
+
+	require.NoError(t, c.Set(ctx, "RR8", []byte("value"), time.Minute))
+	srv.FastForward(2 * time.Minute)
+
+	_, found, err := c.Get(ctx, "RR8")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestRedis_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	c, srv := newRedisWithStandIn(t)
+	srv.Close()
+
+	_, _, err := c.Get(context.Background(), "RR8")
+	require.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package odsfhir
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type attemptOutcome int
+
+const (
+	// outcomeSuccess means ODS answered, even if it rejected the request.
+	outcomeSuccess attemptOutcome = iota
+	// outcomeFailure means ODS could not be reached, timed out or failed with a server error.
+	outcomeFailure
+	// outcomeIgnored means the attempt says nothing about the health of ODS, e.g. the caller went away.
+	outcomeIgnored
+)
+
+// circuitBreaker opens after threshold consecutive failures and fails calls fast for openFor.
+// It then lets a single probe through: its success closes the breaker, its failure opens it again.
+type circuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// newCircuitBreaker returns a breaker which never opens when threshold is zero or less.
+func newCircuitBreaker(threshold int, openFor time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		openFor:   openFor,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a call may go ahead and, when it may not, how long until the breaker lets a probe through.
+func (b *circuitBreaker) allow() (time.Duration, bool) {
+	if b.threshold <= 0 {
+		return 0, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		remaining := b.openFor - b.now().Sub(b.openedAt)
+		if remaining > 0 {
+			return remaining, false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return 0, true
+	case breakerHalfOpen:
+		if b.probing {
+			return b.openFor, false
+		}
+		b.probing = true
+		return 0, true
+	default:
+		return 0, true
+	}
+}
+
+// record feeds the outcome of an allowed call back into the breaker.
+func (b *circuitBreaker) record(outcome attemptOutcome) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch outcome {
+	case outcomeSuccess:
+		b.state = breakerClosed
+		b.failures = 0
+		b.probing = false
+	case outcomeFailure:
+		b.failures++
+		if b.state == breakerHalfOpen || b.failures >= b.threshold {
+			b.state = breakerOpen
+			b.openedAt = b.now()
+		}
+		b.probing = false
+	case outcomeIgnored:
+		b.probing = false
+	}
+}
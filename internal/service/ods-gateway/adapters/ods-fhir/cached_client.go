@@ -0,0 +1,259 @@
+package odsfhir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+const (
+	organisationKeyPrefix = "v1:organisation:"
+	searchKeyPrefix       = "v1:search:"
+
+	defaultRefreshTimeout = 30 * time.Second
+)
+
+// CacheTTLs configures how long each kind of upstream response is cached. A zero TTL disables caching it.
+//
+// Once an entry is older than its TTL it may still be served for StaleWhileRevalidate while it is refreshed
+// in the background, and for StaleIfError when the ODS API cannot be reached.
+type CacheTTLs struct {
+	Organisation         time.Duration
+	Search               time.Duration
+	NotFound             time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+	RefreshTimeout       time.Duration
+}
+
+type cacheEntry struct {
+	StoredAt     time.Time                      `json:"storedAt"`
+	NotFound     bool                           `json:"notFound,omitempty"`
+	Organisation *fhirHTTP.OrganizationResource `json:"organisation,omitempty"`
+	Bundle       *fhirHTTP.OrganizationBundle   `json:"bundle,omitempty"`
+}
+
+type freshness int
+
+const (
+	fresh freshness = iota
+	revalidate
+	expired
+)
+
+// CachedClient serves organisation lookups and searches from a ResponseCache, falling back to the wrapped client.
+type CachedClient struct {
+	next  common.OdsFHIRClient
+	cache common.ResponseCache
+	ttls  CacheTTLs
+	now   func() time.Time
+
+	refreshing sync.Map
+}
+
+func NewCachedClient(next common.OdsFHIRClient, cache common.ResponseCache, ttls CacheTTLs) *CachedClient {
+	if ttls.RefreshTimeout <= 0 {
+		ttls.RefreshTimeout = defaultRefreshTimeout
+	}
+
+	return &CachedClient{
+		next:  next,
+		cache: cache,
+		ttls:  ttls,
+		now:   time.Now,
+	}
+}
+
+func (c *CachedClient) SearchOrganisations(
+	ctx context.Context,
+	req common.SeachOrganisationsRequest,
+) (*fhirHTTP.OrganizationBundle, error) {
+	key := searchKeyPrefix + req.CacheKey()
+
+	entry, found := c.load(ctx, key)
+	found = found && entry.Bundle != nil
+	if found {
+		switch c.freshness(entry, c.ttls.Search) {
+		case fresh:
+			return entry.Bundle, nil
+		case revalidate:
+			c.refreshInBackground(ctx, key, func(ctx context.Context) error {
+				_, err := c.fetchSearch(ctx, key, req)
+				return err
+			})
+			common.MarkStale(ctx, entry.StoredAt)
+			return entry.Bundle, nil
+		}
+	}
+
+	bundle, err := c.fetchSearch(ctx, key, req)
+	if err != nil && found && c.usableOnError(entry, c.ttls.Search, err) {
+		elog.Ctx(ctx).Warn().Err(err).Time("storedAt", entry.StoredAt).Msg("ODS API unavailable, serving stale search results")
+		common.MarkStale(ctx, entry.StoredAt)
+		return entry.Bundle, nil
+	}
+
+	return bundle, err
+}
+
+func (c *CachedClient) GetOrganisationByID(ctx context.Context, organisationID string) (*fhirHTTP.OrganizationResource, error) {
+	key := organisationKeyPrefix + strings.ToUpper(strings.TrimSpace(organisationID))
+
+	entry, found := c.load(ctx, key)
+	if found && entry.NotFound {
+		return nil, apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API").
+			WithDetail("upstreamStatus", http.StatusNotFound)
+	}
+
+	found = found && entry.Organisation != nil
+	if found {
+		switch c.freshness(entry, c.ttls.Organisation) {
+		case fresh:
+			return entry.Organisation, nil
+		case revalidate:
+			c.refreshInBackground(ctx, key, func(ctx context.Context) error {
+				_, err := c.fetchOrganisation(ctx, key, organisationID)
+				return err
+			})
+			common.MarkStale(ctx, entry.StoredAt)
+			return entry.Organisation, nil
+		}
+	}
+
+	organisation, err := c.fetchOrganisation(ctx, key, organisationID)
+	if err != nil && found && c.usableOnError(entry, c.ttls.Organisation, err) {
+		elog.Ctx(ctx).Warn().Err(err).Time("storedAt", entry.StoredAt).Msg("ODS API unavailable, serving stale organisation")
+		common.MarkStale(ctx, entry.StoredAt)
+		return entry.Organisation, nil
+	}
+
+	return organisation, err
+}
+
+func (c *CachedClient) fetchSearch(
+	ctx context.Context,
+	key string,
+	req common.SeachOrganisationsRequest,
+) (*fhirHTTP.OrganizationBundle, error) {
+	bundle, err := c.next.SearchOrganisations(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(ctx, key, cacheEntry{Bundle: bundle}, c.retention(c.ttls.Search))
+	return bundle, nil
+}
+
+func (c *CachedClient) fetchOrganisation(
+	ctx context.Context,
+	key string,
+	organisationID string,
+) (*fhirHTTP.OrganizationResource, error) {
+	organisation, err := c.next.GetOrganisationByID(ctx, organisationID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		c.store(ctx, key, cacheEntry{NotFound: true}, c.ttls.NotFound)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(ctx, key, cacheEntry{Organisation: organisation}, c.retention(c.ttls.Organisation))
+	return organisation, nil
+}
+
+func (c *CachedClient) freshness(entry cacheEntry, ttl time.Duration) freshness {
+	age := c.now().Sub(entry.StoredAt)
+	switch {
+	case age < ttl:
+		return fresh
+	case age < ttl+c.ttls.StaleWhileRevalidate:
+		return revalidate
+	default:
+		return expired
+	}
+}
+
+// usableOnError reports whether entry may stand in for a response the ODS API failed to produce.
+// Rejections such as not found or invalid input are passed through; an overloaded upstream queue is not.
+func (c *CachedClient) usableOnError(entry cacheEntry, ttl time.Duration, err error) bool {
+	if !errors.Is(err, apperrors.ErrUpstreamUnavailable) && !errors.Is(err, apperrors.ErrUpstreamTimeout) &&
+		!errors.Is(err, apperrors.ErrOverloaded) {
+		return false
+	}
+	return c.now().Sub(entry.StoredAt) < ttl+c.ttls.StaleIfError
+}
+
+// retention is how long a successful response is kept: its TTL plus the longest stale window.
+func (c *CachedClient) retention(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	return ttl + max(c.ttls.StaleWhileRevalidate, c.ttls.StaleIfError)
+}
+
+// refreshInBackground runs refresh at most once per key at a time, detached from the request lifetime.
+// Refreshes queue behind interactive traffic when the ODS API is rate limited.
+func (c *CachedClient) refreshInBackground(ctx context.Context, key string, refresh func(ctx context.Context) error) {
+	if _, inFlight := c.refreshing.LoadOrStore(key, struct{}{}); inFlight {
+		return
+	}
+
+	go func() {
+		defer c.refreshing.Delete(key)
+
+		refreshCtx := common.WithPriority(context.WithoutCancel(ctx), common.PriorityBackground)
+		refreshCtx, cancel := context.WithTimeout(refreshCtx, c.ttls.RefreshTimeout)
+		defer cancel()
+
+		if err := refresh(refreshCtx); err != nil {
+			elog.Ctx(refreshCtx).Warn().Err(err).Str("key", key).Msg("error refreshing stale response cache entry")
+		}
+	}()
+}
+
+// load never fails the lookup: a broken cache only costs an upstream call.
+func (c *CachedClient) load(ctx context.Context, key string) (cacheEntry, bool) {
+	raw, found, err := c.cache.Get(ctx, key)
+	if err != nil {
+		elog.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("error reading from response cache")
+		return cacheEntry{}, false
+	}
+	if !found {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		elog.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("error decoding response cache entry")
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (c *CachedClient) store(ctx context.Context, key string, entry cacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	entry.StoredAt = c.now()
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		elog.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("error encoding response cache entry")
+		return
+	}
+
+	if err := c.cache.Set(ctx, key, raw, ttl); err != nil {
+		elog.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("error writing to response cache")
+	}
+}
@@ -0,0 +1,201 @@
+package odsfhir_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/cache"
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common/mocks"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+var testTTLs = odsfhir.CacheTTLs{
+	Organisation: time.Minute,
+	Search:       time.Minute,
+	NotFound:     time.Minute,
+}
+
+func newCachedClientWithMock(t *testing.T) (*odsfhir.CachedClient, *mocks.FakeOdsFHIRClient) {
+	t.Helper()
+
+	mockODS := &mocks.FakeOdsFHIRClient{}
+
+	return odsfhir.NewCachedClient(mockODS, cache.NewMemory(100), testTTLs), mockODS
+}
+
+func TestCachedClient_GetOrganisationByID_ServesFromCache(t *testing.T) {
+	t.Parallel()
+
+	client, mockODS := newCachedClientWithMock(t)
+	ctx := context.Background()
+
+	mockODS.GetOrganisationByIDReturns(&fhirHTTP.OrganizationResource{Id: "RR8", Name: "LEEDS TEACHING HOSPITALS NHS TRUST"}, nil)
+
+	first, err := client.GetOrganisationByID(ctx, "RR8")
+	require.NoError(t, err)
+
+	second, err := client.GetOrganisationByID(ctx, "rr8")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, mockODS.GetOrganisationByIDCallCount())
+	assert.Equal(t, first.Name, second.Name)
+}
+
+func TestCachedClient_GetOrganisationByID_CachesNotFound(t *testing.T) {
+	t.Parallel()
+
+	client, mockODS := newCachedClientWithMock(t)
+	ctx := context.Background()
+
+	mockODS.GetOrganisationByIDReturns(nil, apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API"))
+
+	_, err := client.GetOrganisationByID(ctx, "UNKNOWN")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	_, err = client.GetOrganisationByID(ctx, "UNKNOWN")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	assert.Equal(t, 1, mockODS.GetOrganisationByIDCallCount())
+}
+
+func TestCachedClient_GetOrganisationByID_DoesNotCacheFailures(t *testing.T) {
+	t.Parallel()
+
+	client, mockODS := newCachedClientWithMock(t)
+	ctx := context.Background()
+
+	mockODS.GetOrganisationByIDReturns(nil, apperrors.New(apperrors.ErrUpstreamUnavailable, "ODS API responded with 503"))
+
+	_, err := client.GetOrganisationByID(ctx, "RR8")
+	require.Error(t, err)
+	_, err = client.GetOrganisationByID(ctx, "RR8")
+	require.Error(t, err)
+
+	assert.Equal(t, 2, mockODS.GetOrganisationByIDCallCount())
+}
+
+func TestCachedClient_GetOrganisationByID_CacheErrorFallsBackToUpstream(t *testing.T) {
+	t.Parallel()
+
+	mockODS := &mocks.FakeOdsFHIRClient{}
+	mockCache := &mocks.FakeResponseCache{}
+	mockCache.GetReturns(nil, false, errors.New("connection refused"))
+	mockCache.SetReturns(errors.New("connection refused"))
+
+	mockODS.GetOrganisationByIDReturns(&fhirHTTP.OrganizationResource{Id: "RR8"}, nil)
+
+	org, err := odsfhir.NewCachedClient(mockODS, mockCache, testTTLs).GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+	assert.Equal(t, "RR8", org.Id)
+}
+
+func TestCachedClient_SearchOrganisations_KeyedByRequest(t *testing.T) {
+	t.Parallel()
+
+	client, mockODS := newCachedClientWithMock(t)
+	ctx := context.Background()
+
+	mockODS.SearchOrganisationsReturns(&fhirHTTP.OrganizationBundle{Total: utils.Ref("1")}, nil)
+
+	_, err := client.SearchOrganisations(ctx, common.SeachOrganisationsRequest{Name: utils.Ref("Leeds"), PageSize: 10, Page: 1})
+	require.NoError(t, err)
+
+	// same search, different case: served from cache
+	_, err = client.SearchOrganisations(ctx, common.SeachOrganisationsRequest{Name: utils.Ref("LEEDS "), PageSize: 10, Page: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, mockODS.SearchOrganisationsCallCount())
+
+	// next page: different key
+	_, err = client.SearchOrganisations(ctx, common.SeachOrganisationsRequest{Name: utils.Ref("Leeds"), PageSize: 10, Page: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, mockODS.SearchOrganisationsCallCount())
+}
+
+func TestCachedClient_GetOrganisationByID_StaleIfError(t *testing.T) {
+	t.Parallel()
+
+	mockODS := &mocks.FakeOdsFHIRClient{}
+	client := odsfhir.NewCachedClient(mockODS, cache.NewMemory(100), odsfhir.CacheTTLs{
+		Organisation: 10 * time.Millisecond,
+		StaleIfError: time.Hour,
+	})
+
+	mockODS.GetOrganisationByIDReturns(&fhirHTTP.OrganizationResource{Id: "RR8"}, nil)
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	mockODS.GetOrganisationByIDReturns(nil, apperrors.New(apperrors.ErrUpstreamTimeout, "ODS API timed out"))
+
+	ctx, freshness := common.WithFreshness(context.Background())
+	org, err := client.GetOrganisationByID(ctx, "RR8")
+	require.NoError(t, err)
+	assert.Equal(t, "RR8", org.Id)
+	assert.Equal(t, 2, mockODS.GetOrganisationByIDCallCount())
+
+	stale, storedAt := freshness.Stale()
+	assert.True(t, stale)
+	assert.False(t, storedAt.IsZero())
+}
+
+func TestCachedClient_GetOrganisationByID_StaleNotServedForRejections(t *testing.T) {
+	t.Parallel()
+
+	mockODS := &mocks.FakeOdsFHIRClient{}
+	client := odsfhir.NewCachedClient(mockODS, cache.NewMemory(100), odsfhir.CacheTTLs{
+		Organisation: 10 * time.Millisecond,
+		StaleIfError: time.Hour,
+	})
+
+	mockODS.GetOrganisationByIDReturns(&fhirHTTP.OrganizationResource{Id: "RR8"}, nil)
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	mockODS.GetOrganisationByIDReturns(nil, apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API"))
+
+	_, err = client.GetOrganisationByID(context.Background(), "RR8")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestCachedClient_SearchOrganisations_StaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	mockODS := &mocks.FakeOdsFHIRClient{}
+	client := odsfhir.NewCachedClient(mockODS, cache.NewMemory(100), odsfhir.CacheTTLs{
+		Search:               10 * time.Millisecond,
+		StaleWhileRevalidate: time.Hour,
+	})
+	req := common.SeachOrganisationsRequest{Name: utils.Ref("Leeds"), PageSize: 10, Page: 1}
+
+	mockODS.SearchOrganisationsReturns(&fhirHTTP.OrganizationBundle{Total: utils.Ref("1")}, nil)
+	_, err := client.SearchOrganisations(context.Background(), req)
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	mockODS.SearchOrganisationsReturns(&fhirHTTP.OrganizationBundle{Total: utils.Ref("2")}, nil)
+
+	ctx, freshness := common.WithFreshness(context.Background())
+	bundle, err := client.SearchOrganisations(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "1", *bundle.Total)
+
+	stale, _ := freshness.Stale()
+	assert.True(t, stale)
+
+	// the stale entry is refreshed in the background
+	require.Eventually(t, func() bool {
+		bundle, err := client.SearchOrganisations(context.Background(), req)
+		return err == nil && *bundle.Total == "2"
+	}, time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, mockODS.SearchOrganisationsCallCount(), 2)
+}
@@ -2,21 +2,23 @@ package odsfhir
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
-	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
-
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
-	http "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
 )
 
 type Client struct {
-	apiClient http.ClientWithResponsesInterface
+	apiClient fhirHTTP.ClientWithResponsesInterface
 }
 
-func NewClient(apiClient http.ClientWithResponsesInterface) *Client {
+func NewClient(apiClient fhirHTTP.ClientWithResponsesInterface) *Client {
 	return &Client{
 		apiClient: apiClient,
 	}
@@ -25,42 +27,72 @@ func NewClient(apiClient http.ClientWithResponsesInterface) *Client {
 func (c *Client) SearchOrganisations(
 	ctx context.Context,
 	req common.SeachOrganisationsRequest,
-) (*http.OrganizationBundle, error) {
-	params := http.GetOrganizationResourcesParams{
+) (*fhirHTTP.OrganizationBundle, error) {
+	params := fhirHTTP.GetOrganizationResourcesParams{
 		NameContains:              req.Name,
 		Active:                    req.Active,
 		AddressPostalcodeContains: req.Postcode,
 		AddressCityContains:       req.City,
 		OdsOrgRole:                req.RoleCode,
 		OdsOrgPrimaryRole:         req.PrimaryRoleOnly,
+		UnderscoreLastUpdated:     lastUpdatedFilter(req.LastUpdatedFrom),
 		UnderscoreCount:           utils.Ref(fmt.Sprintf("%d", req.PageSize)),
 		UnderscorePage:            utils.Ref(fmt.Sprintf("%d", req.Page)),
 	}
 	resp, err := c.apiClient.GetOrganizationResourcesWithResponse(ctx, &params)
 	if err != nil {
-		log.Err(err).Msg("error getting organisations from ODS API")
+		logFailure(ctx, err, "error getting organisations from ODS API")
+		return nil, transportError(err, "error getting organisations from ODS API")
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		err = statusError(resp.StatusCode(), resp.Status(), resp.Body)
+		logFailure(ctx, err, "error getting organisations from ODS API")
 		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		log.Err(errors.New(resp.Status())).Msg("error getting organisations from ODS API")
-		return nil, errors.New(resp.Status())
+	if resp.ApplicationfhirJSON200 == nil {
+		return nil, apperrors.New(apperrors.ErrMapping, "unexpected organisations response from ODS API")
 	}
 
 	return resp.ApplicationfhirJSON200, nil
 }
 
-func (c *Client) GetOrganisationByID(ctx context.Context, organisationID string) (*http.OrganizationResource, error) {
+func (c *Client) GetOrganisationByID(ctx context.Context, organisationID string) (*fhirHTTP.OrganizationResource, error) {
 	resp, err := c.apiClient.GetSingleOrganizationWithResponse(ctx, organisationID)
 	if err != nil {
-		log.Err(err).Msg("error getting organisation from ODS API")
-		return nil, errors.Wrap(err, "error getting organisation by id")
+		logFailure(ctx, err, "error getting organisation from ODS API")
+		return nil, transportError(err, "error getting organisation by id")
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		err = statusError(resp.StatusCode(), resp.Status(), resp.Body)
+		logFailure(ctx, err, "error getting organisation from ODS API")
+		return nil, err
 	}
 
-	if resp.StatusCode() != 200 {
-		log.Err(errors.New(resp.Status())).Msg("error getting organisation from ODS API")
-		return nil, errors.New(resp.Status())
+	if resp.ApplicationfhirJSON200 == nil {
+		return nil, apperrors.New(apperrors.ErrMapping, "unexpected organisation response from ODS API")
 	}
 
 	return resp.ApplicationfhirJSON200, nil
 }
+
+// logFailure logs a failed call to the ODS API. Requests the ODS API rejects, such as for an unknown ODS code, are
+// the consumer's doing and logged at Debug level; other failures are errors, typed so that their reports are grouped.
+func logFailure(ctx context.Context, err error, msg string) {
+	if errors.Is(err, apperrors.ErrNotFound) || errors.Is(err, apperrors.ErrInvalidInput) {
+		elog.Ctx(ctx).Debug().Err(err).Msg(msg)
+		return
+	}
+	elog.Ctx(ctx).Err(err).Str(elog.ErrorTypeField, elog.ErrorType(err)).Msg(msg)
+}
+
+// lastUpdatedFilter maps "updated on or after a date" onto the ODS API, which only supports the gt prefix,
+// by asking for updates after the previous day.
+func lastUpdatedFilter(from *time.Time) *string {
+	if from == nil {
+		return nil
+	}
+	return utils.Ref("gt" + from.AddDate(0, 0, -1).Format(time.DateOnly))
+}
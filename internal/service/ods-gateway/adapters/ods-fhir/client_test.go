@@ -0,0 +1,221 @@
+package odsfhir_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// helper to create an adapter talking to a stub ODS API.
+func newClientWithStub(t *testing.T, handler http.HandlerFunc) *odsfhir.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	return odsfhir.NewClient(apiClient)
+}
+
+func TestGetOrganisationByID_Success(t *testing.T) {
+	t.Parallel()
+
+	client := newClientWithStub(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/Organization/RR8", r.URL.Path)
+		w.Header().Set("Content-Type", "application/fhir+json")
+		_, _ = w.Write([]byte(`{"resourceType":"Organization","id":"RR8","name":"LEEDS TEACHING HOSPITALS NHS TRUST"}`))
+	})
+
+	org, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+	require.NotNil(t, org)
+	assert.Equal(t, "RR8", org.Id)
+}
+
+func TestGetOrganisationByID_UpstreamStatusClassified(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status int
+		kind   error
+		// errorType is logged with failures of the ODS API, reported as errors, and not with rejected requests.
+		errorType string
+	}{
+		{name: "not found", status: http.StatusNotFound, kind: apperrors.ErrNotFound},
+		{name: "bad request", status: http.StatusBadRequest, kind: apperrors.ErrInvalidInput},
+		{
+			name: "gateway timeout", status: http.StatusGatewayTimeout, kind: apperrors.ErrUpstreamTimeout,
+			errorType: "upstream timeout: *odsfhir.UpstreamError",
+		},
+		{
+			name: "server error", status: http.StatusInternalServerError, kind: apperrors.ErrUpstreamUnavailable,
+			errorType: "upstream unavailable: *odsfhir.UpstreamError",
+		},
+		{
+			name: "service unavailable", status: http.StatusServiceUnavailable, kind: apperrors.ErrUpstreamUnavailable,
+			errorType: "upstream unavailable: *odsfhir.UpstreamError",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newClientWithStub(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			var logged bytes.Buffer
+			ctx := zerolog.New(&logged).WithContext(context.Background())
+
+			_, err := client.GetOrganisationByID(ctx, "UNKNOWN")
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.kind)
+
+			appErr, ok := apperrors.From(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.status, appErr.Details["upstreamStatus"])
+
+			if tt.errorType == "" {
+				assert.NotContains(t, logged.String(), `"level":"error"`)
+				return
+			}
+			assert.Contains(t, logged.String(), `"level":"error"`)
+			assert.Contains(t, logged.String(), `"errorType":"`+tt.errorType+`"`)
+		})
+	}
+}
+
+func TestGetOrganisationByID_TransportError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	_, err = odsfhir.NewClient(apiClient).GetOrganisationByID(context.Background(), "RR8")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+}
+
+func TestGetOrganisationByID_DeadlineExceeded(t *testing.T) {
+	t.Parallel()
+
+	client := newClientWithStub(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	_, err := client.GetOrganisationByID(ctx, "RR8")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrUpstreamTimeout)
+}
+
+func TestSearchOrganisations_LastUpdatedFrom(t *testing.T) {
+	t.Parallel()
+
+	client := newClientWithStub(t, func(w http.ResponseWriter, r *http.Request) {
+		// the ODS API only supports gt, so "on or after 1 January" is sent as "after 31 December".
+		assert.Equal(t, "gt2024-12-31", r.URL.Query().Get("_lastUpdated"))
+		w.Header().Set("Content-Type", "application/fhir+json")
+		_, _ = w.Write([]byte(`{"resourceType":"Bundle","total":"0"}`))
+	})
+
+	_, err := client.SearchOrganisations(context.Background(), common.SeachOrganisationsRequest{
+		LastUpdatedFrom: utils.Ref(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		PageSize:        10,
+		Page:            1,
+	})
+	require.NoError(t, err)
+}
+
+func TestSearchOrganisations_UpstreamError(t *testing.T) {
+	t.Parallel()
+
+	client := newClientWithStub(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.SearchOrganisations(context.Background(), common.SeachOrganisationsRequest{PageSize: 10, Page: 1})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+}
+
+func TestSearchOrganisations_OperationOutcomeIssuesInDetails(t *testing.T) {
+	t.Parallel()
+
+	client := newClientWithStub(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/fhir+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{
+			"resourceType": "OperationOutcome",
+			"issue": [{
+				"severity": "error",
+				"code": "invalid",
+				"details": {"coding": {"code": "INVALID_VALUE", "display": "An input field has an invalid value for its type"}},
+				"diagnostics": "name:contains must be at least 3 characters"
+			}]
+		}`))
+	})
+
+	_, err := client.SearchOrganisations(context.Background(), common.SeachOrganisationsRequest{Name: utils.Ref("Ab")})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	var upstreamErr *odsfhir.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	require.NotNil(t, upstreamErr.Outcome)
+	assert.Equal(t, []string{"INVALID_VALUE"}, upstreamErr.Outcome.IssueCodes())
+
+	appErr, ok := apperrors.From(err)
+	require.True(t, ok)
+	assert.Equal(t, []map[string]interface{}{{
+		"severity":    "error",
+		"code":        "invalid",
+		"detailsCode": "INVALID_VALUE",
+		"display":     "An input field has an invalid value for its type",
+		"diagnostics": "name:contains must be at least 3 characters",
+	}}, appErr.Details["issues"])
+}
+
+func TestGetOrganisationByID_NoRecordFound(t *testing.T) {
+	t.Parallel()
+
+	client := newClientWithStub(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/fhir+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{
+			"resourceType": "OperationOutcome",
+			"issue": [{
+				"severity": "error",
+				"code": "not-found",
+				"details": {"coding": [{"code": "NO_RECORD_FOUND", "display": "No record found"}]}
+			}]
+		}`))
+	})
+
+	_, err := client.GetOrganisationByID(context.Background(), "UNKNOWN")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	assert.ErrorContains(t, err, "NO_RECORD_FOUND")
+}
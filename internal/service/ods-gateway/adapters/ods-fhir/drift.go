@@ -0,0 +1,300 @@
+package odsfhir
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// maxDriftFindings bounds the memory held by a DriftDetector when the upstream changes a lot at once.
+const maxDriftFindings = 500
+
+// DriftConfig tunes a DriftDetector.
+type DriftConfig struct {
+	// SampleRate is the fraction of successful responses inspected, between 0 (none) and 1 (all).
+	SampleRate float64
+	// KnownExtensionURLs are the extension URLs the gateway understands; any other URL is reported.
+	KnownExtensionURLs []string
+}
+
+type driftKey struct {
+	kind, operation, path, url string
+}
+
+// DriftDetector checks a sample of real ODS API response bodies against the vendored ODS API spec. It records
+// fields the spec does not describe, required fields which are missing and unexpected extension URLs, and logs
+// each distinct finding the first time it is seen.
+type DriftDetector struct {
+	cfg       DriftConfig
+	schemas   map[string]*openapi3.Schema
+	extension *openapi3.Schema
+	known     map[string]struct{}
+	now       func() time.Time
+
+	mu       sync.Mutex
+	sampled  int64
+	dropped  int64
+	findings map[driftKey]*common.UpstreamDriftFinding
+}
+
+var _ common.UpstreamDriftReporter = (*DriftDetector)(nil)
+
+// NewDriftDetector builds a DriftDetector for the organisation operations described by spec.
+func NewDriftDetector(spec *openapi3.T, cfg DriftConfig) (*DriftDetector, error) {
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		return nil, fmt.Errorf("drift sample rate must be between 0 and 1, got %v", cfg.SampleRate)
+	}
+
+	d := &DriftDetector{
+		cfg:      cfg,
+		schemas:  make(map[string]*openapi3.Schema),
+		known:    make(map[string]struct{}, len(cfg.KnownExtensionURLs)),
+		now:      time.Now,
+		findings: make(map[driftKey]*common.UpstreamDriftFinding),
+	}
+
+	for operation, path := range map[string]string{
+		operationGetSingleOrganization:    "/Organization/{id}",
+		operationGetOrganizationResources: "/Organization",
+	} {
+		schema, err := successSchema(spec, path)
+		if err != nil {
+			return nil, err
+		}
+		d.schemas[operation] = schema
+	}
+
+	if ref, ok := spec.Components.Schemas["Extension"]; ok && ref.Value != nil {
+		d.extension = ref.Value
+	}
+	for _, url := range cfg.KnownExtensionURLs {
+		d.known[url] = struct{}{}
+	}
+
+	return d, nil
+}
+
+// successSchema finds the schema of the JSON body of the 200 response to GET path in spec.
+func successSchema(spec *openapi3.T, path string) (*openapi3.Schema, error) {
+	item := spec.Paths.Find(path)
+	if item == nil || item.Get == nil {
+		return nil, fmt.Errorf("GET %s not found in the ODS API spec", path)
+	}
+
+	resp := item.Get.Responses.Status(http.StatusOK)
+	if resp == nil || resp.Value == nil {
+		return nil, fmt.Errorf("GET %s has no 200 response", path)
+	}
+	for _, media := range resp.Value.Content {
+		if media.Schema != nil && media.Schema.Value != nil {
+			return media.Schema.Value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("GET %s has no response schema", path)
+}
+
+// Inspect checks body, the response to operation, if it is sampled.
+func (d *DriftDetector) Inspect(operation string, body []byte) {
+	schema, ok := d.schemas[operation]
+	if !ok || d.cfg.SampleRate <= 0 {
+		return
+	}
+	if d.cfg.SampleRate < 1 && rand.Float64() >= d.cfg.SampleRate { //nolint:gosec // sampling needs no cryptographic randomness
+		return
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		log.Debug().Err(err).Str("operation", operation).Msg("skipping drift check of a non-JSON ODS API response")
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sampled++
+	d.walk(operation, "", schema, value)
+}
+
+func (d *DriftDetector) walk(operation, path string, schema *openapi3.Schema, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				d.record(driftKey{kind: common.DriftMissingRequired, operation: operation, path: join(path, name)})
+			}
+		}
+
+		if schema == d.extension {
+			if url, ok := v["url"].(string); ok {
+				if _, known := d.known[url]; !known {
+					d.record(driftKey{kind: common.DriftUnexpectedExtension, operation: operation, path: path, url: url})
+				}
+			}
+		}
+
+		for name, field := range v {
+			prop, ok := schema.Properties[name]
+			if !ok || prop.Value == nil {
+				// reported even where additionalProperties is allowed: the generated types drop those fields too.
+				d.record(driftKey{kind: common.DriftUnknownField, operation: operation, path: join(path, name)})
+				continue
+			}
+			d.walk(operation, join(path, name), prop.Value, field)
+		}
+
+	case []any:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return
+		}
+		for _, item := range v {
+			d.walk(operation, path+"[]", schema.Items.Value, item)
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// record counts one occurrence of a finding. It must be called with d.mu held.
+func (d *DriftDetector) record(key driftKey) {
+	now := d.now()
+
+	if finding, ok := d.findings[key]; ok {
+		finding.Count++
+		finding.LastSeen = now
+		return
+	}
+
+	if len(d.findings) >= maxDriftFindings {
+		d.dropped++
+		return
+	}
+
+	d.findings[key] = &common.UpstreamDriftFinding{
+		Kind:         key.kind,
+		Operation:    key.operation,
+		Path:         key.path,
+		ExtensionURL: key.url,
+		Count:        1,
+		FirstSeen:    now,
+		LastSeen:     now,
+	}
+
+	log.Warn().
+		Str("kind", key.kind).
+		Str("operation", key.operation).
+		Str("path", key.path).
+		Str("extensionUrl", key.url).
+		Msg("ODS API response drifted from the vendored spec")
+}
+
+// UpstreamDriftReport returns every finding recorded so far, ordered by kind, operation, path and extension URL.
+func (d *DriftDetector) UpstreamDriftReport() common.UpstreamDriftReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	findings := make([]common.UpstreamDriftFinding, 0, len(d.findings))
+	for _, finding := range d.findings {
+		findings = append(findings, *finding)
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Operation != b.Operation {
+			return a.Operation < b.Operation
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.ExtensionURL < b.ExtensionURL
+	})
+
+	return common.UpstreamDriftReport{
+		SampleRate: d.cfg.SampleRate,
+		Sampled:    d.sampled,
+		Dropped:    d.dropped,
+		Findings:   findings,
+	}
+}
+
+// DriftDetectingAPIClient decorates the generated ODS API client, passing the body of every successful
+// organisation read and search to a DriftDetector.
+type DriftDetectingAPIClient struct {
+	next     fhirHTTP.ClientWithResponsesInterface
+	detector *DriftDetector
+}
+
+var _ fhirHTTP.ClientWithResponsesInterface = (*DriftDetectingAPIClient)(nil)
+
+func NewDriftDetectingAPIClient(next fhirHTTP.ClientWithResponsesInterface, detector *DriftDetector) *DriftDetectingAPIClient {
+	return &DriftDetectingAPIClient{
+		next:     next,
+		detector: detector,
+	}
+}
+
+func (c *DriftDetectingAPIClient) GetSingleOrganizationWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+	resp, err := c.next.GetSingleOrganizationWithResponse(ctx, id, reqEditors...)
+	if err == nil && resp.StatusCode() == http.StatusOK {
+		c.detector.Inspect(operationGetSingleOrganization, resp.Body)
+	}
+	return resp, err
+}
+
+func (c *DriftDetectingAPIClient) GetOrganizationResourcesWithResponse(
+	ctx context.Context,
+	params *fhirHTTP.GetOrganizationResourcesParams,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+	resp, err := c.next.GetOrganizationResourcesWithResponse(ctx, params, reqEditors...)
+	if err == nil && resp.StatusCode() == http.StatusOK {
+		c.detector.Inspect(operationGetOrganizationResources, resp.Body)
+	}
+	return resp, err
+}
+
+func (c *DriftDetectingAPIClient) GetCodesystemIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCodesystemIdResponse, error) {
+	return c.next.GetCodesystemIdWithResponse(ctx, id, reqEditors...)
+}
+
+func (c *DriftDetectingAPIClient) GetValuesetSpecifiedIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+	return c.next.GetValuesetSpecifiedIdWithResponse(ctx, id, reqEditors...)
+}
+
+func (c *DriftDetectingAPIClient) GetCapabilityStatementWithResponse(
+	ctx context.Context,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+	return c.next.GetCapabilityStatementWithResponse(ctx, reqEditors...)
+}
@@ -0,0 +1,173 @@
+package odsfhir_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+const driftedOrganisation = `{
+	"resourceType": "Organization",
+	"id": "RR8",
+	"telecom": [{"system": "phone", "value": "0113 243 2799"}],
+	"extension": [
+		{
+			"url": "https://fhir.nhs.uk/STU3/StructureDefinition/Extension-ODSAPI-OrganizationRole-1",
+			"extension": [
+				{"url": "role", "valueCoding": {"code": "RO197", "userSelected": false}},
+				{"url": "lifecycle", "valueString": "retired"}
+			]
+		},
+		{"url": "https://fhir.nhs.uk/STU3/StructureDefinition/Extension-ODSAPI-NewThing-1", "valueString": "x"}
+	]
+}`
+
+// helper to create a drift detecting client talking to a stub ODS API which answers every call with body.
+func newDriftClientWithStub(t *testing.T, sampleRate float64, body string) (*odsfhir.DriftDetectingAPIClient, *odsfhir.DriftDetector) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/fhir+json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	spec, err := fhirHTTP.GetSwagger()
+	require.NoError(t, err)
+
+	detector, err := odsfhir.NewDriftDetector(spec, odsfhir.DriftConfig{
+		SampleRate:         sampleRate,
+		KnownExtensionURLs: queries.ExtensionURLs(),
+	})
+	require.NoError(t, err)
+
+	return odsfhir.NewDriftDetectingAPIClient(apiClient, detector), detector
+}
+
+type finding struct {
+	kind, path, url string
+	count           int64
+}
+
+// helper to reduce a report to the fields which do not depend on time.
+func findingsOf(report common.UpstreamDriftReport) []finding {
+	findings := make([]finding, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		findings = append(findings, finding{kind: f.Kind, path: f.Path, url: f.ExtensionURL, count: f.Count})
+	}
+	return findings
+}
+
+func TestDriftDetector_RecordsDriftOfOrganisation(t *testing.T) {
+	t.Parallel()
+
+	client, detector := newDriftClientWithStub(t, 1, driftedOrganisation)
+
+	for range 2 {
+		resp, err := client.GetSingleOrganizationWithResponse(context.Background(), "RR8")
+		require.NoError(t, err)
+		require.NotNil(t, resp.ApplicationfhirJSON200)
+	}
+
+	report := detector.UpstreamDriftReport()
+	assert.EqualValues(t, 2, report.Sampled)
+	assert.Equal(t, []finding{
+		{kind: common.DriftMissingRequired, path: "name", count: 2},
+		{kind: common.DriftUnexpectedExtension, path: "extension[]", url: "https://fhir.nhs.uk/STU3/StructureDefinition/Extension-ODSAPI-NewThing-1", count: 2},
+		{kind: common.DriftUnexpectedExtension, path: "extension[].extension[]", url: "lifecycle", count: 2},
+		{kind: common.DriftUnknownField, path: "extension[].extension[].valueCoding.userSelected", count: 2},
+		{kind: common.DriftUnknownField, path: "telecom", count: 2},
+	}, findingsOf(report))
+
+	for _, f := range report.Findings {
+		assert.Equal(t, "get-single-organization", f.Operation)
+		assert.False(t, f.FirstSeen.IsZero())
+		assert.False(t, f.LastSeen.Before(f.FirstSeen))
+	}
+}
+
+func TestDriftDetector_RecordsDriftOfSearchBundle(t *testing.T) {
+	t.Parallel()
+
+	client, detector := newDriftClientWithStub(t, 1, `{
+		"resourceType": "Bundle",
+		"type": "searchset",
+		"signature": {},
+		"entry": [
+			{"resource": {"resourceType": "Organization", "id": "RR8", "name": "LEEDS", "partOf": {}}, "search": {}},
+			{"resource": {"resourceType": "Organization", "id": "RAE", "name": "BRADFORD"}}
+		]
+	}`)
+
+	_, err := client.GetOrganizationResourcesWithResponse(context.Background(), &fhirHTTP.GetOrganizationResourcesParams{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []finding{
+		{kind: common.DriftUnknownField, path: "entry[].resource.partOf", count: 1},
+		{kind: common.DriftUnknownField, path: "entry[].search", count: 1},
+		{kind: common.DriftUnknownField, path: "signature", count: 1},
+	}, findingsOf(detector.UpstreamDriftReport()))
+}
+
+func TestDriftDetector_NoDriftForConformingResponse(t *testing.T) {
+	t.Parallel()
+
+	client, detector := newDriftClientWithStub(t, 1, `{
+		"resourceType": "Organization",
+		"id": "RR8",
+		"name": "LEEDS TEACHING HOSPITALS NHS TRUST",
+		"active": true,
+		"identifier": {"system": "https://fhir.nhs.uk/Id/ods-organization-code", "value": "RR8"},
+		"extension": [{
+			"url": "https://fhir.nhs.uk/STU3/StructureDefinition/Extension-ODSAPI-ActivePeriod-1",
+			"valuePeriod": {
+				"start": "1998-04-01",
+				"extension": [{"url": "https://fhir.nhs.uk/STU3/StructureDefinition/Extension-ODSAPI-DateType-1", "valueString": "Operational"}]
+			}
+		}]
+	}`)
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "RR8")
+	require.NoError(t, err)
+
+	report := detector.UpstreamDriftReport()
+	assert.EqualValues(t, 1, report.Sampled)
+	assert.Empty(t, report.Findings)
+}
+
+func TestDriftDetector_SampleRateZeroInspectsNothing(t *testing.T) {
+	t.Parallel()
+
+	client, detector := newDriftClientWithStub(t, 0, driftedOrganisation)
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "RR8")
+	require.NoError(t, err)
+
+	report := detector.UpstreamDriftReport()
+	assert.Zero(t, report.Sampled)
+	assert.Empty(t, report.Findings)
+}
+
+func TestNewDriftDetector_RejectsInvalidSampleRate(t *testing.T) {
+	t.Parallel()
+
+	spec, err := fhirHTTP.GetSwagger()
+	require.NoError(t, err)
+
+	for _, rate := range []float64{-0.1, 1.5} {
+		_, err := odsfhir.NewDriftDetector(spec, odsfhir.DriftConfig{SampleRate: rate})
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,129 @@
+package odsfhir
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+const (
+	issueCodeNotFound        = "not-found"
+	issueCodeInvalid         = "invalid"
+	issueCodeCodeInvalid     = "code-invalid"
+	detailsCodeNoRecord      = "NO_RECORD_FOUND"
+	detailsCodeInvalidPrefix = "INVALID_"
+)
+
+// UpstreamError is the cause of every error produced for an unsuccessful ODS API response.
+// Outcome is nil when the body of the response was not an OperationOutcome.
+type UpstreamError struct {
+	StatusCode int
+	Status     string
+	Outcome    *fhirHTTP.OperationOutcome
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Outcome == nil || len(e.Outcome.Issue) == 0 {
+		return fmt.Sprintf("ODS API responded with %s", e.Status)
+	}
+	return fmt.Sprintf("ODS API responded with %s (%s)", e.Status, strings.Join(e.Outcome.IssueCodes(), ", "))
+}
+
+// transportError classifies errors raised before any upstream response was received.
+// Errors already classified, such as a rejection by the GovernedAPIClient, are passed through.
+func transportError(err error, message string) error {
+	if _, ok := apperrors.From(err); ok {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return apperrors.Wrap(apperrors.ErrUpstreamTimeout, err, message)
+	}
+	return apperrors.Wrap(apperrors.ErrUpstreamUnavailable, err, message)
+}
+
+// statusError classifies unsuccessful upstream responses, preferring the issues of the OperationOutcome over the status code.
+func statusError(statusCode int, status string, body []byte) error {
+	upstreamErr := &UpstreamError{
+		StatusCode: statusCode,
+		Status:     status,
+	}
+	if outcome, ok := fhirHTTP.ParseOperationOutcome(body); ok {
+		upstreamErr.Outcome = outcome
+	}
+
+	var appErr *apperrors.Error
+	switch kind := classify(upstreamErr); kind {
+	case apperrors.ErrNotFound:
+		appErr = apperrors.Wrap(kind, upstreamErr, "organisation not found in ODS API")
+	case apperrors.ErrInvalidInput:
+		appErr = apperrors.Wrap(kind, upstreamErr, "request rejected by ODS API")
+	case apperrors.ErrUpstreamTimeout:
+		appErr = apperrors.Wrap(kind, upstreamErr, "ODS API timed out")
+	default:
+		appErr = apperrors.Wrap(kind, upstreamErr, "ODS API responded with "+status)
+	}
+
+	appErr.WithDetail("upstreamStatus", statusCode)
+	if upstreamErr.Outcome != nil && len(upstreamErr.Outcome.Issue) > 0 {
+		appErr.WithDetail("issues", issueDetails(upstreamErr.Outcome.Issue))
+	}
+
+	return appErr
+}
+
+func classify(err *UpstreamError) error {
+	if err.Outcome != nil {
+		for _, issue := range err.Outcome.Issue {
+			detailsCode := issue.DetailsCode()
+			switch {
+			case detailsCode == detailsCodeNoRecord || issue.Code == issueCodeNotFound:
+				return apperrors.ErrNotFound
+			case strings.HasPrefix(detailsCode, detailsCodeInvalidPrefix),
+				issue.Code == issueCodeInvalid,
+				issue.Code == issueCodeCodeInvalid:
+				return apperrors.ErrInvalidInput
+			}
+		}
+	}
+
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return apperrors.ErrNotFound
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return apperrors.ErrInvalidInput
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return apperrors.ErrUpstreamTimeout
+	default:
+		return apperrors.ErrUpstreamUnavailable
+	}
+}
+
+// issueDetails projects OperationOutcome issues onto the details of the gateway Error schema.
+func issueDetails(issues []fhirHTTP.OperationOutcomeIssue) []map[string]interface{} {
+	details := make([]map[string]interface{}, 0, len(issues))
+	for _, issue := range issues {
+		d := map[string]interface{}{
+			"severity": issue.Severity,
+			"code":     issue.Code,
+		}
+		if code := issue.DetailsCode(); code != "" {
+			d["detailsCode"] = code
+		}
+		if display := issue.DetailsDisplay(); display != "" {
+			d["display"] = display
+		}
+		if issue.Diagnostics != nil {
+			d["diagnostics"] = *issue.Diagnostics
+		}
+		details = append(details, d)
+	}
+	return details
+}
@@ -0,0 +1,451 @@
+package odsfhir
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// errClosed fails the calls made through a GovernedAPIClient once it is closed.
+var errClosed = apperrors.New(apperrors.ErrUpstreamUnavailable, "the ODS API client is closed")
+
+// GovernorConfig bounds the traffic sent to the ODS API by a GovernedAPIClient.
+type GovernorConfig struct {
+	// RateLimit is the sustained number of requests per second, Burst how many may be sent back to back.
+	// A RateLimit of zero or less disables the limit.
+	RateLimit float64
+	Burst     int
+	// MaxQueue is how many calls may wait for the rate limit before further calls are rejected.
+	// A MaxQueue of zero or less leaves the queue unbounded.
+	MaxQueue int
+}
+
+// GovernedAPIClient decorates the generated ODS API client with a token-bucket rate limit shared by all callers.
+// Calls waiting for a token are queued by the priority carried in their context, identical concurrent
+// organisation reads and searches are coalesced into a single upstream call, and calls arriving while the
+// queue is full are rejected with an apperrors.ErrOverloaded error.
+type GovernedAPIClient struct {
+	next    fhirHTTP.ClientWithResponsesInterface
+	limiter *rate.Limiter
+	group   singleflight.Group
+
+	maxQueue int
+	mu       sync.Mutex
+	queue    ticketQueue
+	seq      uint64
+	// flights are the coalesced calls by key, while they have callers.
+	flights map[string]*flight
+	wake    chan struct{}
+
+	// stopped is done once the client is closed, by stop.
+	stopped context.Context
+	stop    context.CancelFunc
+}
+
+// flight is a coalesced call, queued at the highest priority of its callers and given until the latest of their
+// deadlines.
+type flight struct {
+	priority common.Priority
+	callers  int
+	// ticket is the place of the call in the queue, once it is queued.
+	ticket *ticket
+
+	// deadline is the latest deadline of the callers, when every one of them has one, at which timer closes done.
+	deadline time.Time
+	bounded  bool
+	timer    *time.Timer
+	done     chan struct{}
+	// origin is the span of the caller whose context the call carries.
+	origin trace.SpanContext
+}
+
+var _ fhirHTTP.ClientWithResponsesInterface = (*GovernedAPIClient)(nil)
+
+// NewGovernedAPIClient starts the dispatcher handing out tokens to queued calls, which runs until Close.
+func NewGovernedAPIClient(next fhirHTTP.ClientWithResponsesInterface, cfg GovernorConfig) *GovernedAPIClient {
+	limit := rate.Inf
+	if cfg.RateLimit > 0 {
+		limit = rate.Limit(cfg.RateLimit)
+	}
+
+	c := &GovernedAPIClient{
+		next:     next,
+		limiter:  rate.NewLimiter(limit, max(cfg.Burst, 1)),
+		maxQueue: cfg.MaxQueue,
+		flights:  make(map[string]*flight),
+		wake:     make(chan struct{}, 1),
+	}
+	c.stopped, c.stop = context.WithCancel(context.Background())
+	go c.dispatch()
+
+	return c
+}
+
+// Close stops the dispatcher. Calls waiting for the rate limit, and calls made from then on, fail.
+func (c *GovernedAPIClient) Close() {
+	c.stop()
+}
+
+// Queued returns the number of calls waiting for the rate limit.
+func (c *GovernedAPIClient) Queued() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.queue.Len()
+}
+
+func (c *GovernedAPIClient) GetSingleOrganizationWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+	return coalesce(ctx, c, "organisation:"+id, reqEditors, func(ctx context.Context) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+		return c.next.GetSingleOrganizationWithResponse(ctx, id, reqEditors...)
+	})
+}
+
+func (c *GovernedAPIClient) GetOrganizationResourcesWithResponse(
+	ctx context.Context,
+	params *fhirHTTP.GetOrganizationResourcesParams,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+	key, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return coalesce(ctx, c, "search:"+string(key), reqEditors, func(ctx context.Context) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+		return c.next.GetOrganizationResourcesWithResponse(ctx, params, reqEditors...)
+	})
+}
+
+func (c *GovernedAPIClient) GetCodesystemIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCodesystemIdResponse, error) {
+	if err := c.wait(ctx, nil); err != nil {
+		return nil, err
+	}
+	return c.next.GetCodesystemIdWithResponse(ctx, id, reqEditors...)
+}
+
+func (c *GovernedAPIClient) GetValuesetSpecifiedIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+	if err := c.wait(ctx, nil); err != nil {
+		return nil, err
+	}
+	return c.next.GetValuesetSpecifiedIdWithResponse(ctx, id, reqEditors...)
+}
+
+func (c *GovernedAPIClient) GetCapabilityStatementWithResponse(
+	ctx context.Context,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+	if err := c.wait(ctx, nil); err != nil {
+		return nil, err
+	}
+	return c.next.GetCapabilityStatementWithResponse(ctx, reqEditors...)
+}
+
+// coalesce shares one rate-limited upstream call between all concurrent callers using the same key.
+// The shared call is detached from the cancellation of the caller that started it, so it does not fail
+// the others; each caller still stops waiting when its own context is done. The call waits for the rate
+// limit at the highest priority of its callers, so that an interactive caller joining a background call
+// does not wait behind other background calls, and is given until the latest deadline of its callers.
+//
+// The request sent carries the headers of the caller that started it, so only callers with the same correlation
+// ID share a call, the spans of the others being linked to the one of that caller, and calls given request
+// editors of their own are not shared.
+func coalesce[T any](
+	ctx context.Context,
+	c *GovernedAPIClient,
+	key string,
+	reqEditors []fhirHTTP.RequestEditorFn,
+	call func(ctx context.Context) (T, error),
+) (T, error) {
+	var zero T
+	if len(reqEditors) > 0 {
+		if err := c.wait(ctx, nil); err != nil {
+			return zero, err
+		}
+		return call(ctx)
+	}
+
+	key += "\x00" + tracing.CorrelationIDFromContext(ctx)
+	f := c.join(ctx, key)
+	defer c.leave(key, f)
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		c.mu.Lock()
+		f.origin = trace.SpanContextFromContext(ctx)
+		c.mu.Unlock()
+
+		sharedCtx := &flightContext{Context: context.WithoutCancel(ctx), c: c, f: f}
+		if err := c.wait(sharedCtx, f); err != nil {
+			return nil, err
+		}
+		return call(sharedCtx)
+	})
+
+	select {
+	case res := <-ch:
+		c.linkOrigin(ctx, f)
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		val, _ := res.Val.(T)
+		return val, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// join adds the caller of ctx to the flight of key, raising its priority, and its place in the queue, and pushing
+// back its deadline, if need be.
+func (c *GovernedAPIClient) join(ctx context.Context, key string) *flight {
+	priority := common.PriorityFromContext(ctx)
+	deadline, bounded := ctx.Deadline()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.flights[key]
+	if ok && f.bounded && (!bounded || deadline.After(f.deadline)) {
+		if f.timer.Stop() {
+			f.deadline, f.bounded = deadline, bounded
+			if bounded {
+				f.timer.Reset(time.Until(deadline))
+			}
+		} else {
+			// the flight is expiring: the caller, given longer, starts a call of its own.
+			delete(c.flights, key)
+			c.group.Forget(key)
+			ok = false
+		}
+	}
+	if !ok {
+		f = &flight{priority: priority, deadline: deadline, bounded: bounded, done: make(chan struct{})}
+		if bounded {
+			f.timer = time.AfterFunc(time.Until(deadline), func() { c.expire(key, f) })
+		}
+		c.flights[key] = f
+	}
+
+	f.callers++
+	if priority > f.priority {
+		f.priority = priority
+		if f.ticket != nil && f.ticket.index >= 0 {
+			f.ticket.priority = priority
+			heap.Fix(&c.queue, f.ticket.index)
+		}
+	}
+	return f
+}
+
+// leave removes a caller from the flight f of key, which is forgotten with its last caller: a later caller starts
+// a call of its own rather than joining one nobody waits for.
+func (c *GovernedAPIClient) leave(key string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f.callers--; f.callers == 0 && c.flights[key] == f {
+		delete(c.flights, key)
+		c.group.Forget(key)
+	}
+}
+
+// expire ends the flight f of key at the deadline of its callers.
+func (c *GovernedAPIClient) expire(key string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.flights[key] == f {
+		delete(c.flights, key)
+		c.group.Forget(key)
+	}
+	close(f.done)
+}
+
+// linkOrigin links the span of the caller of ctx to the span of the caller whose context the call of f carried.
+func (c *GovernedAPIClient) linkOrigin(ctx context.Context, f *flight) {
+	c.mu.Lock()
+	origin := f.origin
+	c.mu.Unlock()
+
+	span := trace.SpanFromContext(ctx)
+	if origin.IsValid() && !origin.Equal(span.SpanContext()) {
+		span.AddLink(trace.Link{SpanContext: origin})
+	}
+}
+
+// flightContext is the context of a coalesced call: it carries the values of the caller that started the call, and
+// is done at the deadline of its flight, which later callers may push back.
+type flightContext struct {
+	context.Context
+	c *GovernedAPIClient
+	f *flight
+}
+
+func (ctx *flightContext) Deadline() (time.Time, bool) {
+	ctx.c.mu.Lock()
+	defer ctx.c.mu.Unlock()
+	return ctx.f.deadline, ctx.f.bounded
+}
+
+func (ctx *flightContext) Done() <-chan struct{} {
+	return ctx.f.done
+}
+
+func (ctx *flightContext) Err() error {
+	select {
+	case <-ctx.f.done:
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
+// wait blocks until the dispatcher hands the call a token, ctx is done, or rejects the call when the queue is full.
+// A coalesced call is queued at the priority of its flight f, nil for other calls.
+func (c *GovernedAPIClient) wait(ctx context.Context, f *flight) error {
+	if c.stopped.Err() != nil {
+		return errClosed
+	}
+
+	c.mu.Lock()
+	if c.maxQueue > 0 && c.queue.Len() >= c.maxQueue {
+		queued := c.queue.Len()
+		c.mu.Unlock()
+
+		return apperrors.New(apperrors.ErrOverloaded, "too many requests queued for the ODS API").
+			WithRetryAfter(c.drainTime(queued))
+	}
+
+	c.seq++
+	t := &ticket{
+		priority: common.PriorityFromContext(ctx),
+		seq:      c.seq,
+		ready:    make(chan struct{}),
+	}
+	if f != nil {
+		t.priority, f.ticket = f.priority, t
+	}
+	heap.Push(&c.queue, t)
+	c.mu.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+
+	var err error
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-c.stopped.Done():
+		err = errClosed
+	}
+
+	c.mu.Lock()
+	if t.index >= 0 {
+		heap.Remove(&c.queue, t.index)
+	}
+	c.mu.Unlock()
+
+	return err
+}
+
+// dispatch waits for a token whenever calls are queued and hands it to the highest priority call at that moment,
+// so interactive calls arriving while background calls wait are served first.
+func (c *GovernedAPIClient) dispatch() {
+	for {
+		select {
+		case <-c.wake:
+		case <-c.stopped.Done():
+			return
+		}
+
+		for c.Queued() > 0 {
+			if c.limiter.Wait(c.stopped) != nil {
+				return
+			}
+
+			c.mu.Lock()
+			if c.queue.Len() > 0 {
+				t, _ := heap.Pop(&c.queue).(*ticket)
+				close(t.ready)
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// drainTime estimates how long it takes the rate limit to work through queued calls, in whole seconds.
+func (c *GovernedAPIClient) drainTime(queued int) time.Duration {
+	limit := c.limiter.Limit()
+	if limit == rate.Inf {
+		return time.Second
+	}
+
+	seconds := math.Ceil(float64(queued+1) / float64(limit))
+	return max(time.Duration(seconds)*time.Second, time.Second)
+}
+
+type ticket struct {
+	priority common.Priority
+	seq      uint64
+	index    int
+	ready    chan struct{}
+}
+
+// ticketQueue is a heap of tickets ordered by priority, then arrival.
+type ticketQueue []*ticket
+
+func (q ticketQueue) Len() int { return len(q) }
+
+func (q ticketQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q ticketQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *ticketQueue) Push(x any) {
+	t, _ := x.(*ticket)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *ticketQueue) Pop() any {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	t.index = -1
+	*q = old[:n-1]
+	return t
+}
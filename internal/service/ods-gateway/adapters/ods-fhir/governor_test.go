@@ -0,0 +1,324 @@
+package odsfhir_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// helper to create a governed client talking to a stub ODS API.
+func newGovernedClientWithStub(t *testing.T, cfg odsfhir.GovernorConfig, handler http.HandlerFunc) *odsfhir.GovernedAPIClient {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	client := odsfhir.NewGovernedAPIClient(apiClient, cfg)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func writeOrganisation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/fhir+json")
+	_, _ = w.Write([]byte(`{"resourceType":"Organization","id":"` + r.URL.Path[len("/Organization/"):] + `","name":"ORG"}`))
+}
+
+func TestGovernedAPIClient_CoalescesIdenticalLookups(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 100, Burst: 10, MaxQueue: 10},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			<-release
+			writeOrganisation(w, r)
+		})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan *fhirHTTP.GetSingleOrganizationResponse, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.GetSingleOrganizationWithResponse(context.Background(), "RR8")
+			assert.NoError(t, err)
+			results <- resp
+		}()
+	}
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	assert.EqualValues(t, 1, calls.Load())
+	for resp := range results {
+		require.NotNil(t, resp)
+		require.NotNil(t, resp.ApplicationfhirJSON200)
+		assert.Equal(t, "RR8", resp.ApplicationfhirJSON200.Id)
+	}
+}
+
+func TestGovernedAPIClient_DoesNotCoalesceDifferentLookups(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 100, Burst: 10, MaxQueue: 10},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			writeOrganisation(w, r)
+		})
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "RR8")
+	require.NoError(t, err)
+	_, err = client.GetSingleOrganizationWithResponse(context.Background(), "RYJ")
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestGovernedAPIClient_RejectsWhenQueueFull(t *testing.T) {
+	t.Parallel()
+
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 0.1, Burst: 1, MaxQueue: 1}, writeOrganisation)
+
+	// the first call takes the only token, the second waits for the next one.
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "A")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _, _ = client.GetSingleOrganizationWithResponse(ctx, "B") }()
+	require.Eventually(t, func() bool { return client.Queued() == 1 }, time.Second, 5*time.Millisecond)
+
+	_, err = client.GetSingleOrganizationWithResponse(context.Background(), "C")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrOverloaded)
+
+	appErr, ok := apperrors.From(err)
+	require.True(t, ok)
+	assert.Equal(t, 20*time.Second, appErr.RetryAfter)
+}
+
+func TestGovernedAPIClient_CancelledCallLeavesQueue(t *testing.T) {
+	t.Parallel()
+
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 0.1, Burst: 1, MaxQueue: 1}, writeOrganisation)
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "A")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.GetSingleOrganizationWithResponse(ctx, "B")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Eventually(t, func() bool { return client.Queued() == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestGovernedAPIClient_InteractiveCallsJumpTheQueue(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 4, Burst: 1, MaxQueue: 10},
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			order = append(order, r.URL.Path)
+			mu.Unlock()
+			writeOrganisation(w, r)
+		})
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "FIRST")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	call := func(ctx context.Context, id string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetSingleOrganizationWithResponse(ctx, id)
+			assert.NoError(t, err)
+		}()
+	}
+
+	call(common.WithPriority(context.Background(), common.PriorityBackground), "BULK")
+	require.Eventually(t, func() bool { return client.Queued() == 1 }, time.Second, time.Millisecond)
+	call(context.Background(), "INTERACTIVE")
+	wg.Wait()
+
+	assert.Equal(t, []string{"/Organization/FIRST", "/Organization/INTERACTIVE", "/Organization/BULK"}, order)
+}
+
+func TestGovernedAPIClient_CoalescedCallsTakeTheHighestPriority(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 4, Burst: 1, MaxQueue: 10},
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			order = append(order, r.URL.Path)
+			mu.Unlock()
+			writeOrganisation(w, r)
+		})
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "FIRST")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	call := func(ctx context.Context, id string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetSingleOrganizationWithResponse(ctx, id)
+			assert.NoError(t, err)
+		}()
+	}
+
+	background := common.WithPriority(context.Background(), common.PriorityBackground)
+	call(background, "BULK-1")
+	require.Eventually(t, func() bool { return client.Queued() == 1 }, time.Second, time.Millisecond)
+	call(background, "BULK-2")
+	require.Eventually(t, func() bool { return client.Queued() == 2 }, time.Second, time.Millisecond)
+	// a consumer waiting on the organisation of the later background call joins it.
+	call(context.Background(), "BULK-2")
+	wg.Wait()
+
+	assert.Equal(t, []string{"/Organization/FIRST", "/Organization/BULK-2", "/Organization/BULK-1"}, order)
+}
+
+func TestGovernedAPIClient_CoalescedCallsTakeTheLatestDeadline(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 100, Burst: 10, MaxQueue: 10},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			<-release
+			writeOrganisation(w, r)
+		})
+
+	short, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	t.Cleanup(cancel)
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.GetSingleOrganizationWithResponse(short, "RR8")
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	long, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.GetSingleOrganizationWithResponse(long, "RR8")
+		second <- err
+	}()
+
+	// the call outlives the deadline of the caller that started it, for the caller given longer.
+	require.ErrorIs(t, <-first, context.DeadlineExceeded)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-second)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestGovernedAPIClient_DoesNotCoalesceCallsSendingOtherHeaders(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, r.Header.Get(tracing.CorrelationIDHeader)+"/"+r.Header.Get("X-Test"))
+		mu.Unlock()
+		<-release
+		writeOrganisation(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL, fhirHTTP.WithRequestEditorFn(odsfhir.PropagateTraceContext))
+	require.NoError(t, err)
+	client := odsfhir.NewGovernedAPIClient(apiClient, odsfhir.GovernorConfig{RateLimit: 100, Burst: 10, MaxQueue: 10})
+	t.Cleanup(client.Close)
+
+	var wg sync.WaitGroup
+	call := func(correlationID string, reqEditors ...fhirHTTP.RequestEditorFn) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := tracing.WithCorrelationID(context.Background(), correlationID)
+			_, err := client.GetSingleOrganizationWithResponse(ctx, "RR8", reqEditors...)
+			assert.NoError(t, err)
+		}()
+	}
+	call("a")
+	call("a")
+	call("b")
+	call("a", func(_ context.Context, req *http.Request) error {
+		req.Header.Set("X-Test", "editor")
+		return nil
+	})
+
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent)
+	}
+	require.Eventually(t, func() bool { return count() == 3 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.ElementsMatch(t, []string{"a/", "b/", "a/editor"}, sent)
+}
+
+func TestGovernedAPIClient_FailsCallsOnceClosed(t *testing.T) {
+	t.Parallel()
+
+	client := newGovernedClientWithStub(t, odsfhir.GovernorConfig{RateLimit: 0.1, Burst: 1, MaxQueue: 10}, writeOrganisation)
+
+	_, err := client.GetSingleOrganizationWithResponse(context.Background(), "A")
+	require.NoError(t, err)
+
+	queued := make(chan error, 1)
+	go func() {
+		_, err := client.GetSingleOrganizationWithResponse(context.Background(), "B")
+		queued <- err
+	}()
+	require.Eventually(t, func() bool { return client.Queued() == 1 }, time.Second, time.Millisecond)
+
+	client.Close()
+	assert.ErrorIs(t, <-queued, apperrors.ErrUpstreamUnavailable)
+	assert.Equal(t, 0, client.Queued())
+
+	_, err = client.GetSingleOrganizationWithResponse(context.Background(), "C")
+	assert.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+}
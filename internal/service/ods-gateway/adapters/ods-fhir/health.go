@@ -0,0 +1,93 @@
+package odsfhir
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// NewMetadataCheck checks that the ODS API serves its capability statement from /metadata. The call is made at
+// background priority, so that it never delays requests waiting for the upstream rate limit.
+func NewMetadataCheck(client fhirHTTP.ClientWithResponsesInterface) health.CheckFunc {
+	return func(ctx context.Context) error {
+		resp, err := client.GetCapabilityStatementWithResponse(common.WithPriority(ctx, common.PriorityBackground))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("ODS API metadata returned %s", resp.Status())
+		}
+		return nil
+	}
+}
+
+// NewReachabilityCheck checks that the host of serverURL resolves and completes a TLS handshake with the TLS settings
+// of cfg, which catches DNS, firewall and certificate problems apart from the ODS API itself. When the ODS API is
+// reached through a proxy, only the proxy is resolved and dialled, as the gateway may not resolve the upstream host.
+func NewReachabilityCheck(serverURL string, cfg TransportConfig) (health.CheckFunc, error) {
+	upstream, err := url.Parse(serverURL)
+	if err != nil || upstream.Host == "" {
+		return nil, fmt.Errorf("invalid ODS API server URL %q", serverURL)
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ProxyURL != "" {
+		proxy, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		return func(ctx context.Context) error {
+			return dial(ctx, proxy, nil)
+		}, nil
+	}
+
+	if upstream.Scheme != "https" {
+		tlsConfig = nil
+	} else {
+		tlsConfig.ServerName = upstream.Hostname()
+	}
+
+	return func(ctx context.Context) error {
+		return dial(ctx, upstream, tlsConfig)
+	}, nil
+}
+
+// dial resolves the host of u and connects to it, completing a TLS handshake when tlsConfig is set.
+func dial(ctx context.Context, u *url.URL, tlsConfig *tls.Config) error {
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return fmt.Errorf("resolving %s: %w", host, err)
+	}
+
+	var dialer interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	} = &net.Dialer{}
+	if tlsConfig != nil {
+		dialer = &tls.Dialer{Config: tlsConfig}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", host, err)
+	}
+
+	return conn.Close()
+}
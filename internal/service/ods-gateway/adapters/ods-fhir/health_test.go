@@ -0,0 +1,67 @@
+package odsfhir_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+func TestMetadataCheck(t *testing.T) {
+	t.Parallel()
+
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/metadata", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+	check := odsfhir.NewMetadataCheck(client)
+
+	require.NoError(t, check(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, check(context.Background()), "503")
+}
+
+func TestReachabilityCheck_VerifiesTLS(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	untrusted, err := odsfhir.NewReachabilityCheck(srv.URL, odsfhir.TransportConfig{})
+	require.NoError(t, err)
+	assert.ErrorContains(t, untrusted(context.Background()), "certificate")
+
+	caBundle := writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw)
+	trusted, err := odsfhir.NewReachabilityCheck(srv.URL, odsfhir.TransportConfig{CABundleFile: caBundle})
+	require.NoError(t, err)
+	assert.NoError(t, trusted(context.Background()))
+
+	srv.Close()
+	assert.ErrorContains(t, trusted(context.Background()), "connecting to 127.0.0.1")
+}
+
+func TestReachabilityCheck_DialsProxy(t *testing.T) {
+	t.Parallel()
+
+	proxy := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(proxy.Close)
+
+	check, err := odsfhir.NewReachabilityCheck("https://ods.invalid/STU3", odsfhir.TransportConfig{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	assert.NoError(t, check(context.Background()), "the upstream host is only resolved by the proxy")
+
+	_, err = odsfhir.NewReachabilityCheck("not a url", odsfhir.TransportConfig{})
+	assert.Error(t, err)
+}
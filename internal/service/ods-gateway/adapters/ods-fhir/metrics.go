@@ -0,0 +1,103 @@
+package odsfhir
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// operationIds of the ODS API spec.
+const (
+	operationGetSingleOrganization    = "get-single-organization"
+	operationGetOrganizationResources = "get-organization-resources"
+	operationGetCodesystemID          = "get-codesystem-id"
+	operationGetValuesetSpecifiedID   = "get-valueset-specified-id"
+	operationGetCapabilityStatement   = "get-capability-statement"
+)
+
+// MeasuredAPIClient decorates the generated ODS API client, observing the latency and status code of every call
+// by the operationId of the ODS API spec, and adding it to the UpstreamStats of the request. Wrapping the raw client
+// measures each attempt, retries included.
+type MeasuredAPIClient struct {
+	next fhirHTTP.ClientWithResponsesInterface
+}
+
+var _ fhirHTTP.ClientWithResponsesInterface = (*MeasuredAPIClient)(nil)
+
+func NewMeasuredAPIClient(next fhirHTTP.ClientWithResponsesInterface) *MeasuredAPIClient {
+	return &MeasuredAPIClient{next: next}
+}
+
+// statusCoder is implemented by every response of the generated client.
+type statusCoder interface {
+	StatusCode() int
+}
+
+func observe[T statusCoder](ctx context.Context, operation string, start time.Time, resp T, err error) {
+	elapsed := time.Since(start)
+	common.RecordUpstreamCall(ctx, elapsed)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode())
+	}
+	metrics.UpstreamRequestDuration.WithLabelValues(operation, status).Observe(elapsed.Seconds())
+}
+
+func (c *MeasuredAPIClient) GetSingleOrganizationWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+	start := time.Now()
+	resp, err := c.next.GetSingleOrganizationWithResponse(ctx, id, reqEditors...)
+	observe(ctx, operationGetSingleOrganization, start, resp, err)
+	return resp, err
+}
+
+func (c *MeasuredAPIClient) GetOrganizationResourcesWithResponse(
+	ctx context.Context,
+	params *fhirHTTP.GetOrganizationResourcesParams,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+	start := time.Now()
+	resp, err := c.next.GetOrganizationResourcesWithResponse(ctx, params, reqEditors...)
+	observe(ctx, operationGetOrganizationResources, start, resp, err)
+	return resp, err
+}
+
+func (c *MeasuredAPIClient) GetCodesystemIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCodesystemIdResponse, error) {
+	start := time.Now()
+	resp, err := c.next.GetCodesystemIdWithResponse(ctx, id, reqEditors...)
+	observe(ctx, operationGetCodesystemID, start, resp, err)
+	return resp, err
+}
+
+func (c *MeasuredAPIClient) GetValuesetSpecifiedIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+	start := time.Now()
+	resp, err := c.next.GetValuesetSpecifiedIdWithResponse(ctx, id, reqEditors...)
+	observe(ctx, operationGetValuesetSpecifiedID, start, resp, err)
+	return resp, err
+}
+
+func (c *MeasuredAPIClient) GetCapabilityStatementWithResponse(
+	ctx context.Context,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+	start := time.Now()
+	resp, err := c.next.GetCapabilityStatementWithResponse(ctx, reqEditors...)
+	observe(ctx, operationGetCapabilityStatement, start, resp, err)
+	return resp, err
+}
@@ -0,0 +1,61 @@
+package odsfhir_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+func TestMeasuredAPIClient_ObservesCalls(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metadata" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeOrganisation(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+	client := odsfhir.NewMeasuredAPIClient(apiClient)
+
+	ctx, stats := common.WithUpstreamStats(context.Background())
+	_, err = client.GetSingleOrganizationWithResponse(ctx, "RR8")
+	require.NoError(t, err)
+	_, err = client.GetCapabilityStatementWithResponse(ctx)
+	require.NoError(t, err)
+
+	calls, latency := stats.Calls()
+	assert.Equal(t, 2, calls)
+	assert.Positive(t, latency)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	unreachable, err := fhirHTTP.NewClientWithResponses(closed.URL)
+	require.NoError(t, err)
+	_, err = odsfhir.NewMeasuredAPIClient(unreachable).GetValuesetSpecifiedIdWithResponse(context.Background(), "x")
+	require.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	scraped := string(body)
+	assert.Contains(t, scraped, `ods_gateway_upstream_request_duration_seconds_count{operation="get-single-organization",status="200"} 1`)
+	assert.Contains(t, scraped, `ods_gateway_upstream_request_duration_seconds_count{operation="get-capability-statement",status="503"} 1`)
+	assert.Contains(t, scraped, `ods_gateway_upstream_request_duration_seconds_count{operation="get-valueset-specified-id",status="error"} 1`)
+}
@@ -0,0 +1,263 @@
+package odsfhir
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// ResilienceConfig tunes a ResilientAPIClient. Zero values disable the corresponding policy.
+type ResilienceConfig struct {
+	// OrganisationTimeout, SearchTimeout and DefaultTimeout bound every attempt of an organisation read,
+	// an organisation search and any other call respectively.
+	OrganisationTimeout time.Duration
+	SearchTimeout       time.Duration
+	DefaultTimeout      time.Duration
+
+	// MaxRetries is how many times a call failing with 429, 5xx or a transport error is retried.
+	// Retries back off exponentially from RetryBaseDelay with jitter, never waiting longer than RetryMaxDelay.
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// FailureThreshold consecutive failures open the circuit breaker, which then fails calls fast for OpenDuration.
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+// ResilientAPIClient decorates the generated ODS API client with per-operation timeouts, retries and a circuit breaker.
+// All ODS API operations are idempotent GETs, so every call is safe to retry.
+type ResilientAPIClient struct {
+	next    fhirHTTP.ClientWithResponsesInterface
+	cfg     atomic.Pointer[ResilienceConfig]
+	breaker *circuitBreaker
+}
+
+var _ fhirHTTP.ClientWithResponsesInterface = (*ResilientAPIClient)(nil)
+
+func NewResilientAPIClient(next fhirHTTP.ClientWithResponsesInterface, cfg ResilienceConfig) *ResilientAPIClient {
+	c := &ResilientAPIClient{
+		next:    next,
+		breaker: newCircuitBreaker(cfg.FailureThreshold, cfg.OpenDuration),
+	}
+	c.cfg.Store(&cfg)
+
+	return c
+}
+
+// SetTimeouts replaces the per-attempt timeouts of calls started from now on.
+func (c *ResilientAPIClient) SetTimeouts(organisation, search, other time.Duration) {
+	cfg := *c.cfg.Load()
+	cfg.OrganisationTimeout = organisation
+	cfg.SearchTimeout = search
+	cfg.DefaultTimeout = other
+	c.cfg.Store(&cfg)
+}
+
+func (c *ResilientAPIClient) GetSingleOrganizationWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+	return withResilience(ctx, c, c.cfg.Load().OrganisationTimeout,
+		func(ctx context.Context) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+			return c.next.GetSingleOrganizationWithResponse(ctx, id, reqEditors...)
+		},
+		func(resp *fhirHTTP.GetSingleOrganizationResponse) *http.Response { return resp.HTTPResponse },
+	)
+}
+
+func (c *ResilientAPIClient) GetOrganizationResourcesWithResponse(
+	ctx context.Context,
+	params *fhirHTTP.GetOrganizationResourcesParams,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+	return withResilience(ctx, c, c.cfg.Load().SearchTimeout,
+		func(ctx context.Context) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+			return c.next.GetOrganizationResourcesWithResponse(ctx, params, reqEditors...)
+		},
+		func(resp *fhirHTTP.GetOrganizationResourcesResponse) *http.Response { return resp.HTTPResponse },
+	)
+}
+
+func (c *ResilientAPIClient) GetCodesystemIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCodesystemIdResponse, error) {
+	return withResilience(ctx, c, c.cfg.Load().DefaultTimeout,
+		func(ctx context.Context) (*fhirHTTP.GetCodesystemIdResponse, error) {
+			return c.next.GetCodesystemIdWithResponse(ctx, id, reqEditors...)
+		},
+		func(resp *fhirHTTP.GetCodesystemIdResponse) *http.Response { return resp.HTTPResponse },
+	)
+}
+
+func (c *ResilientAPIClient) GetValuesetSpecifiedIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+	return withResilience(ctx, c, c.cfg.Load().DefaultTimeout,
+		func(ctx context.Context) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+			return c.next.GetValuesetSpecifiedIdWithResponse(ctx, id, reqEditors...)
+		},
+		func(resp *fhirHTTP.GetValuesetSpecifiedIdResponse) *http.Response { return resp.HTTPResponse },
+	)
+}
+
+func (c *ResilientAPIClient) GetCapabilityStatementWithResponse(
+	ctx context.Context,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+	return withResilience(ctx, c, c.cfg.Load().DefaultTimeout,
+		func(ctx context.Context) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+			return c.next.GetCapabilityStatementWithResponse(ctx, reqEditors...)
+		},
+		func(resp *fhirHTTP.GetCapabilityStatementResponse) *http.Response { return resp.HTTPResponse },
+	)
+}
+
+// withResilience runs call until it succeeds, fails with an error that is not worth retrying, or runs out of retries.
+// The last response or error is returned as is, so the caller classifies it like any other.
+func withResilience[T any](
+	ctx context.Context,
+	c *ResilientAPIClient,
+	timeout time.Duration,
+	call func(ctx context.Context) (T, error),
+	httpResponse func(T) *http.Response,
+) (T, error) {
+	for attempt := 0; ; attempt++ {
+		if wait, ok := c.breaker.allow(); !ok {
+			var zero T
+			return zero, apperrors.New(apperrors.ErrUpstreamUnavailable, "ODS API circuit breaker is open").WithRetryAfter(wait)
+		}
+
+		resp, err := attemptCall(ctx, timeout, call)
+
+		var raw *http.Response
+		if err == nil {
+			raw = httpResponse(resp)
+		}
+
+		retry, outcome := classifyAttempt(ctx, raw, err)
+		c.breaker.record(outcome)
+		if !retry || attempt >= c.cfg.Load().MaxRetries {
+			return resp, err
+		}
+
+		delay, ok := c.backoff(ctx, attempt, raw)
+		if !ok {
+			return resp, err
+		}
+
+		elog.Ctx(ctx).Warn().Err(err).Int("attempt", attempt+1).Dur("delay", delay).Int("status", statusOf(raw)).
+			Msg("retrying ODS API call")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
+	}
+}
+
+func attemptCall[T any](ctx context.Context, timeout time.Duration, call func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return call(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return call(attemptCtx)
+}
+
+// classifyAttempt reports whether an attempt is worth retrying and what it says about the health of ODS.
+func classifyAttempt(ctx context.Context, resp *http.Response, err error) (bool, attemptOutcome) {
+	if err != nil {
+		// the caller went away, or a decorator further down, such as the governor, already made a decision.
+		if ctx.Err() != nil {
+			return false, outcomeIgnored
+		}
+		if _, ok := apperrors.From(err); ok {
+			return false, outcomeIgnored
+		}
+		return true, outcomeFailure
+	}
+
+	switch status := statusOf(resp); {
+	case status == http.StatusTooManyRequests:
+		return true, outcomeIgnored
+	case status >= http.StatusInternalServerError:
+		return true, outcomeFailure
+	default:
+		return false, outcomeSuccess
+	}
+}
+
+// backoff returns the delay before the next attempt: exponential from RetryBaseDelay with jitter, or the
+// Retry-After of the response when that is longer. It reports false when the wait exceeds RetryMaxDelay
+// or the deadline of ctx.
+func (c *ResilientAPIClient) backoff(ctx context.Context, attempt int, resp *http.Response) (time.Duration, bool) {
+	cfg := c.cfg.Load()
+
+	delay := cfg.RetryBaseDelay << attempt
+	if cfg.RetryMaxDelay > 0 && (delay > cfg.RetryMaxDelay || delay <= 0) {
+		delay = cfg.RetryMaxDelay
+	}
+	if delay > 0 {
+		// equal jitter: half of the delay is fixed, the other half random.
+		delay = delay/2 + rand.N(delay/2+1) //nolint:gosec // jitter does not need a secure source.
+	}
+
+	if retryAfter, ok := parseRetryAfter(resp, time.Now()); ok {
+		if cfg.RetryMaxDelay > 0 && retryAfter > cfg.RetryMaxDelay {
+			return 0, false
+		}
+		delay = max(delay, retryAfter)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,207 @@
+package odsfhir_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// helper to create an adapter talking to a stub ODS API through a ResilientAPIClient.
+func newResilientClientWithStub(t *testing.T, cfg odsfhir.ResilienceConfig, handler http.HandlerFunc) *odsfhir.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	return odsfhir.NewClient(odsfhir.NewResilientAPIClient(apiClient, cfg))
+}
+
+func fastRetries(maxRetries int) odsfhir.ResilienceConfig {
+	return odsfhir.ResilienceConfig{
+		MaxRetries:     maxRetries,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  10 * time.Millisecond,
+	}
+}
+
+func TestResilientAPIClient_RetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	client := newResilientClientWithStub(t, fastRetries(2), func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeOrganisation(w, r)
+	})
+
+	org, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+	assert.Equal(t, "RR8", org.Id)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestResilientAPIClient_GivesUpAfterMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	client := newResilientClientWithStub(t, fastRetries(2), func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestResilientAPIClient_DoesNotRetryRejections(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	client := newResilientClientWithStub(t, fastRetries(2), func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	_, err := client.GetOrganisationByID(context.Background(), "UNKNOWN")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestResilientAPIClient_HonoursRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	cfg := fastRetries(1)
+	cfg.RetryMaxDelay = 2 * time.Second
+	client := newResilientClientWithStub(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeOrganisation(w, r)
+	})
+
+	start := time.Now()
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestResilientAPIClient_DoesNotWaitLongerThanMaxDelay(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	client := newResilientClientWithStub(t, fastRetries(1), func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestResilientAPIClient_TimesOutSlowAttempts(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	cfg := fastRetries(1)
+	cfg.OrganisationTimeout = 20 * time.Millisecond
+	client := newResilientClientWithStub(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.ErrorIs(t, err, apperrors.ErrUpstreamTimeout)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestResilientAPIClient_CircuitBreakerFailsFast(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls   atomic.Int32
+		healthy atomic.Bool
+	)
+	client := newResilientClientWithStub(t, odsfhir.ResilienceConfig{
+		FailureThreshold: 2,
+		OpenDuration:     50 * time.Millisecond,
+	}, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writeOrganisation(w, r)
+	})
+
+	for range 2 {
+		_, err := client.GetOrganisationByID(context.Background(), "RR8")
+		require.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+	}
+
+	_, err := client.GetOrganisationByID(context.Background(), "RR8")
+	require.ErrorIs(t, err, apperrors.ErrUpstreamUnavailable)
+	appErr, ok := apperrors.From(err)
+	require.True(t, ok)
+	assert.Positive(t, appErr.RetryAfter)
+	assert.EqualValues(t, 2, calls.Load())
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+	_, err = client.GetOrganisationByID(context.Background(), "RR8")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, calls.Load())
+}
+
+func TestResilientAPIClient_SetTimeoutsAppliesToNewCalls(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL)
+	require.NoError(t, err)
+
+	cfg := fastRetries(0)
+	cfg.OrganisationTimeout = 5 * time.Second
+	resilient := odsfhir.NewResilientAPIClient(apiClient, cfg)
+	resilient.SetTimeouts(20*time.Millisecond, time.Second, time.Second)
+
+	start := time.Now()
+	_, err = odsfhir.NewClient(resilient).GetOrganisationByID(context.Background(), "RR8")
+	require.ErrorIs(t, err, apperrors.ErrUpstreamTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,115 @@
+package odsfhir
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+// PropagateTraceContext is a RequestEditorFn sending the trace context and correlation ID of ctx to the ODS API.
+// It is meant to be installed on the generated client with WithRequestEditorFn.
+func PropagateTraceContext(ctx context.Context, req *http.Request) error {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if id := tracing.CorrelationIDFromContext(ctx); id != "" {
+		req.Header.Set(tracing.CorrelationIDHeader, id)
+	}
+	return nil
+}
+
+// TracedAPIClient decorates the generated ODS API client with a client span per call, named after the operationId
+// of the ODS API spec. Wrapping the raw client traces each attempt, retries included.
+type TracedAPIClient struct {
+	next fhirHTTP.ClientWithResponsesInterface
+}
+
+var _ fhirHTTP.ClientWithResponsesInterface = (*TracedAPIClient)(nil)
+
+func NewTracedAPIClient(next fhirHTTP.ClientWithResponsesInterface) *TracedAPIClient {
+	return &TracedAPIClient{next: next}
+}
+
+func withSpan[T statusCoder](ctx context.Context, operation string, call func(context.Context) (T, error)) (T, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ODS "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("ods.operation", operation)),
+	)
+	defer span.End()
+
+	resp, err := call(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	status := resp.StatusCode()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusBadRequest && status != http.StatusNotFound {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	return resp, nil
+}
+
+func (c *TracedAPIClient) GetSingleOrganizationWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+	return withSpan(ctx, operationGetSingleOrganization,
+		func(ctx context.Context) (*fhirHTTP.GetSingleOrganizationResponse, error) {
+			return c.next.GetSingleOrganizationWithResponse(ctx, id, reqEditors...)
+		})
+}
+
+func (c *TracedAPIClient) GetOrganizationResourcesWithResponse(
+	ctx context.Context,
+	params *fhirHTTP.GetOrganizationResourcesParams,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+	return withSpan(ctx, operationGetOrganizationResources,
+		func(ctx context.Context) (*fhirHTTP.GetOrganizationResourcesResponse, error) {
+			return c.next.GetOrganizationResourcesWithResponse(ctx, params, reqEditors...)
+		})
+}
+
+func (c *TracedAPIClient) GetCodesystemIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCodesystemIdResponse, error) {
+	return withSpan(ctx, operationGetCodesystemID,
+		func(ctx context.Context) (*fhirHTTP.GetCodesystemIdResponse, error) {
+			return c.next.GetCodesystemIdWithResponse(ctx, id, reqEditors...)
+		})
+}
+
+func (c *TracedAPIClient) GetValuesetSpecifiedIdWithResponse(
+	ctx context.Context,
+	id string,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+	return withSpan(ctx, operationGetValuesetSpecifiedID,
+		func(ctx context.Context) (*fhirHTTP.GetValuesetSpecifiedIdResponse, error) {
+			return c.next.GetValuesetSpecifiedIdWithResponse(ctx, id, reqEditors...)
+		})
+}
+
+func (c *TracedAPIClient) GetCapabilityStatementWithResponse(
+	ctx context.Context,
+	reqEditors ...fhirHTTP.RequestEditorFn,
+) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+	return withSpan(ctx, operationGetCapabilityStatement,
+		func(ctx context.Context) (*fhirHTTP.GetCapabilityStatementResponse, error) {
+			return c.next.GetCapabilityStatementWithResponse(ctx, reqEditors...)
+		})
+}
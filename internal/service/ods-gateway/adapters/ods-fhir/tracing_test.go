@@ -0,0 +1,79 @@
+package odsfhir_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
+)
+
+var (
+	spanRecorder     *tracetest.SpanRecorder
+	spanRecorderOnce sync.Once
+)
+
+// helper to record the spans of every test in the package with the global tracer provider.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return spanRecorder
+}
+
+func TestTracedAPIClient_PropagatesTraceContext(t *testing.T) {
+	t.Parallel()
+
+	recorder := recordSpans(t)
+
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		writeOrganisation(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	apiClient, err := fhirHTTP.NewClientWithResponses(srv.URL, fhirHTTP.WithRequestEditorFn(odsfhir.PropagateTraceContext))
+	require.NoError(t, err)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	ctx = tracing.WithCorrelationID(ctx, "corr-123")
+
+	_, err = odsfhir.NewTracedAPIClient(apiClient).GetSingleOrganizationWithResponse(ctx, "RR8")
+	require.NoError(t, err)
+	parent.End()
+
+	received := <-headers
+	assert.Equal(t, "corr-123", received.Get(tracing.CorrelationIDHeader))
+
+	var client sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.SpanKind() == trace.SpanKindClient && s.SpanContext().TraceID() == parent.SpanContext().TraceID() {
+			client = s
+		}
+	}
+	require.NotNil(t, client)
+	assert.Equal(t, "ODS get-single-organization", client.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), client.Parent().SpanID())
+
+	// the ODS API sees the client span as the parent of its own work.
+	wantTraceparent := "00-" + client.SpanContext().TraceID().String() + "-" + client.SpanContext().SpanID().String() + "-01"
+	assert.Equal(t, wantTraceparent, received.Get("traceparent"))
+}
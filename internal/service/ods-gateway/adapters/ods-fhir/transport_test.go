@@ -0,0 +1,201 @@
+package odsfhir_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	odsfhir "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
+)
+
+// writePEM writes a PEM block of the given type into a file in a test temp dir and returns its path.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return path
+}
+
+// newClientCertificate creates a self-signed client certificate and returns it with the paths of its PEM files.
+func newClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ods-gateway"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return cert, writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+func okHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestNewHTTPClient_TrustsCABundle(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	t.Cleanup(srv.Close)
+
+	client, err := odsfhir.NewHTTPClient(odsfhir.TransportConfig{})
+	require.NoError(t, err)
+	_, err = client.Get(srv.URL)
+	require.Error(t, err, "the test server certificate must not be trusted by default")
+
+	client, err = odsfhir.NewHTTPClient(odsfhir.TransportConfig{
+		CABundleFile: writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw),
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewHTTPClient_PresentsClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	clientCert, certFile, keyFile := newClientCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "ods-gateway" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	client, err := odsfhir.NewHTTPClient(odsfhir.TransportConfig{
+		CABundleFile:   writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw),
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewHTTPClient_UsesProxy(t *testing.T) {
+	t.Parallel()
+
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		assert.Equal(t, "ods.invalid", r.URL.Host)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(proxy.Close)
+
+	client, err := odsfhir.NewHTTPClient(odsfhir.TransportConfig{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+
+	resp, err := client.Get("http://ods.invalid/STU3/Organization/RR8")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 1, proxied.Load())
+}
+
+func TestNewHTTPClient_HTTP2(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		disableHTTP2 bool
+		protoMajor   int
+	}{
+		{name: "enabled by default", protoMajor: 2},
+		{name: "disabled", disableHTTP2: true, protoMajor: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(okHandler))
+			srv.EnableHTTP2 = true
+			srv.StartTLS()
+			t.Cleanup(srv.Close)
+
+			client, err := odsfhir.NewHTTPClient(odsfhir.TransportConfig{
+				CABundleFile: writePEM(t, "ca.pem", "CERTIFICATE", srv.Certificate().Raw),
+				DisableHTTP2: tt.disableHTTP2,
+			})
+			require.NoError(t, err)
+
+			resp, err := client.Get(srv.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.protoMajor, resp.ProtoMajor)
+		})
+	}
+}
+
+func TestNewHTTPClient_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, certFile, _ := newClientCertificate(t)
+
+	tests := []struct {
+		name string
+		cfg  odsfhir.TransportConfig
+	}{
+		{name: "invalid proxy URL", cfg: odsfhir.TransportConfig{ProxyURL: "://proxy"}},
+		{name: "missing CA bundle", cfg: odsfhir.TransportConfig{CABundleFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{name: "CA bundle without certificates", cfg: odsfhir.TransportConfig{CABundleFile: writePEM(t, "empty.pem", "NOTHING", nil)}},
+		{name: "client certificate without key", cfg: odsfhir.TransportConfig{ClientCertFile: certFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := odsfhir.NewHTTPClient(tt.cfg)
+			require.Error(t, err)
+		})
+	}
+}
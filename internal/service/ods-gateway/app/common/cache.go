@@ -0,0 +1,16 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+// ResponseCache stores serialised upstream responses. Implementations must be safe for concurrent use.
+//
+//counterfeiter:generate -o ./mocks/fake_response_cache.gen.go . ResponseCache
+type ResponseCache interface {
+	// Get returns the value stored under key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key, evicting it after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
@@ -0,0 +1,39 @@
+package common
+
+import "time"
+
+const (
+	// DriftUnknownField is a field the upstream sent which the vendored ODS API spec does not describe.
+	DriftUnknownField = "unknown_field"
+	// DriftMissingRequired is a field the vendored ODS API spec requires which the upstream left out.
+	DriftMissingRequired = "missing_required"
+	// DriftUnexpectedExtension is a FHIR extension URL the gateway does not know about.
+	DriftUnexpectedExtension = "unexpected_extension"
+)
+
+// UpstreamDriftFinding is one difference between real ODS API responses and the vendored spec, aggregated over
+// every sampled response in which it was seen.
+type UpstreamDriftFinding struct {
+	Kind      string `json:"kind"`
+	Operation string `json:"operation"`
+	// Path locates the field in the response body, with array indices collapsed to "[]", e.g. "entry[].resource.telecom".
+	Path         string    `json:"path"`
+	ExtensionURL string    `json:"extensionUrl,omitempty"`
+	Count        int64     `json:"count"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+}
+
+// UpstreamDriftReport summarises the schema drift found in sampled ODS API responses since the gateway started.
+type UpstreamDriftReport struct {
+	SampleRate float64 `json:"sampleRate"`
+	Sampled    int64   `json:"sampled"`
+	// Dropped counts occurrences of findings which were not recorded because the report was full.
+	Dropped  int64                  `json:"dropped"`
+	Findings []UpstreamDriftFinding `json:"findings"`
+}
+
+// UpstreamDriftReporter reports on the schema drift of the ODS API.
+type UpstreamDriftReporter interface {
+	UpstreamDriftReport() UpstreamDriftReport
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type freshnessKey struct{}
+
+// Freshness records whether data served past its freshness lifetime was used to answer a request.
+type Freshness struct {
+	mu       sync.Mutex
+	stale    bool
+	storedAt time.Time
+}
+
+// WithFreshness attaches a new Freshness recorder to ctx.
+func WithFreshness(ctx context.Context) (context.Context, *Freshness) {
+	f := &Freshness{}
+	return context.WithValue(ctx, freshnessKey{}, f), f
+}
+
+// MarkStale records that data stored at storedAt was served stale. It is a no-op when ctx carries no recorder.
+func MarkStale(ctx context.Context, storedAt time.Time) {
+	f, ok := ctx.Value(freshnessKey{}).(*Freshness)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.stale || storedAt.Before(f.storedAt) {
+		f.storedAt = storedAt
+	}
+	f.stale = true
+}
+
+// Stale reports whether stale data was served and when the oldest piece of it was stored.
+func (f *Freshness) Stale() (bool, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stale, f.storedAt
+}
@@ -2,6 +2,12 @@ package common
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
 )
@@ -15,10 +21,47 @@ type SeachOrganisationsRequest struct {
 	RoleCode        *string
 	Active          *bool
 	PrimaryRoleOnly *bool
+	LastUpdatedFrom *time.Time
 	PageSize        int
 	Page            int
 }
 
+// CacheKey derives a stable key for the request. Case-insensitive criteria are normalised so equivalent
+// searches share a key.
+func (r SeachOrganisationsRequest) CacheKey() string {
+	values := url.Values{}
+	setString := func(key string, value *string, fold bool) {
+		if value == nil {
+			return
+		}
+		v := strings.TrimSpace(*value)
+		if fold {
+			v = strings.ToLower(v)
+		}
+		values.Set(key, v)
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			values.Set(key, strconv.FormatBool(*value))
+		}
+	}
+
+	setString("name", r.Name, true)
+	setString("city", r.City, true)
+	setString("postcode", r.Postcode, true)
+	setString("roleCode", r.RoleCode, false)
+	setBool("active", r.Active)
+	setBool("primaryRoleOnly", r.PrimaryRoleOnly)
+	if r.LastUpdatedFrom != nil {
+		values.Set("lastUpdatedFrom", r.LastUpdatedFrom.Format(time.DateOnly))
+	}
+	values.Set("pageSize", strconv.Itoa(r.PageSize))
+	values.Set("page", strconv.Itoa(r.Page))
+
+	sum := sha256.Sum256([]byte(values.Encode()))
+	return hex.EncodeToString(sum[:])
+}
+
 //counterfeiter:generate -o ./mocks/fake_ods_fhir_client.gen.go . OdsFHIRClient
 type OdsFHIRClient interface {
 	SearchOrganisations(ctx context.Context, request SeachOrganisationsRequest) (*fhirHTTP.OrganizationBundle, error)
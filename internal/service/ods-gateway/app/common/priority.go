@@ -0,0 +1,28 @@
+package common
+
+import "context"
+
+// Priority orders calls to the ODS API when they have to queue for the upstream rate limit.
+type Priority int
+
+const (
+	// PriorityBackground is used for bulk and maintenance traffic, such as cache refreshes.
+	PriorityBackground Priority = iota
+	// PriorityInteractive is used for requests a consumer is waiting on. It is the default.
+	PriorityInteractive
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx carrying p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority carried by ctx, or PriorityInteractive when none is set.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityInteractive
+}
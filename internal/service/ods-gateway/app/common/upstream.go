@@ -0,0 +1,44 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type upstreamStatsKey struct{}
+
+// UpstreamStats counts the calls made to the ODS API while answering a request, and the time spent in them.
+type UpstreamStats struct {
+	mu       sync.Mutex
+	calls    int
+	duration time.Duration
+}
+
+// WithUpstreamStats attaches a new UpstreamStats recorder to ctx.
+func WithUpstreamStats(ctx context.Context) (context.Context, *UpstreamStats) {
+	s := &UpstreamStats{}
+	return context.WithValue(ctx, upstreamStatsKey{}, s), s
+}
+
+// RecordUpstreamCall records one call to the ODS API which took d. It is a no-op when ctx carries no recorder.
+func RecordUpstreamCall(ctx context.Context, d time.Duration) {
+	s, ok := ctx.Value(upstreamStatsKey{}).(*UpstreamStats)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	s.duration += d
+}
+
+// Calls reports how many calls were made to the ODS API and how long they took altogether.
+func (s *UpstreamStats) Calls() (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls, s.duration
+}
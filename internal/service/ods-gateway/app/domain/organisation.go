@@ -38,5 +38,6 @@ type OrganisationRole struct {
 	Display           string
 	OperationalPeriod *OperationalPeriod
 	Primary           bool `json:"primary"`
-	Status            string
+	// Status is "Active" or "Inactive", derived from the operational period when ODS reports none.
+	Status string
 }
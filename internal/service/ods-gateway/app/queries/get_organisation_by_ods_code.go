@@ -5,12 +5,17 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/domain"
 )
 
 type GetOrganisationByODSCodeQuery struct {
 	ODSCode string
+	// IncludeInactiveRoles keeps roles ODS reports as inactive, which are dropped by default.
+	IncludeInactiveRoles bool
 }
 
 type GetOrganisationByODSCodeQueryHandler interface {
@@ -32,7 +37,7 @@ func (h *getOrganisationByODSCodeQueryHandlerImpl) Handle(
 	query GetOrganisationByODSCodeQuery,
 ) (domain.Organisation, error) {
 	if query.ODSCode == "" {
-		return domain.Organisation{}, errors.New("ODS code is required")
+		return domain.Organisation{}, apperrors.New(apperrors.ErrInvalidInput, "ODS code is required")
 	}
 
 	organisation, err := h.fhirClient.GetOrganisationByID(ctx, query.ODSCode)
@@ -41,8 +46,17 @@ func (h *getOrganisationByODSCodeQueryHandlerImpl) Handle(
 	}
 
 	if organisation == nil {
-		return domain.Organisation{}, errors.New("no data received from ODS API")
+		return domain.Organisation{}, apperrors.New(apperrors.ErrMapping, "no data received from ODS API")
 	}
 
-	return mapOrganisationToDomain(*organisation), nil
+	_, span := tracing.Tracer().Start(ctx, "map organisation")
+	result := mapOrganisationToDomain(*organisation)
+	if !query.IncludeInactiveRoles {
+		result.Roles = activeRoles(result.Roles)
+	}
+	span.End()
+
+	elog.Ctx(ctx).Debug().Str("odsCode", query.ODSCode).Int("roles", len(result.Roles)).Msg("organisation looked up")
+
+	return result, nil
 }
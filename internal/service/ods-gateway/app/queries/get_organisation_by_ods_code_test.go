@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common/mocks"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
@@ -237,3 +238,104 @@ func TestGetOrganisationByODSCode_InvalidInput(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorContains(t, err, "ODS code is required")
 }
+
+func TestGetOrganisationByODSCode_InvalidInput_Classified(t *testing.T) {
+	t.Parallel()
+
+	handler, mockODS := newHandlerWithMock(t)
+
+	_, err := handler.Handle(context.Background(), queries.GetOrganisationByODSCodeQuery{})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+	assert.Equal(t, 0, mockODS.GetOrganisationByIDCallCount())
+}
+
+func TestGetOrganisationByODSCode_UpstreamErrorKindPreserved(t *testing.T) {
+	t.Parallel()
+
+	handler, mockODS := newHandlerWithMock(t)
+
+	mockODS.GetOrganisationByIDReturns(nil, apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API"))
+
+	_, err := handler.Handle(context.Background(), queries.GetOrganisationByODSCodeQuery{ODSCode: "UNKNOWN"})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestGetOrganisationByODSCode_InactiveRoles(t *testing.T) {
+	t.Parallel()
+
+	role := func(code, status string, end *types.Date) http.Extension {
+		inner := []http.Extension{{Url: utils.Ref(queries.ExtensionRole), ValueCoding: &http.Coding{Code: utils.Ref(code)}}}
+		if status != "" {
+			inner = append(inner, http.Extension{Url: utils.Ref(queries.ExtensionStatus), ValueString: utils.Ref(status)})
+		}
+		if end != nil {
+			inner = append(inner, http.Extension{
+				Url:         utils.Ref(queries.ExtensionActivePeriod),
+				ValuePeriod: &http.Period{Start: &types.Date{Time: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)}, End: end},
+			})
+		}
+		return http.Extension{Url: utils.Ref(queries.OrgRoleURL), Extension: &inner}
+	}
+	ended := &types.Date{Time: time.Date(2013, 3, 31, 0, 0, 0, 0, time.UTC)}
+	org := &http.OrganizationResource{
+		Id:   "RR8",
+		Name: "LEEDS TEACHING HOSPITALS NHS TRUST",
+		Extension: utils.Ref([]http.Extension{
+			role("RO197", "Active", nil),
+			role("RO57", "Inactive", nil),
+			role("RO98", "", nil),
+			role("RO76", "", ended),
+			role("RO24", "Suspended", ended),
+		}),
+	}
+
+	tests := []struct {
+		name                 string
+		includeInactiveRoles bool
+		codes                []string
+		statuses             []string
+	}{
+		{
+			name:     "filtered by default",
+			codes:    []string{"RO197", "RO98"},
+			statuses: []string{queries.RoleStatusActive, queries.RoleStatusActive},
+		},
+		{
+			name:                 "included on request",
+			includeInactiveRoles: true,
+			codes:                []string{"RO197", "RO57", "RO98", "RO76", "RO24"},
+			statuses: []string{
+				queries.RoleStatusActive, queries.RoleStatusInactive, queries.RoleStatusActive,
+				queries.RoleStatusInactive, queries.RoleStatusInactive,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, mockODS := newHandlerWithMock(t)
+			mockODS.GetOrganisationByIDReturns(org, nil)
+
+			result, err := handler.Handle(context.Background(), queries.GetOrganisationByODSCodeQuery{
+				ODSCode:              "RR8",
+				IncludeInactiveRoles: tt.includeInactiveRoles,
+			})
+			require.NoError(t, err)
+
+			codes := make([]string, 0, len(result.Roles))
+			statuses := make([]string, 0, len(result.Roles))
+			for _, r := range result.Roles {
+				codes = append(codes, r.Code)
+				statuses = append(statuses, r.Status)
+			}
+			assert.Equal(t, tt.codes, codes)
+			assert.Equal(t, tt.statuses, statuses)
+		})
+	}
+}
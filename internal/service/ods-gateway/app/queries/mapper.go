@@ -1,6 +1,11 @@
 package queries
 
 import (
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/domain"
 	fhirHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
@@ -20,10 +25,23 @@ const (
 	ExtensionActivePeriod = "activePeriod"
 )
 
+// ExtensionURLs lists every extension URL the mapper reads, including the relative URLs nested in a role.
+func ExtensionURLs() []string {
+	return []string{
+		ActivePeriodURL, DateTypeURL, OrgRoleURL,
+		ExtensionRole, ExtensionPrimaryRole, ExtensionStatus, ExtensionActivePeriod,
+	}
+}
+
+const (
+	RoleStatusActive   = "Active"
+	RoleStatusInactive = "Inactive"
+)
+
 func mapOrganisationToDomain(org fhirHTTP.OrganizationResource) domain.Organisation {
 	odsCode := org.Id
-	if *org.Identifier.System == ODSCodeURL {
-		odsCode = *org.Identifier.Value
+	if identifier := utils.Deref(org.Identifier); utils.Deref(identifier.System) == ODSCodeURL {
+		odsCode = utils.Deref(identifier.Value)
 	}
 
 	orgAddress := utils.Deref(org.Address)
@@ -98,6 +116,7 @@ func getRoles(org fhirHTTP.OrganizationResource) []domain.OrganisationRole {
 
 		// only include roles that at least have a code
 		if r.Code != "" {
+			r.Status = roleStatus(r)
 			roles = append(roles, r)
 		}
 	}
@@ -105,6 +124,36 @@ func getRoles(org fhirHTTP.OrganizationResource) []domain.OrganisationRole {
 	return roles
 }
 
+// roleStatus maps the free-text ODS role status onto RoleStatusActive or RoleStatusInactive. A missing or
+// unknown status is derived from the end of the operational period of the role.
+func roleStatus(r domain.OrganisationRole) string {
+	switch {
+	case strings.EqualFold(r.Status, RoleStatusActive):
+		return RoleStatusActive
+	case strings.EqualFold(r.Status, RoleStatusInactive):
+		return RoleStatusInactive
+	}
+
+	if r.Status != "" {
+		log.Warn().Str("status", r.Status).Str("role", r.Code).Msg("unknown ODS role status")
+	}
+	if r.OperationalPeriod != nil && r.OperationalPeriod.End != nil && r.OperationalPeriod.End.Before(time.Now()) {
+		return RoleStatusInactive
+	}
+	return RoleStatusActive
+}
+
+// activeRoles drops the roles whose status is inactive.
+func activeRoles(roles []domain.OrganisationRole) []domain.OrganisationRole {
+	active := make([]domain.OrganisationRole, 0, len(roles))
+	for _, r := range roles {
+		if r.Status != RoleStatusInactive {
+			active = append(active, r)
+		}
+	}
+	return active
+}
+
 func getMetadata(org fhirHTTP.OrganizationResource) domain.OrganisationMetadata {
 	if org.Meta == nil {
 		return domain.OrganisationMetadata{}
@@ -0,0 +1,70 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/domain"
+)
+
+const (
+	QueryGetOrganisationByODSCode = "GetOrganisationByODSCode"
+	QuerySearchOrganisations      = "SearchOrganisations"
+)
+
+// NewMeasuredGetOrganisationByODSCodeQueryHandler records the duration and outcome of every query handled by next,
+// and traces it with a span.
+func NewMeasuredGetOrganisationByODSCodeQueryHandler(
+	next GetOrganisationByODSCodeQueryHandler,
+) GetOrganisationByODSCodeQueryHandler {
+	return &measuredGetOrganisationByODSCodeQueryHandler{next: next}
+}
+
+type measuredGetOrganisationByODSCodeQueryHandler struct {
+	next GetOrganisationByODSCodeQueryHandler
+}
+
+func (h *measuredGetOrganisationByODSCodeQueryHandler) Handle(
+	ctx context.Context,
+	query GetOrganisationByODSCodeQuery,
+) (domain.Organisation, error) {
+	ctx, span := tracing.Tracer().Start(ctx, QueryGetOrganisationByODSCode,
+		trace.WithAttributes(attribute.String("ods.code", query.ODSCode)))
+	start := time.Now()
+
+	result, err := h.next.Handle(ctx, query)
+	metrics.ObserveQuery(QueryGetOrganisationByODSCode, start, err)
+	tracing.End(span, err)
+
+	return result, err
+}
+
+// NewMeasuredSearchOrganisationsQueryHandler records the duration and outcome of every query handled by next,
+// and traces it with a span.
+func NewMeasuredSearchOrganisationsQueryHandler(next SearchOrganisationsQueryHandler) SearchOrganisationsQueryHandler {
+	return &measuredSearchOrganisationsQueryHandler{next: next}
+}
+
+type measuredSearchOrganisationsQueryHandler struct {
+	next SearchOrganisationsQueryHandler
+}
+
+func (h *measuredSearchOrganisationsQueryHandler) Handle(
+	ctx context.Context,
+	query SearchOrganisationsQuery,
+) (SearchOrganisationsResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, QuerySearchOrganisations,
+		trace.WithAttributes(attribute.Int("page", query.Page), attribute.Int("page.size", query.PageSize)))
+	start := time.Now()
+
+	result, err := h.next.Handle(ctx, query)
+	metrics.ObserveQuery(QuerySearchOrganisations, start, err)
+	tracing.End(span, err)
+
+	return result, err
+}
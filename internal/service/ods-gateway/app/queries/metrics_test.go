@@ -0,0 +1,45 @@
+package queries_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common/mocks"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
+)
+
+func TestMeasuredQueryHandlers_ObserveDurationByOutcome(t *testing.T) {
+	t.Parallel()
+
+	mockODS := &mocks.FakeOdsFHIRClient{}
+	mockODS.GetOrganisationByIDReturns(nil, apperrors.New(apperrors.ErrNotFound, "organisation not found"))
+	mockODS.SearchOrganisationsReturns(nil, apperrors.New(apperrors.ErrUpstreamTimeout, "ODS API timed out"))
+
+	lookup := queries.NewMeasuredGetOrganisationByODSCodeQueryHandler(queries.NewGetOrganisationByODSCodeQueryHandler(mockODS))
+	search := queries.NewMeasuredSearchOrganisationsQueryHandler(queries.NewSearchOrganisationsQueryHandler(mockODS))
+
+	_, err := lookup.Handle(context.Background(), queries.GetOrganisationByODSCodeQuery{ODSCode: "RR8"})
+	require.Error(t, err)
+	_, err = lookup.Handle(context.Background(), queries.GetOrganisationByODSCodeQuery{})
+	require.Error(t, err)
+	_, err = search.Handle(context.Background(), queries.SearchOrganisationsQuery{Page: 1, PageSize: 10})
+	require.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	scraped := string(body)
+	assert.Contains(t, scraped, `ods_gateway_query_duration_seconds_count{outcome="not_found",query="GetOrganisationByODSCode"} 1`)
+	assert.Contains(t, scraped, `ods_gateway_query_duration_seconds_count{outcome="invalid_input",query="GetOrganisationByODSCode"} 1`)
+	assert.Contains(t, scraped, `ods_gateway_query_duration_seconds_count{outcome="upstream_timeout",query="SearchOrganisations"} 1`)
+}
@@ -3,9 +3,14 @@ package queries
 import (
 	"context"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/domain"
@@ -18,6 +23,8 @@ type SearchOrganisationsQuery struct {
 	RoleCode        *string
 	Active          *bool
 	PrimaryRoleOnly *bool
+	// LastUpdatedFrom limits results to organisations updated on or after this date.
+	LastUpdatedFrom *time.Time
 	PageSize        int
 	Page            int
 }
@@ -52,6 +59,7 @@ func (h *searchOrganisationsQueryHandlerImpl) Handle(
 		RoleCode:        query.RoleCode,
 		Active:          query.Active,
 		PrimaryRoleOnly: query.PrimaryRoleOnly,
+		LastUpdatedFrom: query.LastUpdatedFrom,
 		PageSize:        query.PageSize,
 		Page:            query.Page,
 	})
@@ -59,18 +67,27 @@ func (h *searchOrganisationsQueryHandlerImpl) Handle(
 		return SearchOrganisationsResponse{}, errors.Wrap(err, "error getting organisation from ODS API")
 	}
 
+	if organisationBundle == nil {
+		return SearchOrganisationsResponse{}, apperrors.New(apperrors.ErrMapping, "no data received from ODS API")
+	}
+
+	_, span := tracing.Tracer().Start(ctx, "map organisations")
 	orgs := make([]domain.Organisation, 0)
 	for _, entry := range utils.Deref(organisationBundle.Entry) {
 		if entry.Resource != nil {
 			orgs = append(orgs, mapOrganisationToDomain(*entry.Resource))
 		}
 	}
+	span.SetAttributes(attribute.Int("organisations.count", len(orgs)))
 
 	total, err := strconv.Atoi(utils.Deref(organisationBundle.Total))
+	tracing.End(span, err)
 	if err != nil {
-		return SearchOrganisationsResponse{}, err
+		return SearchOrganisationsResponse{}, apperrors.Wrap(apperrors.ErrMapping, err, "invalid total in ODS API response")
 	}
 
+	elog.Ctx(ctx).Debug().Int("results", len(orgs)).Int("total", total).Msg("organisations searched")
+
 	return SearchOrganisationsResponse{
 		Organisations: orgs,
 		TotalCount:    total,
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/utils"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
 	http "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
@@ -43,6 +44,7 @@ func TestSearchOrganisations_Success(t *testing.T) {
 		RoleCode:        utils.Ref("ROLE-1"),
 		Active:          utils.Ref(true),
 		PrimaryRoleOnly: utils.Ref(true),
+		LastUpdatedFrom: utils.Ref(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
 		PageSize:        25,
 		Page:            2,
 	}
@@ -132,6 +134,7 @@ func TestSearchOrganisations_Success(t *testing.T) {
 	assert.Equal(t, q.RoleCode, req.RoleCode)
 	assert.Equal(t, q.Active, req.Active)
 	assert.Equal(t, q.PrimaryRoleOnly, req.PrimaryRoleOnly)
+	assert.Equal(t, q.LastUpdatedFrom, req.LastUpdatedFrom)
 	assert.Equal(t, q.PageSize, req.PageSize)
 	assert.Equal(t, q.Page, req.Page)
 
@@ -191,3 +194,15 @@ func TestSearchOrganisations_InvalidTotal_ReturnsError(t *testing.T) {
 	_, err := handler.Handle(ctx, q)
 	require.Error(t, err)
 }
+
+func TestSearchOrganisations_InvalidTotal_IsMappingError(t *testing.T) {
+	t.Parallel()
+
+	handler, mockODS := newSearchHandlerWithMock(t)
+
+	mockODS.SearchOrganisationsReturns(&http.OrganizationBundle{Total: utils.Ref("not-a-number")}, nil)
+
+	_, err := handler.Handle(context.Background(), queries.SearchOrganisationsQuery{Name: utils.Ref("Acme")})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrMapping)
+}
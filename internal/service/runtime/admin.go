@@ -0,0 +1,152 @@
+package runtime
+
+import (
+	"net/http"
+	"net/http/pprof"
+	goruntime "runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+)
+
+const (
+	AdminLogLevelPath      = "/log-level"
+	AdminConfigPath        = "/config"
+	AdminBuildInfoPath     = "/build-info"
+	AdminGoroutinesPath    = "/debug/goroutines"
+	AdminHeapPath          = "/debug/heap"
+	AdminUpstreamProbePath = "/upstream/probe"
+)
+
+// LogLevel is the body of the log level endpoints of the admin server.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	GoVersion string `json:"goVersion"`
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified"`
+}
+
+// HeapSnapshot is a summary of the Go heap and scheduler.
+type HeapSnapshot struct {
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	HeapInuse    uint64 `json:"heapInuse"`
+	HeapObjects  uint64 `json:"heapObjects"`
+	HeapReleased uint64 `json:"heapReleased"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"numGC"`
+	PauseTotalNs uint64 `json:"pauseTotalNs"`
+}
+
+// NewAdminServer serves operational endpoints on their own listener, which is meant to be reachable by operators
+// only: pprof profiles, the log level, the redacted configuration, build info, goroutine and heap snapshots and
+// an upstream probe. It has no authentication of its own. With a reloader, the configuration served is the one
+// applied last.
+func NewAdminServer(cfg config.Config, checker *health.Checker, reloader *Reloader) *echo.Echo {
+	e := echo.New()
+
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(RecoverMiddleware())
+
+	e.GET("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+	e.GET("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+	e.GET("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	e.POST("/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	e.GET("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+	// the index also serves the named profiles, such as /debug/pprof/heap.
+	e.GET("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
+
+	e.GET(AdminLogLevelPath, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, LogLevel{Level: elog.Level()})
+	})
+	e.PUT(AdminLogLevelPath, func(c echo.Context) error {
+		var body LogLevel
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		if err := elog.SetLevel(body.Level); err != nil {
+			return apperrors.New(apperrors.ErrInvalidInput, err.Error())
+		}
+
+		elog.Ctx(c.Request().Context()).Warn().Str("level", elog.Level()).Msg("log level changed")
+		return c.JSON(http.StatusOK, LogLevel{Level: elog.Level()})
+	})
+
+	e.GET(AdminConfigPath, func(c echo.Context) error {
+		if reloader != nil {
+			return c.JSON(http.StatusOK, reloader.Current().Redacted())
+		}
+		return c.JSON(http.StatusOK, cfg.Redacted())
+	})
+
+	e.GET(AdminBuildInfoPath, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, readBuildInfo())
+	})
+
+	e.GET(AdminGoroutinesPath, func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		// debug=2 prints the full stack of every goroutine, as on an unrecovered panic.
+		return rpprof.Lookup("goroutine").WriteTo(c.Response(), 2)
+	})
+
+	e.GET(AdminHeapPath, func(c echo.Context) error {
+		var stats goruntime.MemStats
+		goruntime.ReadMemStats(&stats)
+
+		return c.JSON(http.StatusOK, HeapSnapshot{
+			Goroutines:   goruntime.NumGoroutine(),
+			HeapAlloc:    stats.HeapAlloc,
+			HeapInuse:    stats.HeapInuse,
+			HeapObjects:  stats.HeapObjects,
+			HeapReleased: stats.HeapReleased,
+			Sys:          stats.Sys,
+			NumGC:        stats.NumGC,
+			PauseTotalNs: stats.PauseTotalNs,
+		})
+	})
+
+	e.POST(AdminUpstreamProbePath, HealthProbeHandler(checker))
+
+	return e
+}
+
+func readBuildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: goruntime.Version()}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Module = build.Main.Path
+	info.Version = build.Main.Version
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,144 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to send a request to the admin server and return the recorded response.
+func serveAdmin(t *testing.T, e *echo.Echo, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// not parallel: the test changes the global log level.
+func TestAdminServer_SwitchesLogLevel(t *testing.T) {
+	previous := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(previous) })
+
+	e := runtime.NewAdminServer(config.Config{}, newChecker(t), nil)
+
+	rec := serveAdmin(t, e, http.MethodPut, runtime.AdminLogLevelPath, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"DEBUG"}`, rec.Body.String())
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+	rec = serveAdmin(t, e, http.MethodGet, runtime.AdminLogLevelPath, "")
+	assert.JSONEq(t, `{"level":"DEBUG"}`, rec.Body.String())
+
+	rec = serveAdmin(t, e, http.MethodPut, runtime.AdminLogLevelPath, `{"level":"verbose"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+}
+
+// not parallel: the test changes the global log level.
+func TestReloadLogLevel_KeepsLevelSetThroughAdminServer(t *testing.T) {
+	previous := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(previous) })
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	initial := config.Config{LogLevel: "INFO", APIKey: "old"}
+	source := &configSource{cfg: initial}
+	reloader := runtime.NewReloader(initial, source.load)
+	reloader.OnReload(runtime.ReloadLogLevel(reloader))
+	e := runtime.NewAdminServer(initial, newChecker(t), reloader)
+
+	rec := serveAdmin(t, e, http.MethodPut, runtime.AdminLogLevelPath, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// reloading another setting keeps the level of the operator.
+	source.set(config.Config{LogLevel: "INFO", APIKey: "new"}, nil)
+	require.NoError(t, reloader.Reload(runtime.ReloadTriggerSignal))
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+	// changing LOG_LEVEL applies it.
+	source.set(config.Config{LogLevel: "WARN", APIKey: "new"}, nil)
+	require.NoError(t, reloader.Reload(runtime.ReloadTriggerSignal))
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+}
+
+func TestAdminServer_DumpsRedactedConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config{APIKey: "secret", RequestTimeout: 20 * time.Second}
+	cfg.ODSConfig.ServerURL = "https://ods.example.com/STU3"
+
+	rec := serveAdmin(t, runtime.NewAdminServer(cfg, newChecker(t), nil), http.MethodGet, runtime.AdminConfigPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "secret")
+
+	var dump map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dump))
+	assert.Equal(t, "REDACTED", dump["API_KEY"])
+	assert.Equal(t, "20s", dump["REQUEST_TIMEOUT"])
+	assert.Equal(t, "https://ods.example.com/STU3", dump["ODS_FHIR_API_SERVER_URL"])
+}
+
+func TestAdminServer_Diagnostics(t *testing.T) {
+	t.Parallel()
+
+	e := runtime.NewAdminServer(config.Config{}, newChecker(t), nil)
+
+	rec := serveAdmin(t, e, http.MethodGet, runtime.AdminBuildInfoPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var info runtime.BuildInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.True(t, strings.HasPrefix(info.GoVersion, "go"))
+
+	rec = serveAdmin(t, e, http.MethodGet, runtime.AdminHeapPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var heap runtime.HeapSnapshot
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &heap))
+	assert.Positive(t, heap.Goroutines)
+	assert.Positive(t, heap.HeapAlloc)
+
+	rec = serveAdmin(t, e, http.MethodGet, runtime.AdminGoroutinesPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine ")
+
+	rec = serveAdmin(t, e, http.MethodGet, "/debug/pprof/", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = serveAdmin(t, e, http.MethodGet, "/debug/pprof/heap?debug=1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminServer_ProbesUpstream(t *testing.T) {
+	t.Parallel()
+
+	var runs atomic.Int32
+	checker, err := health.NewChecker(health.Check{Name: "ods_metadata", CacheTTL: time.Hour, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	require.NoError(t, err)
+	e := runtime.NewAdminServer(config.Config{}, checker, nil)
+
+	for range 2 {
+		rec := serveAdmin(t, e, http.MethodPost, runtime.AdminUpstreamProbePath, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, int32(2), runs.Load(), "probes ignore cached results")
+}
@@ -0,0 +1,170 @@
+package runtime
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+)
+
+type AuthConfig struct {
+	// Header carries API keys. It defaults to X-API-Key.
+	Header string
+	Keys   *auth.KeyStore
+	// Tokens verifies bearer tokens sent in the Authorization header. Only API keys are accepted when it is nil.
+	Tokens *auth.TokenVerifier
+	// Methods restricts the methods accepted on the routes it has, keyed by route template, to those it lists.
+	// Routes missing from it accept every method.
+	Methods map[string][]string
+	Skips   []string
+}
+
+// AuthMiddleware authenticates requests by their bearer token, their API key or their client certificate, whichever
+// of those the route accepts is presented first in that order, and puts the consumer identified on the request
+// context.
+func AuthMiddleware(c AuthConfig) echo.MiddlewareFunc {
+	header := c.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+
+	skips := make(map[string]struct{}, len(c.Skips))
+	for _, p := range c.Skips {
+		skips[p] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if _, ok := skips[ctx.Request().URL.Path]; ok {
+				return next(ctx)
+			}
+
+			methods, restricted := c.Methods[ctx.Path()]
+			accepts := func(method string) bool {
+				return !restricted || slices.Contains(methods, method)
+			}
+
+			var consumer auth.Consumer
+			token, bearer := bearerToken(ctx.Request())
+			key := strings.TrimSpace(ctx.Request().Header.Get(header))
+
+			switch {
+			case bearer && c.Tokens != nil && accepts(auth.MethodBearer):
+				var err error
+				consumer, err = c.Tokens.Verify(ctx.Request().Context(), token)
+				if err != nil {
+					elog.Ctx(ctx.Request().Context()).Debug().Err(err).Msg("bearer token rejected")
+					ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, auth.ErrInvalidToken.Error())
+				}
+			case key != "" && c.Keys != nil && !c.Keys.Empty() && accepts(auth.MethodAPIKey):
+				var err error
+				consumer, err = c.Keys.Authenticate(key, time.Now())
+				if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+				}
+			case clientCertificate(ctx.Request()) != nil && c.Keys != nil && accepts(auth.MethodCertificate):
+				var err error
+				consumer, err = c.Keys.AuthenticateCertificate(clientCertificate(ctx.Request()))
+				if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+				}
+			default:
+				if c.Tokens != nil && accepts(auth.MethodBearer) {
+					ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				}
+				return echo.NewHTTPError(http.StatusUnauthorized, missingCredentials(c.Tokens != nil, accepts))
+			}
+
+			withLogField(ctx, "consumer", consumer.Name)
+			withLogField(ctx, "apiKeyId", consumer.KeyID)
+			req := ctx.Request()
+			ctx.SetRequest(req.WithContext(auth.WithConsumer(req.Context(), consumer)))
+
+			return next(ctx)
+		}
+	}
+}
+
+// missingCredentials names the credentials a route accepts, to reject requests presenting none of them.
+func missingCredentials(tokens bool, accepts func(method string) bool) string {
+	var names []string
+	if accepts(auth.MethodAPIKey) {
+		names = append(names, "api key")
+	}
+	if tokens && accepts(auth.MethodBearer) {
+		names = append(names, "bearer token")
+	}
+	if len(names) == 0 {
+		return "missing client certificate"
+	}
+	return "missing " + strings.Join(names, " or ")
+}
+
+// clientCertificate returns the client certificate of a TLS request, when it was verified against the client CAs.
+func clientCertificate(req *http.Request) *x509.Certificate {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return req.TLS.VerifiedChains[0][0]
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// ScopeMiddleware rejects requests to the routes of scopes, keyed by route template, whose consumer is not granted
+// the scope of the route. Routes missing from scopes are open to every consumer: CheckScopes makes sure that only
+// the routes meant to be open are.
+func ScopeMiddleware(scopes map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scope, ok := scopes[c.Path()]
+			if !ok {
+				return next(c)
+			}
+
+			consumer, _ := auth.ConsumerFromContext(c.Request().Context())
+			if !consumer.HasScope(scope) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("api key lacks the %s scope", scope))
+			}
+			return next(c)
+		}
+	}
+}
+
+// CheckScopes reports every route, other than those of the open paths, which scopes gives no scope to, so that a
+// route added without a scope fails the startup rather than being served to every consumer.
+func CheckScopes(routes []*echo.Route, scopes map[string]string, open ...string) error {
+	exempt := make(map[string]struct{}, len(open))
+	for _, p := range open {
+		exempt[p] = struct{}{}
+	}
+
+	var errs []error
+	for _, r := range routes {
+		// group middlewares register catch-all routes answering 404.
+		if r.Method == echo.RouteNotFound {
+			continue
+		}
+		if _, ok := exempt[r.Path]; ok {
+			continue
+		}
+		if _, ok := scopes[r.Path]; !ok {
+			errs = append(errs, fmt.Errorf("route %s %s has no scope", r.Method, r.Path))
+		}
+	}
+	return errors.Join(errs...)
+}
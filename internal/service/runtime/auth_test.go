@@ -0,0 +1,279 @@
+package runtime_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to create a key store where each of keys belongs to the consumer "tester", granted every scope.
+func newKeyStore(t *testing.T, keys ...string) *auth.KeyStore {
+	t.Helper()
+
+	consumer := auth.ConsumerConfig{Name: "tester", Scopes: []string{auth.ScopeAll}}
+	for _, key := range keys {
+		consumer.Keys = append(consumer.Keys, auth.KeyConfig{SHA256: auth.HashKey(key)})
+	}
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{consumer})
+	require.NoError(t, err)
+	return store
+}
+
+func TestAuthMiddleware_PutsKeyConsumerOnContext(t *testing.T) {
+	t.Parallel()
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{
+		{
+			Name:   "gp-portal",
+			Scopes: []string{auth.ScopeOrganisationsRead},
+			Keys: []auth.KeyConfig{
+				{ID: "current", SHA256: auth.HashKey("current-key")},
+				{ID: "expired", SHA256: auth.HashKey("expired-key"), ExpiresAt: time.Now().Add(-time.Hour)},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.MetricsMiddleware())
+	api := e.Group("")
+	api.Use(runtime.AuthMiddleware(runtime.AuthConfig{Keys: store, Skips: []string{"/open"}}))
+	api.Use(runtime.ScopeMiddleware(map[string]string{
+		"/auth-test/organisations": auth.ScopeOrganisationsRead,
+		"/auth-test/diagnostics":   auth.ScopeDiagnosticsRead,
+	}))
+	handler := func(c echo.Context) error {
+		consumer, ok := auth.ConsumerFromContext(c.Request().Context())
+		if !ok {
+			return c.String(http.StatusOK, "anonymous")
+		}
+		return c.String(http.StatusOK, consumer.Name+"/"+consumer.KeyID)
+	}
+	api.GET("/auth-test/organisations", handler)
+	api.GET("/auth-test/diagnostics", handler)
+	api.GET("/open", handler)
+
+	serve := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/auth-test/organisations", "current-key")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gp-portal/current", rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/auth-test/organisations", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("/auth-test/organisations", "unknown-key").Code)
+
+	rec = serve("/auth-test/organisations", "expired-key")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "expired")
+
+	rec = serve("/auth-test/diagnostics", "current-key")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), auth.ScopeDiagnosticsRead)
+
+	rec = serve("/open", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "anonymous", rec.Body.String())
+
+	assert.Contains(t, scrapeMetrics(t),
+		`ods_gateway_consumer_requests_total{consumer="gp-portal",route="/auth-test/organisations",status="200"} 1`)
+}
+
+func TestAuthMiddleware_AcceptsTheMethodsOfTheRoute(t *testing.T) {
+	t.Parallel()
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{{
+		Name:             "reporting",
+		Scopes:           []string{auth.ScopeAll},
+		Keys:             []auth.KeyConfig{{ID: "2026-10", SHA256: auth.HashKey("key")}},
+		CertificateNames: []string{"reporting.hscn.example"},
+	}})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	api := e.Group("")
+	api.Use(runtime.AuthMiddleware(runtime.AuthConfig{
+		Keys:    store,
+		Methods: map[string][]string{"/auth-test/export": {auth.MethodCertificate}},
+	}))
+	handler := func(c echo.Context) error {
+		consumer, _ := auth.ConsumerFromContext(c.Request().Context())
+		return c.String(http.StatusOK, consumer.KeyID)
+	}
+	api.GET("/auth-test/export", handler)
+	api.GET("/auth-test/organisations", handler)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "reporting.hscn.example"}, SerialNumber: big.NewInt(0xabc)}
+	serve := func(path string, withKey, withCert bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if withKey {
+			req.Header.Set("X-API-Key", "key")
+		}
+		if withCert {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/auth-test/export", true, false)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "missing client certificate")
+
+	// the API key presented alongside the certificate is not taken on the route.
+	rec = serve("/auth-test/export", true, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "cert:abc", rec.Body.String())
+
+	rec = serve("/auth-test/organisations", true, true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2026-10", rec.Body.String())
+}
+
+func TestCheckScopes_ReportsRoutesWithoutAScope(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	e.GET("/liveness", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	api := e.Group("")
+	api.Use(runtime.ScopeMiddleware(map[string]string{"/organisations": auth.ScopeOrganisationsRead}))
+	handler := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	api.GET("/organisations", handler)
+
+	scopes := map[string]string{"/organisations": auth.ScopeOrganisationsRead}
+	require.NoError(t, runtime.CheckScopes(e.Routes(), scopes, "/liveness"))
+
+	api.GET("/organisations/:odsCode/successors", handler)
+	api.POST("/export", handler)
+	err := runtime.CheckScopes(e.Routes(), scopes, "/liveness")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "route GET /organisations/:odsCode/successors has no scope")
+	assert.ErrorContains(t, err, "route POST /export has no scope")
+	assert.NotContains(t, err.Error(), "/liveness")
+}
+
+// helper to sign an ES256 token with key, in the way of an identity provider.
+func signToken(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+
+	segment := func(v any) string {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signed := segment(map[string]string{"alg": auth.AlgES256, "kid": kid, "typ": "JWT"}) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+}
+
+func TestAuthMiddleware_AcceptsBearerTokensAndAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	raw, err := json.Marshal(auth.JWKS{Keys: []auth.JWK{auth.ECPublicJWK("ec-1", &key.PublicKey)}})
+	require.NoError(t, err)
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwks, raw, 0o600))
+
+	tokens, err := auth.NewTokenVerifier(auth.TokenConfig{
+		JWKSFile: jwks,
+		Issuer:   "https://idp.example.com/",
+		Audience: "ods-gateway",
+		ScopeMap: map[string]string{"ods.read": auth.ScopeOrganisationsRead},
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.MetricsMiddleware())
+	api := e.Group("")
+	api.Use(runtime.AuthMiddleware(runtime.AuthConfig{Keys: newKeyStore(t, "key"), Tokens: tokens}))
+	api.Use(runtime.ScopeMiddleware(map[string]string{
+		"/auth-test/organisations": auth.ScopeOrganisationsRead,
+		"/auth-test/diagnostics":   auth.ScopeDiagnosticsRead,
+	}))
+	handler := func(c echo.Context) error {
+		consumer, _ := auth.ConsumerFromContext(c.Request().Context())
+		return c.String(http.StatusOK, consumer.Name+"/"+consumer.KeyID)
+	}
+	api.GET("/auth-test/organisations", handler)
+	api.GET("/auth-test/diagnostics", handler)
+
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header = header
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	bearer := func(token string) http.Header {
+		return http.Header{echo.HeaderAuthorization: []string{"Bearer " + token}}
+	}
+	claims := map[string]any{
+		"iss":   "https://idp.example.com/",
+		"aud":   "ods-gateway",
+		"sub":   "reporting-service",
+		"scope": "ods.read",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+
+	rec := serve("/auth-test/organisations", bearer(signToken(t, key, "ec-1", claims)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "jwt:reporting-service/jwt:ec-1", rec.Body.String())
+
+	rec = serve("/auth-test/diagnostics", bearer(signToken(t, key, "ec-1", claims)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	rec = serve("/auth-test/organisations", bearer(signToken(t, key, "ec-1", claims)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+	rec = serve("/auth-test/organisations", http.Header{})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+	rec = serve("/auth-test/diagnostics", http.Header{"X-Api-Key": []string{"key"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "tester/")
+
+	// the subjects of tokens are not known in advance, so they share a label.
+	scraped := scrapeMetrics(t)
+	assert.Contains(t, scraped, `ods_gateway_consumer_requests_total{consumer="jwt:*",route="/auth-test/organisations",status="200"} 1`)
+	assert.NotContains(t, scraped, `consumer="jwt:reporting-service"`)
+}
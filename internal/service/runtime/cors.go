@@ -0,0 +1,45 @@
+package runtime
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/cors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+)
+
+// CORSMiddleware applies the CORS policy of cfg, and logs it once as it takes effect.
+func CORSMiddleware(cfg config.CORSConfig) (echo.MiddlewareFunc, error) {
+	origins, err := cors.ParseOrigins(cfg.AllowOrigins)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AllowCredentials && origins.AllowsAny() {
+		return nil, errors.New("CORS credentials cannot be allowed for any origin")
+	}
+
+	maxAge := int(cfg.MaxAge.Seconds())
+
+	log.Info().
+		Strs("allowOrigins", cfg.AllowOrigins).
+		Strs("allowMethods", cfg.AllowMethods).
+		Strs("allowHeaders", cfg.AllowHeaders).
+		Strs("exposeHeaders", cfg.ExposeHeaders).
+		Bool("allowCredentials", cfg.AllowCredentials).
+		Int("maxAge", maxAge).
+		Msg("CORS policy")
+
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOriginFunc: func(origin string) (bool, error) {
+			return origins.Allows(origin), nil
+		},
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           maxAge,
+	}), nil
+}
@@ -0,0 +1,88 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	t.Parallel()
+
+	cors, err := runtime.CORSMiddleware(config.CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowMethods:     []string{http.MethodGet},
+		AllowHeaders:     []string{"X-API-Key"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(cors)
+	e.GET("/Organization", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	preflight := func(origin string) http.Header {
+		req := httptest.NewRequest(http.MethodOptions, "/Organization", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Header()
+	}
+
+	allowed := preflight("https://app.example.com")
+	assert.Equal(t, "https://app.example.com", allowed.Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, http.MethodGet, allowed.Get(echo.HeaderAccessControlAllowMethods))
+	assert.Equal(t, "X-API-Key", allowed.Get(echo.HeaderAccessControlAllowHeaders))
+	assert.Equal(t, "true", allowed.Get(echo.HeaderAccessControlAllowCredentials))
+	assert.Equal(t, "600", allowed.Get(echo.HeaderAccessControlMaxAge))
+
+	denied := preflight("https://example.org")
+	assert.Empty(t, denied.Get(echo.HeaderAccessControlAllowOrigin))
+}
+
+func TestCORSMiddleware_AllowsBearerTokensByDefault(t *testing.T) {
+	t.Parallel()
+
+	var cfg config.CORSConfig
+	require.NoError(t, env.Parse(&cfg, env.Options{Environment: map[string]string{}}))
+	cfg.AllowOrigins = config.DefaultCORSOrigins("")
+	cors, err := runtime.CORSMiddleware(cfg)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(cors)
+	e.GET("/Organization", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/Organization", nil)
+	req.Header.Set(echo.HeaderOrigin, "http://localhost:3000")
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodGet)
+	req.Header.Set(echo.HeaderAccessControlRequestHeaders, echo.HeaderAuthorization)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "http://localhost:3000", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Contains(t, strings.Split(rec.Header().Get(echo.HeaderAccessControlAllowHeaders), ","), echo.HeaderAuthorization)
+}
+
+func TestCORSMiddleware_RejectsInvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, err := runtime.CORSMiddleware(config.CORSConfig{AllowOrigins: []string{"https://app.*.example.com"}})
+	require.Error(t, err)
+
+	_, err = runtime.CORSMiddleware(config.CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+	assert.Error(t, err)
+}
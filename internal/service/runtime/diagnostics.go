@@ -0,0 +1,18 @@
+package runtime
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+)
+
+const UpstreamDriftPath = "/diagnostics/upstream-drift"
+
+// UpstreamDriftHandler serves the schema drift found in sampled ODS API responses as JSON.
+func UpstreamDriftHandler(reporter common.UpstreamDriftReporter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, reporter.UpstreamDriftReport())
+	}
+}
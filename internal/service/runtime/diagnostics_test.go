@@ -0,0 +1,50 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+type stubDriftReporter common.UpstreamDriftReport
+
+func (s stubDriftReporter) UpstreamDriftReport() common.UpstreamDriftReport {
+	return common.UpstreamDriftReport(s)
+}
+
+func TestUpstreamDriftEndpoint(t *testing.T) {
+	t.Parallel()
+
+	reporter := stubDriftReporter{
+		SampleRate: 0.5,
+		Sampled:    4,
+		Findings: []common.UpstreamDriftFinding{
+			{Kind: common.DriftUnknownField, Operation: "get-single-organization", Path: "telecom", Count: 3},
+		},
+	}
+
+	e, err := runtime.NewHTTPServer(config.Config{APIKey: "secret"}, nil, reporter, newChecker(t), nil)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, runtime.UpstreamDriftPath, nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "the report is only served to API clients")
+
+	req := httptest.NewRequest(http.MethodGet, runtime.UpstreamDriftPath, nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var report common.UpstreamDriftReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, common.UpstreamDriftReport(reporter), report)
+}
@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
+)
+
+const (
+	CodeInvalidInput    = "INVALID_INPUT"
+	CodeNotFound        = "NOT_FOUND"
+	CodeUpstreamError   = "UPSTREAM_ERROR"
+	CodeUpstreamTimeout = "UPSTREAM_TIMEOUT"
+	CodeMappingError    = "MAPPING_ERROR"
+	CodeOverloaded      = "SERVICE_OVERLOADED"
+	CodeRateLimited     = "RATE_LIMITED"
+	CodeInternalError   = "INTERNAL_ERROR"
+)
+
+type errorMapping struct {
+	kind   error
+	status int
+	code   string
+}
+
+// order matters: the first kind found in the error chain wins.
+var errorMappings = []errorMapping{
+	{kind: apperrors.ErrInvalidInput, status: http.StatusBadRequest, code: CodeInvalidInput},
+	{kind: apperrors.ErrNotFound, status: http.StatusNotFound, code: CodeNotFound},
+	{kind: apperrors.ErrUpstreamTimeout, status: http.StatusGatewayTimeout, code: CodeUpstreamTimeout},
+	{kind: apperrors.ErrUpstreamUnavailable, status: http.StatusBadGateway, code: CodeUpstreamError},
+	{kind: apperrors.ErrMapping, status: http.StatusBadGateway, code: CodeMappingError},
+	{kind: apperrors.ErrOverloaded, status: http.StatusServiceUnavailable, code: CodeOverloaded},
+	{kind: apperrors.ErrRateLimited, status: http.StatusTooManyRequests, code: CodeRateLimited},
+}
+
+// HTTPErrorHandler renders every error returned by handlers and middlewares as the Error schema.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, body := errorResponse(err)
+	logger := elog.Ctx(c.Request().Context())
+	if appErr, ok := apperrors.From(err); ok && appErr.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+	var panicErr *recoveredPanic
+	switch {
+	case errors.As(err, &panicErr):
+		// already logged, with its stack, by RecoverMiddleware.
+	case status >= http.StatusInternalServerError:
+		logger.Err(err).Str(elog.ErrorTypeField, elog.ErrorType(err)).Int("status", status).Str("code", body.Code).
+			Msg("request failed")
+	default:
+		logger.Debug().Err(err).Int("status", status).Str("code", body.Code).Msg("request rejected")
+	}
+
+	var writeErr error
+	if c.Request().Method == http.MethodHead {
+		writeErr = c.NoContent(status)
+	} else {
+		writeErr = c.JSON(status, body)
+	}
+	if writeErr != nil {
+		logger.Err(writeErr).Msg("error writing error response")
+	}
+}
+
+func errorResponse(err error) (int, svcHTTP.Error) {
+	for _, m := range errorMappings {
+		if !errors.Is(err, m.kind) {
+			continue
+		}
+
+		body := svcHTTP.Error{
+			Code:    m.code,
+			Message: err.Error(),
+		}
+		if appErr, ok := apperrors.From(err); ok {
+			body.Message = appErr.Message
+			if len(appErr.Details) > 0 {
+				body.Details = &appErr.Details
+			}
+		}
+		return m.status, body
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code, svcHTTP.Error{
+			Code:    statusCode(httpErr.Code),
+			Message: fmt.Sprint(httpErr.Message),
+		}
+	}
+
+	return http.StatusInternalServerError, svcHTTP.Error{
+		Code:    CodeInternalError,
+		Message: "unexpected error",
+	}
+}
+
+// statusCode derives an error code for errors raised by echo itself, e.g. INVALID_INPUT or UNAUTHORIZED.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidInput
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusInternalServerError:
+		return CodeInternalError
+	}
+	return strings.ToUpper(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
@@ -0,0 +1,132 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+func handleError(t *testing.T, err error) (int, svcHTTP.Error) {
+	t.Helper()
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/organisations/X", nil), rec)
+
+	runtime.HTTPErrorHandler(err, c)
+
+	var body svcHTTP.Error
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	return rec.Code, body
+}
+
+func TestHTTPErrorHandler_AppErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{
+			name:   "not found",
+			err:    apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API"),
+			status: http.StatusNotFound,
+			code:   runtime.CodeNotFound,
+		},
+		{
+			name:   "invalid input",
+			err:    apperrors.New(apperrors.ErrInvalidInput, "ODS code is required"),
+			status: http.StatusBadRequest,
+			code:   runtime.CodeInvalidInput,
+		},
+		{
+			name:   "upstream unavailable",
+			err:    apperrors.Wrap(apperrors.ErrUpstreamUnavailable, errors.New("connection refused"), "error calling ODS"),
+			status: http.StatusBadGateway,
+			code:   runtime.CodeUpstreamError,
+		},
+		{
+			name:   "upstream timeout",
+			err:    apperrors.New(apperrors.ErrUpstreamTimeout, "ODS API timed out"),
+			status: http.StatusGatewayTimeout,
+			code:   runtime.CodeUpstreamTimeout,
+		},
+		{
+			name:   "mapping failure",
+			err:    apperrors.New(apperrors.ErrMapping, "invalid total in ODS API response"),
+			status: http.StatusBadGateway,
+			code:   runtime.CodeMappingError,
+		},
+		{
+			name:   "echo error",
+			err:    echo.NewHTTPError(http.StatusUnauthorized, "missing api key"),
+			status: http.StatusUnauthorized,
+			code:   "UNAUTHORIZED",
+		},
+		{
+			name:   "unexpected error",
+			err:    errors.New("boom"),
+			status: http.StatusInternalServerError,
+			code:   runtime.CodeInternalError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			status, body := handleError(t, tt.err)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, body.Code)
+			assert.NotEmpty(t, body.Message)
+		})
+	}
+}
+
+func TestHTTPErrorHandler_WrappedErrorKeepsDetails(t *testing.T) {
+	t.Parallel()
+
+	appErr := apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API").
+		WithDetail("upstreamStatus", http.StatusNotFound)
+
+	status, body := handleError(t, errors.Join(errors.New("error getting organisation from ODS API"), appErr))
+
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "organisation not found in ODS API", body.Message)
+	require.NotNil(t, body.Details)
+	assert.EqualValues(t, http.StatusNotFound, (*body.Details)["upstreamStatus"])
+}
+
+func TestHTTPErrorHandler_OverloadedSetsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/organisations/X", nil), rec)
+
+	runtime.HTTPErrorHandler(
+		apperrors.New(apperrors.ErrOverloaded, "too many requests queued for the ODS API").WithRetryAfter(1500*time.Millisecond),
+		c,
+	)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	var body svcHTTP.Error
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, runtime.CodeOverloaded, body.Code)
+}
@@ -0,0 +1,48 @@
+package runtime
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
+)
+
+const (
+	ReadinessPath     = "/readiness"
+	HealthDetailsPath = "/health/details"
+)
+
+// ReadinessHandler answers 200 while the service should receive traffic, and 503 when a critical dependency check
+// fails or the service is draining.
+func ReadinessHandler(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !checker.Ready(c.Request().Context()) {
+			return c.NoContent(http.StatusServiceUnavailable)
+		}
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// HealthDetailsHandler serves the status and latency of each dependency check as JSON, with the status code of
+// ReadinessHandler.
+func HealthDetailsHandler(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return healthReport(c, checker.Report(c.Request().Context()))
+	}
+}
+
+// HealthProbeHandler runs every check now, ignoring cached results, and serves the report like HealthDetailsHandler.
+func HealthProbeHandler(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return healthReport(c, checker.Probe(c.Request().Context()))
+	}
+}
+
+func healthReport(c echo.Context, report health.Report) error {
+	status := http.StatusOK
+	if report.Status != health.StatusPass && report.Status != health.StatusWarn {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, report)
+}
@@ -0,0 +1,86 @@
+package runtime_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to create a checker with a single critical check returning err.
+func newChecker(t *testing.T, err ...error) *health.Checker {
+	t.Helper()
+
+	checker, newErr := health.NewChecker(health.Check{
+		Name:     "ods_metadata",
+		Critical: true,
+		Run:      func(context.Context) error { return errors.Join(err...) },
+	})
+	require.NoError(t, newErr)
+
+	return checker
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	healthy := newChecker(t)
+	unhealthy := newChecker(t, errors.New("ODS API metadata returned 503"))
+	draining := newChecker(t)
+	draining.Drain()
+
+	for name, tc := range map[string]struct {
+		checker *health.Checker
+		status  int
+	}{
+		"healthy":   {checker: healthy, status: http.StatusOK},
+		"unhealthy": {checker: unhealthy, status: http.StatusServiceUnavailable},
+		"draining":  {checker: draining, status: http.StatusServiceUnavailable},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			e, err := runtime.NewHTTPServer(config.Config{APIKey: "secret"}, nil, stubDriftReporter{}, tc.checker, nil)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, runtime.ReadinessPath, nil))
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+}
+
+func TestHealthDetails(t *testing.T) {
+	t.Parallel()
+
+	e, err := runtime.NewHTTPServer(config.Config{APIKey: "secret"}, nil, stubDriftReporter{},
+		newChecker(t, errors.New("ODS API metadata returned 503")), nil)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, runtime.HealthDetailsPath, nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "details are only served to API clients")
+
+	req := httptest.NewRequest(http.MethodGet, runtime.HealthDetailsPath, nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusFail, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "ods_metadata", report.Checks[0].Name)
+	assert.True(t, report.Checks[0].Critical)
+	assert.Equal(t, "ODS API metadata returned 503", report.Checks[0].Error)
+}
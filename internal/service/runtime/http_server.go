@@ -1,30 +1,93 @@
 package runtime
 
 import (
-	"crypto/subtle"
+	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog/log"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
 	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http/server"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
 )
 
-func NewHTTPServer(config config.Config, server *server.ODSGatewayServer) *echo.Echo {
+// openPaths are served without authentication.
+var openPaths = []string{"/liveness", ReadinessPath}
+
+// routeScopes gives the scope required of each route of the API, keyed by route template.
+var routeScopes = map[string]string{
+	"/organisations":          auth.ScopeOrganisationsRead,
+	"/organisations/:odsCode": auth.ScopeOrganisationsRead,
+	UpstreamDriftPath:         auth.ScopeDiagnosticsRead,
+	HealthDetailsPath:         auth.ScopeDiagnosticsRead,
+	UsagePath:                 auth.ScopeDiagnosticsRead,
+}
+
+func NewHTTPServer(
+	config config.Config,
+	server *server.ODSGatewayServer,
+	drift common.UpstreamDriftReporter,
+	checker *health.Checker,
+	reloader *Reloader,
+) (*echo.Echo, error) {
+	spec, err := svcHTTP.GetSwagger()
+	if err != nil {
+		return nil, fmt.Errorf("error loading OpenAPI spec: %w", err)
+	}
+
+	requestValidator, err := RequestValidatorMiddleware(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	responseValidator, err := ResponseValidatorMiddleware(spec, config.ResponseValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	corsMiddleware, err := CORSMiddleware(config.CORSConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// the CORS policy, the API keys and the default limits are swapped when the configuration is reloaded.
+	cors := NewSwappableMiddleware(corsMiddleware)
+	tokens, err := newTokenVerifier(config.JWTConfig)
+	if err != nil {
+		return nil, err
+	}
+	authMiddleware, err := newAuthMiddleware(config, tokens)
+	if err != nil {
+		return nil, err
+	}
+	authn := NewSwappableMiddleware(authMiddleware)
+
+	// what consumers used of their limits is kept across reloads.
+	limiter := NewConsumerLimiter(config.ConsumerLimits.Limits())
+	usage := NewUsageTracker()
+
+	if reloader != nil {
+		reloader.OnReload(reloadMiddlewares(cors, authn, tokens, limiter))
+	}
+
 	e := echo.New()
 
 	e.HideBanner = true
 	e.HidePort = true
 
-	e.HTTPErrorHandler = func(err error, c echo.Context) {
-		e.DefaultHTTPErrorHandler(err, c)
-	}
+	e.HTTPErrorHandler = HTTPErrorHandler
 
+	e.Use(MetricsMiddleware())
 	e.Use(middleware.RequestID())
-	e.Use(middleware.Recover())
+	e.Use(TracingMiddleware())
+	e.Use(RequestLoggerMiddleware(log.Logger))
+	e.Use(AccessLogMiddleware(AccessLogConfig{RedactQuery: config.AccessLogRedactQuery}))
+	e.Use(RecoverMiddleware())
 	e.Use(middleware.BodyLimit("2M"))
 
 	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
@@ -34,104 +97,88 @@ func NewHTTPServer(config config.Config, server *server.ODSGatewayServer) *echo.
 		HSTSMaxAge:         31536000,
 	}))
 
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: getOrigins(config.Account),
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
-		AllowHeaders: []string{"Content-Type", "X-API-Key", "x-correlation-id"},
-	}))
+	e.Use(cors.Middleware())
 
 	e.GET("/liveness", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
-	e.GET("/readiness", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	e.GET(ReadinessPath, ReadinessHandler(checker))
 
 	api := e.Group("")
-	api.Use(APIKeyMiddleware(APIKeyConfig{
-		Header:   "X-API-Key",
-		Expected: config.APIKey,
-		Skips:    []string{"/liveness", "/readiness"},
-	}))
+	api.Use(authn.Middleware())
+	api.Use(ScopeMiddleware(routeScopes))
+	api.Use(UsageMiddleware(usage))
+	api.Use(RateLimitMiddleware(limiter))
+	api.Use(PriorityMiddleware())
+	api.Use(responseValidator)
+	api.Use(requestValidator)
 
 	e.Server.ReadTimeout = config.RequestTimeout
 	e.Server.WriteTimeout = config.RequestTimeout
 	e.Server.IdleTimeout = config.RequestTimeout
 
 	svcHTTP.RegisterHandlers(api, server)
+	api.GET(UpstreamDriftPath, UpstreamDriftHandler(drift))
+	api.GET(HealthDetailsPath, HealthDetailsHandler(checker))
+	api.GET(UsagePath, UsageHandler(usage, limiter))
 
-	return e
-}
+	if err := CheckScopes(e.Routes(), routeScopes, openPaths...); err != nil {
+		return nil, err
+	}
 
-type APIKeyConfig struct {
-	Header   string
-	Expected string
-	Skips    []string
-	Allowed  []string
+	return e, nil
 }
 
-func APIKeyMiddleware(c APIKeyConfig) echo.MiddlewareFunc {
-	header := c.Header
-	if header == "" {
-		header = "X-API-Key"
-	}
-
-	allowed := make([]string, 0, 1+len(c.Allowed))
-	if c.Expected != "" {
-		allowed = append(allowed, c.Expected)
+func reloadMiddlewares(
+	cors, authn *SwappableMiddleware,
+	tokens *auth.TokenVerifier,
+	limiter *ConsumerLimiter,
+) Applier {
+	return func(next config.Config) (func(), error) {
+		corsMiddleware, err := CORSMiddleware(next.CORSConfig)
+		if err != nil {
+			return nil, err
+		}
+		authMiddleware, err := newAuthMiddleware(next, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			cors.Store(corsMiddleware)
+			authn.Store(authMiddleware)
+			limiter.SetDefaults(next.ConsumerLimits.Limits())
+		}, nil
 	}
-	allowed = append(allowed, c.Allowed...)
+}
 
-	skips := make(map[string]struct{}, len(c.Skips))
-	for _, p := range c.Skips {
-		skips[p] = struct{}{}
+func newAuthMiddleware(cfg config.Config, tokens *auth.TokenVerifier) (echo.MiddlewareFunc, error) {
+	keys, err := auth.NewKeyStore(cfg.Consumers())
+	if err != nil {
+		return nil, err
 	}
 
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(ctx echo.Context) error {
-			if _, ok := skips[ctx.Request().URL.Path]; ok {
-				return next(ctx)
-			}
-
-			got := strings.TrimSpace(ctx.Request().Header.Get(header))
-			if got == "" || len(allowed) == 0 {
-				return echo.NewHTTPError(http.StatusUnauthorized, "missing api key")
-			}
-
-			for _, want := range allowed {
-				if want == "" {
-					continue
-				}
-				if subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1 {
-					return next(ctx)
-				}
-			}
-
-			return echo.NewHTTPError(http.StatusUnauthorized, "invalid api key")
-		}
-	}
+	return AuthMiddleware(AuthConfig{
+		Header:  "X-API-Key",
+		Keys:    keys,
+		Tokens:  tokens,
+		Methods: cfg.RouteAuthMethods(),
+		Skips:   openPaths,
+	}), nil
 }
 
-func getOrigins(account string) []string {
-	origins := []string{
-		"http://localhost:*",
+// newTokenVerifier returns nil when bearer tokens are not accepted.
+func newTokenVerifier(cfg config.JWTConfig) (*auth.TokenVerifier, error) {
+	if !cfg.Enabled() {
+		return nil, nil
 	}
-	if account == "dev" {
-		origins = append(origins, []string{
-			"https://*.elevate-dev.cleosystems.com",
-			"https://*.elevate-dev.cleosystems.com:*",
-			"https://integration.elevate-dev.cleosystems.com",
-		}...)
-	}
-	if account == "staging" {
-		origins = append(origins, []string{
-			"https://*.elevate-stg.cleosystems.com",
-			"https://*.elevate-stg.cleosystems.com:*",
-			"https://integration.elevate-stg.cleosystems.com",
-		}...)
-	}
-	if account == "production" {
-		origins = append(origins, []string{
-			"https://*.elevate.cleosystems.com",
-			"https://*.elevate.cleosystems.com:*",
-			"https://integration.elevate.cleosystems.com",
-		}...)
-	}
-	return origins
+
+	return auth.NewTokenVerifier(auth.TokenConfig{
+		JWKSURL:       cfg.JWKSURL,
+		JWKSFile:      cfg.JWKSFile,
+		JWKSCacheTTL:  cfg.JWKSCacheTTL,
+		Issuer:        cfg.Issuer,
+		Audience:      cfg.Audience,
+		Leeway:        cfg.Leeway,
+		ConsumerClaim: cfg.ConsumerClaim,
+		ScopesClaim:   cfg.ScopesClaim,
+		ScopeMap:      cfg.Scopes(),
+	})
 }
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+)
+
+// RedactAll redacts the values of every query parameter in the access log.
+const RedactAll = "*"
+
+const redacted = "REDACTED"
+
+// RequestLoggerMiddleware attaches a child of base to the request context, carrying the request ID, correlation ID,
+// trace ID and route of the request. Code serving the request logs through elog.Ctx to include them.
+func RequestLoggerMiddleware(base zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			fields := base.With().Str("route", route)
+			if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+				fields = fields.Str("requestId", id)
+			}
+			if id := req.Header.Get(tracing.CorrelationIDHeader); id != "" {
+				fields = fields.Str("correlationId", id)
+			}
+			if span := trace.SpanContextFromContext(req.Context()); span.HasTraceID() {
+				fields = fields.Str("traceId", span.TraceID().String())
+			}
+
+			logger := fields.Logger()
+			c.SetRequest(req.WithContext(logger.WithContext(req.Context())))
+
+			return next(c)
+		}
+	}
+}
+
+// AccessLogConfig configures AccessLogMiddleware.
+type AccessLogConfig struct {
+	// RedactQuery lists the query parameters whose values are replaced in the access log, or RedactAll.
+	RedactQuery []string
+}
+
+// AccessLogMiddleware logs one line per request with its outcome, latency and the calls it made to the ODS API.
+// It must run after RequestLoggerMiddleware.
+func AccessLogMiddleware(cfg AccessLogConfig) echo.MiddlewareFunc {
+	redact := make(map[string]struct{}, len(cfg.RedactQuery))
+	for _, name := range cfg.RedactQuery {
+		redact[strings.TrimSpace(name)] = struct{}{}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			ctx, upstream := common.WithUpstreamStats(c.Request().Context())
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			// errors are rendered here so that the status logged is the one sent.
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			calls, upstreamLatency := upstream.Calls()
+
+			event := elog.Ctx(ctx).Info()
+			if res.Status >= http.StatusInternalServerError {
+				event = elog.Ctx(ctx).Warn()
+			}
+			event.
+				Str("method", req.Method).
+				Str("path", req.URL.Path).
+				Str("query", redactQuery(req.URL.Query(), redact)).
+				Int("status", res.Status).
+				Int64("bytesOut", res.Size).
+				Dur("latency", time.Since(start)).
+				Int("upstreamCalls", calls).
+				Dur("upstreamLatency", upstreamLatency).
+				Msg("request served")
+
+			return nil
+		}
+	}
+}
+
+// redactQuery encodes query with the values of the redacted parameters replaced, in a stable order.
+func redactQuery(query url.Values, redact map[string]struct{}) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	_, all := redact[RedactAll]
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		_, hide := redact[name]
+		for _, value := range query[name] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			if all || hide {
+				value = redacted
+			}
+			b.WriteString(url.QueryEscape(name))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(value))
+		}
+	}
+
+	return b.String()
+}
+
+// withLogField adds a field to the request-scoped logger of c, if it has one.
+func withLogField(c echo.Context, key, value string) {
+	zerolog.Ctx(c.Request().Context()).UpdateContext(func(l zerolog.Context) zerolog.Context {
+		return l.Str(key, value)
+	})
+}
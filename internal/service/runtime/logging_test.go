@@ -0,0 +1,109 @@
+package runtime_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to create an echo instance logging into the returned buffer, with the API key "secret".
+func newLoggedEcho(t *testing.T, redact []string, handler echo.HandlerFunc) (*echo.Echo, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(middleware.RequestID())
+	e.Use(runtime.RequestLoggerMiddleware(zerolog.New(&buf)))
+	e.Use(runtime.AccessLogMiddleware(runtime.AccessLogConfig{RedactQuery: redact}))
+
+	api := e.Group("")
+	api.Use(runtime.AuthMiddleware(runtime.AuthConfig{Keys: newKeyStore(t, "secret")}))
+	api.GET("/organisations", handler)
+
+	return e, &buf
+}
+
+// helper to decode every JSON log line written to buf.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestLogger_AttachesRequestFields(t *testing.T) {
+	t.Parallel()
+
+	e, buf := newLoggedEcho(t, []string{"name"}, func(c echo.Context) error {
+		ctx := c.Request().Context()
+		common.RecordUpstreamCall(ctx, 20*time.Millisecond)
+		common.RecordUpstreamCall(ctx, 30*time.Millisecond)
+		elog.Ctx(ctx).Info().Msg("handling")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/organisations?name=Leeds&page=1", nil)
+	req.Header.Set("X-API-Key", "secret")
+	req.Header.Set("x-correlation-id", "corr-123")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 2)
+
+	for _, line := range lines {
+		assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), line["requestId"])
+		assert.Equal(t, "corr-123", line["correlationId"])
+		assert.Equal(t, "/organisations", line["route"])
+		assert.Equal(t, "tester", line["consumer"])
+		assert.NotEmpty(t, line["apiKeyId"])
+		assert.NotContains(t, line["apiKeyId"], "secret")
+	}
+
+	access := lines[1]
+	assert.Equal(t, "request served", access["message"])
+	assert.EqualValues(t, http.StatusOK, access["status"])
+	assert.Equal(t, "name=REDACTED&page=1", access["query"])
+	assert.EqualValues(t, 2, access["upstreamCalls"])
+	assert.EqualValues(t, 50, access["upstreamLatency"])
+}
+
+func TestAccessLog_RecordsRejectedRequests(t *testing.T) {
+	t.Parallel()
+
+	e, buf := newLoggedEcho(t, []string{runtime.RedactAll}, func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/organisations?name=Leeds&page=1", nil))
+
+	lines := logLines(t, buf)
+	access := lines[len(lines)-1]
+	assert.Equal(t, "request served", access["message"])
+	assert.EqualValues(t, http.StatusUnauthorized, access["status"])
+	assert.Equal(t, "name=REDACTED&page=REDACTED", access["query"])
+	assert.EqualValues(t, 0, access["upstreamCalls"])
+	assert.NotContains(t, access, "apiKeyId")
+}
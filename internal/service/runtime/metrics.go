@@ -0,0 +1,77 @@
+package runtime
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+)
+
+// unmatchedRoute labels requests which matched no route, so that probing random paths cannot explode the label set.
+const unmatchedRoute = "unmatched"
+
+// otherConsumers stands for the consumers beyond those the usage tracker keeps apart, and
+// tokenConsumers labels the metrics of every consumer of a bearer token, whose names are not known in advance: it
+// starts with auth.TokenConsumerPrefix, so that it cannot be taken for a consumer of the key store.
+const (
+	otherConsumers = "(other)"
+	tokenConsumers = auth.TokenConsumerPrefix + "*"
+)
+
+// consumerLabel labels the metrics of consumer: by name for the consumers of the key store, which are bounded by its
+// configuration, and as tokenConsumers for the others.
+func consumerLabel(consumer auth.Consumer) string {
+	if strings.HasPrefix(consumer.Name, auth.TokenConsumerPrefix) {
+		return tokenConsumers
+	}
+	return consumer.Name
+}
+
+// MetricsMiddleware counts requests and observes their latency by method, route template and status code, and
+// tracks how many requests are in flight. Requests of authenticated consumers are also counted by consumer. It
+// renders errors itself so that the status code recorded is the one sent.
+func MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			metrics.HTTPRequestsInFlight.Inc()
+			defer metrics.HTTPRequestsInFlight.Dec()
+
+			start := time.Now()
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			method := c.Request().Method
+			status := strconv.Itoa(c.Response().Status)
+
+			metrics.HTTPRequestsTotal.WithLabelValues(method, route, status).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+			if consumer, ok := auth.ConsumerFromContext(c.Request().Context()); ok {
+				metrics.ConsumerRequestsTotal.WithLabelValues(consumerLabel(consumer), route, status).Inc()
+			}
+
+			return nil
+		}
+	}
+}
+
+// NewMetricsServer serves /metrics on its own listener, so that it is never exposed alongside the API.
+func NewMetricsServer() *echo.Echo {
+	e := echo.New()
+
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.Use(RecoverMiddleware())
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+
+	return e
+}
@@ -0,0 +1,70 @@
+package runtime_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to scrape the metrics endpoint of the gateway.
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	runtime.NewMetricsServer().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+// helper to read the value of a series from scraped metrics, 0 when it is not there yet.
+func scrapedValue(t *testing.T, scraped, series string) float64 {
+	t.Helper()
+
+	for _, line := range strings.Split(scraped, "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return v
+		}
+	}
+	return 0
+}
+
+func TestMetricsMiddleware_CountsByRouteAndStatus(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.MetricsMiddleware())
+	e.GET("/metrics-test/:odsCode", func(c echo.Context) error {
+		if c.Param("odsCode") == "missing" {
+			return apperrors.New(apperrors.ErrNotFound, "organisation not found")
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, target := range []string{"/metrics-test/RR8", "/metrics-test/RAE", "/metrics-test/missing", "/metrics-test-unknown"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	scraped := scrapeMetrics(t)
+	assert.Contains(t, scraped, `ods_gateway_http_requests_total{method="GET",route="/metrics-test/:odsCode",status="200"} 2`)
+	assert.Contains(t, scraped, `ods_gateway_http_requests_total{method="GET",route="/metrics-test/:odsCode",status="404"} 1`)
+	assert.Contains(t, scraped, `ods_gateway_http_request_duration_seconds_count{method="GET",route="/metrics-test/:odsCode",status="200"} 2`)
+	assert.Contains(t, scraped, `route="unmatched",status="404"}`)
+	assert.Contains(t, scraped, "ods_gateway_http_requests_in_flight")
+	assert.Contains(t, scraped, "go_goroutines")
+}
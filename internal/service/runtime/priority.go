@@ -0,0 +1,28 @@
+package runtime
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
+)
+
+const (
+	PriorityHeader     = "X-Request-Priority"
+	PriorityBackground = "background"
+)
+
+// PriorityMiddleware lets bulk consumers send "X-Request-Priority: background" so that their calls to the
+// ODS API queue behind interactive lookups. Requests without the header are treated as interactive.
+func PriorityMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if strings.EqualFold(strings.TrimSpace(c.Request().Header.Get(PriorityHeader)), PriorityBackground) {
+				req := c.Request()
+				c.SetRequest(req.WithContext(common.WithPriority(req.Context(), common.PriorityBackground)))
+			}
+			return next(c)
+		}
+	}
+}
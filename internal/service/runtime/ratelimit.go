@@ -0,0 +1,268 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+)
+
+// Headers describing the limits of a consumer, after the IETF RateLimit header fields draft.
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// Limits exceeded by rejected requests. LimitConsumers rejects the requests of a new consumer while as many
+// consumers as the limiter tracks are in the middle of their limits.
+const (
+	LimitRate      = "rate"
+	LimitQuota     = "quota"
+	LimitConsumers = "consumers"
+)
+
+const (
+	day = 24 * time.Hour
+	// maxTrackedConsumers bounds the consumers whose state is kept, so that memory does not grow with every subject
+	// of a token ever seen.
+	maxTrackedConsumers = 10000
+)
+
+// ConsumerLimiter enforces the rate limit and the daily quota of each consumer. Consumers without limits of their
+// own get the default limits. The state of each consumer is kept in memory, so each instance enforces the limits on
+// its own. Idle consumers are forgotten once maxTrackedConsumers are tracked; while none is idle, the requests of
+// new consumers are rejected, rather than limited together with those of other consumers.
+type ConsumerLimiter struct {
+	mu        sync.Mutex
+	defaults  auth.Limits
+	consumers map[string]*consumerLimiter
+}
+
+type consumerLimiter struct {
+	limits auth.Limits
+	rate   *rate.Limiter
+	// day is the start of the day used counts the requests of.
+	day  time.Time
+	used int
+}
+
+// RateLimitDecision is the outcome of a request against the limits of its consumer.
+type RateLimitDecision struct {
+	Allowed bool
+	// Exceeded is the limit exceeded, LimitRate or LimitQuota, when the request is not allowed.
+	Exceeded   string
+	RetryAfter time.Duration
+
+	// Policy lists every limit of the consumer, and is empty when the consumer is unlimited. Limit, Remaining and
+	// Reset describe the limit closest to being exceeded.
+	Policy    string
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+func NewConsumerLimiter(defaults auth.Limits) *ConsumerLimiter {
+	return &ConsumerLimiter{defaults: defaults, consumers: make(map[string]*consumerLimiter)}
+}
+
+// SetDefaults changes the limits of consumers without limits of their own, keeping what they used so far.
+func (l *ConsumerLimiter) SetDefaults(defaults auth.Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaults = defaults
+}
+
+// Allow counts a request of consumer at now against its limits. A request rejected by either limit counts against
+// neither.
+func (l *ConsumerLimiter) Allow(consumer auth.Consumer, now time.Time) RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limits := l.defaults
+	if consumer.Limits != nil {
+		limits = *consumer.Limits
+	}
+
+	c, ok := l.consumer(consumer.Name, limits, now)
+	if !ok {
+		return RateLimitDecision{Exceeded: LimitConsumers, RetryAfter: time.Second}
+	}
+	if today := now.UTC().Truncate(day); !c.day.Equal(today) {
+		c.day, c.used = today, 0
+	}
+	untilTomorrow := c.day.Add(day).Sub(now)
+
+	if limits.DailyQuota > 0 && c.used >= limits.DailyQuota {
+		return RateLimitDecision{
+			Exceeded:   LimitQuota,
+			RetryAfter: untilTomorrow,
+			Policy:     policy(limits),
+			Limit:      limits.DailyQuota,
+			Reset:      untilTomorrow,
+		}
+	}
+
+	if c.rate != nil {
+		if !c.rate.AllowN(now, 1) {
+			// the wait for a single token.
+			wait := time.Duration((1 - c.rate.TokensAt(now)) / limits.RateLimit * float64(time.Second))
+			return RateLimitDecision{
+				Exceeded:   LimitRate,
+				RetryAfter: wait,
+				Policy:     policy(limits),
+				Limit:      limits.RateBurst,
+				Reset:      wait,
+			}
+		}
+	}
+	c.used++
+
+	decision := RateLimitDecision{Allowed: true, Policy: policy(limits)}
+	if limits.DailyQuota > 0 {
+		decision.Limit, decision.Remaining, decision.Reset = limits.DailyQuota, limits.DailyQuota-c.used, untilTomorrow
+	}
+	if c.rate != nil {
+		tokens := c.rate.TokensAt(now)
+		if decision.Limit == 0 || int(tokens) < decision.Remaining {
+			refill := time.Duration((float64(limits.RateBurst) - tokens) / limits.RateLimit * float64(time.Second))
+			decision.Limit, decision.Remaining, decision.Reset = limits.RateBurst, int(tokens), refill
+		}
+	}
+	return decision
+}
+
+// consumer returns the state of the consumer name, updated to limits, or false when the consumer is new and no more
+// consumers can be tracked.
+func (l *ConsumerLimiter) consumer(name string, limits auth.Limits, now time.Time) (*consumerLimiter, bool) {
+	c, ok := l.consumers[name]
+	if !ok && len(l.consumers) >= maxTrackedConsumers {
+		l.forgetIdle(now)
+		if len(l.consumers) >= maxTrackedConsumers {
+			return nil, false
+		}
+	}
+	if !ok {
+		c = &consumerLimiter{}
+		l.consumers[name] = c
+	}
+	if ok && c.limits == limits {
+		return c, true
+	}
+
+	c.limits = limits
+	switch {
+	case limits.RateLimit <= 0:
+		c.rate = nil
+	case c.rate == nil:
+		c.rate = rate.NewLimiter(rate.Limit(limits.RateLimit), limits.RateBurst)
+	default:
+		c.rate.SetLimitAt(now, rate.Limit(limits.RateLimit))
+		c.rate.SetBurstAt(now, limits.RateBurst)
+	}
+	return c, true
+}
+
+// forgetIdle forgets the consumers whose state is what a new one starts with: a full bucket, and no daily quota or
+// nothing used of the quota of the day of now.
+func (l *ConsumerLimiter) forgetIdle(now time.Time) {
+	today := now.UTC().Truncate(day)
+	for name, c := range l.consumers {
+		full := c.rate == nil || c.rate.TokensAt(now) >= float64(c.rate.Burst())
+		if full && (c.limits.DailyQuota == 0 || c.used == 0 || !c.day.Equal(today)) {
+			delete(l.consumers, name)
+		}
+	}
+}
+
+// QuotaUsage is what a consumer used of its daily quota.
+type QuotaUsage struct {
+	Consumer   string    `json:"consumer"`
+	Day        time.Time `json:"day"`
+	Used       int       `json:"used"`
+	DailyQuota int       `json:"dailyQuota,omitempty"`
+}
+
+// QuotaUsage returns what each consumer used of its daily quota on the day of now, by consumer name.
+func (l *ConsumerLimiter) QuotaUsage(now time.Time) []QuotaUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	today := now.UTC().Truncate(day)
+	usage := make([]QuotaUsage, 0, len(l.consumers))
+	for name, c := range l.consumers {
+		used := c.used
+		if !c.day.Equal(today) {
+			used = 0
+		}
+		usage = append(usage, QuotaUsage{Consumer: name, Day: today, Used: used, DailyQuota: c.limits.DailyQuota})
+	}
+	slices.SortFunc(usage, func(a, b QuotaUsage) int { return strings.Compare(a.Consumer, b.Consumer) })
+	return usage
+}
+
+// policy describes limits as a RateLimit-Policy header: the quota and its window in seconds, for each limit.
+func policy(limits auth.Limits) string {
+	var policies []string
+	if limits.RateLimit > 0 {
+		window := float64(limits.RateBurst) / limits.RateLimit
+		policies = append(policies, fmt.Sprintf("%d;w=%d", limits.RateBurst, int(math.Max(1, math.Ceil(window)))))
+	}
+	if limits.DailyQuota > 0 {
+		policies = append(policies, fmt.Sprintf("%d;w=%d", limits.DailyQuota, int(day.Seconds())))
+	}
+	return strings.Join(policies, ", ")
+}
+
+// RateLimitMiddleware rejects the requests of consumers over their limits with 429 and Retry-After, and describes
+// the limits of every consumer with limits in the RateLimit headers. Requests without a consumer are not limited.
+func RateLimitMiddleware(limiter *ConsumerLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			consumer, ok := auth.ConsumerFromContext(c.Request().Context())
+			if !ok {
+				return next(c)
+			}
+
+			decision := limiter.Allow(consumer, time.Now())
+			if decision.Policy != "" {
+				header := c.Response().Header()
+				header.Set(HeaderRateLimitPolicy, decision.Policy)
+				header.Set(HeaderRateLimitLimit, strconv.Itoa(decision.Limit))
+				header.Set(HeaderRateLimitRemaining, strconv.Itoa(decision.Remaining))
+				header.Set(HeaderRateLimitReset, strconv.Itoa(int(math.Ceil(decision.Reset.Seconds()))))
+			}
+
+			if !decision.Allowed {
+				metrics.ConsumerRateLimitedTotal.WithLabelValues(consumerLabel(consumer), decision.Exceeded).Inc()
+				if decision.Exceeded == LimitConsumers {
+					elog.Ctx(c.Request().Context()).Warn().Str("consumer", consumer.Name).Int("tracked", maxTrackedConsumers).
+						Msg("request of a new consumer rejected: too many consumers are being limited")
+					return apperrors.New(apperrors.ErrOverloaded, "too many consumers are being limited").
+						WithRetryAfter(decision.RetryAfter)
+				}
+				message := "rate limit exceeded"
+				if decision.Exceeded == LimitQuota {
+					message = "daily quota exceeded"
+				}
+				return apperrors.New(apperrors.ErrRateLimited, message).
+					WithDetail("limit", decision.Exceeded).
+					WithRetryAfter(decision.RetryAfter)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package runtime_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+func TestConsumerLimiter_EnforcesRateLimitAndDailyQuota(t *testing.T) {
+	t.Parallel()
+
+	limiter := runtime.NewConsumerLimiter(auth.Limits{})
+	consumer := auth.Consumer{Name: "bulk-export", Limits: &auth.Limits{RateLimit: 1, RateBurst: 2, DailyQuota: 3}}
+	now := time.Date(2026, 10, 17, 23, 59, 0, 0, time.UTC)
+
+	decision := limiter.Allow(consumer, now)
+	require.True(t, decision.Allowed)
+	assert.Equal(t, "2;w=2, 3;w=86400", decision.Policy)
+	assert.Equal(t, 2, decision.Limit, "the burst is closer to being exceeded than the quota")
+	assert.Equal(t, 1, decision.Remaining)
+
+	require.True(t, limiter.Allow(consumer, now).Allowed)
+
+	decision = limiter.Allow(consumer, now)
+	require.False(t, decision.Allowed)
+	assert.Equal(t, runtime.LimitRate, decision.Exceeded)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+
+	now = now.Add(2 * time.Second)
+	decision = limiter.Allow(consumer, now)
+	require.True(t, decision.Allowed)
+	assert.Equal(t, 3, decision.Limit, "the quota is closer to being exceeded than the burst")
+	assert.Equal(t, 0, decision.Remaining)
+
+	now = now.Add(2 * time.Second)
+	decision = limiter.Allow(consumer, now)
+	require.False(t, decision.Allowed)
+	assert.Equal(t, runtime.LimitQuota, decision.Exceeded)
+	assert.Equal(t, 56*time.Second, decision.RetryAfter, "the quota is reset at midnight UTC")
+	assert.Equal(t, []runtime.QuotaUsage{
+		{Consumer: "bulk-export", Day: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), Used: 3, DailyQuota: 3},
+	}, limiter.QuotaUsage(now))
+
+	now = now.Add(time.Minute)
+	assert.True(t, limiter.Allow(consumer, now).Allowed)
+
+	// consumers without limits of their own get the defaults, which can be changed.
+	other := auth.Consumer{Name: "reporting-service"}
+	assert.Empty(t, limiter.Allow(other, now).Policy)
+	// what was used before counts against the new defaults.
+	limiter.SetDefaults(auth.Limits{DailyQuota: 2})
+	assert.True(t, limiter.Allow(other, now).Allowed)
+	assert.False(t, limiter.Allow(other, now).Allowed)
+}
+
+func TestConsumerLimiter_BoundsTheConsumersTracked(t *testing.T) {
+	t.Parallel()
+
+	limiter := runtime.NewConsumerLimiter(auth.Limits{RateLimit: 1, RateBurst: 1})
+	quota := &auth.Limits{DailyQuota: 10}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for i := range 5000 {
+		require.True(t, limiter.Allow(auth.Consumer{Name: fmt.Sprintf("jwt:subject-%d", i)}, now).Allowed)
+		require.True(t, limiter.Allow(auth.Consumer{Name: fmt.Sprintf("quota-%d", i), Limits: quota}, now).Allowed)
+	}
+
+	// none of them is idle: the requests of new consumers are rejected rather than growing the limiter, or being
+	// limited together.
+	decision := limiter.Allow(auth.Consumer{Name: "jwt:new-1"}, now)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, runtime.LimitConsumers, decision.Exceeded)
+	assert.Len(t, limiter.QuotaUsage(now), 10000)
+
+	// once their buckets are full, consumers without a quota are forgotten to track new consumers.
+	now = now.Add(time.Second)
+	assert.True(t, limiter.Allow(auth.Consumer{Name: "jwt:new-1"}, now).Allowed)
+	assert.Len(t, limiter.QuotaUsage(now), 5001)
+
+	// those with a quota are forgotten the next day, once the limiter is full again.
+	now = now.Add(24 * time.Hour)
+	for i := range 5000 {
+		require.True(t, limiter.Allow(auth.Consumer{Name: fmt.Sprintf("jwt:subject-%d", i)}, now).Allowed)
+	}
+	assert.True(t, limiter.Allow(auth.Consumer{Name: "jwt:new-2"}, now).Allowed)
+	usage := limiter.QuotaUsage(now)
+	assert.Len(t, usage, 5001)
+	assert.NotContains(t, usage, runtime.QuotaUsage{Consumer: "quota-0", Day: now.Truncate(24 * time.Hour), DailyQuota: 10})
+}
+
+func TestRateLimitMiddleware_RejectsWith429(t *testing.T) {
+	t.Parallel()
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{{
+		Name:   "noisy-integration",
+		Scopes: []string{auth.ScopeAll},
+		Keys:   []auth.KeyConfig{{SHA256: auth.HashKey("key")}},
+		Limits: &auth.Limits{RateLimit: 0.5, RateBurst: 1},
+	}})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.AuthMiddleware(runtime.AuthConfig{Keys: store}))
+	e.Use(runtime.RateLimitMiddleware(runtime.NewConsumerLimiter(auth.Limits{})))
+	e.GET("/limited", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("X-API-Key", "key")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve()
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1;w=2", rec.Header().Get(runtime.HeaderRateLimitPolicy))
+	assert.Equal(t, "1", rec.Header().Get(runtime.HeaderRateLimitLimit))
+	assert.Equal(t, "0", rec.Header().Get(runtime.HeaderRateLimitRemaining))
+	assert.Equal(t, "2", rec.Header().Get(runtime.HeaderRateLimitReset))
+
+	rec = serve()
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get(runtime.HeaderRateLimitRemaining))
+	assert.Contains(t, rec.Body.String(), runtime.CodeRateLimited)
+
+	assert.Contains(t, scrapeMetrics(t), `ods_gateway_consumer_rate_limited_total{consumer="noisy-integration",limit="rate"}`)
+}
@@ -0,0 +1,35 @@
+package runtime
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+)
+
+// recoveredPanic marks an error recovered from a panic, which RecoverMiddleware has logged already.
+type recoveredPanic struct {
+	error
+}
+
+func (p *recoveredPanic) Unwrap() error {
+	return p.error
+}
+
+// RecoverMiddleware recovers from panics in the rest of the chain and logs them as errors, with the stack of the
+// panicking goroutine and the fields of the request-scoped logger, so that they are reported. The panic is then
+// rendered as an internal error by HTTPErrorHandler.
+func RecoverMiddleware() echo.MiddlewareFunc {
+	return middleware.RecoverWithConfig(middleware.RecoverConfig{
+		DisableStackAll: true,
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			elog.Ctx(c.Request().Context()).Error().
+				Err(err).
+				Str(elog.ErrorTypeField, "panic: "+elog.ErrorType(err)).
+				Bytes(elog.StackField, stack).
+				Msg("recovered from panic")
+
+			return &recoveredPanic{error: err}
+		},
+	})
+}
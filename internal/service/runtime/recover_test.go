@@ -0,0 +1,46 @@
+package runtime_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+func TestRecoverMiddleware_LogsPanicOnce(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(middleware.RequestID())
+	e.Use(runtime.RequestLoggerMiddleware(zerolog.New(&buf)))
+	e.Use(runtime.RecoverMiddleware())
+	e.GET("/Organization/:id", func(echo.Context) error { panic("nil map") })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/Organization/X26", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), runtime.CodeInternalError)
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "error", lines[0]["level"])
+	assert.Equal(t, "recovered from panic", lines[0]["message"])
+	assert.Equal(t, "nil map", lines[0]["error"])
+	assert.Equal(t, "panic: *errors.errorString", lines[0][elog.ErrorTypeField])
+	assert.Equal(t, "/Organization/:id", lines[0]["route"])
+	assert.NotEmpty(t, lines[0]["requestId"])
+	assert.Contains(t, lines[0][elog.StackField], "goroutine")
+}
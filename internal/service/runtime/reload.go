@@ -0,0 +1,209 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+)
+
+// Triggers of a configuration reload.
+const (
+	ReloadTriggerSignal = "signal"
+	ReloadTriggerFile   = "file"
+)
+
+// Results of a configuration reload, as counted by metrics.ConfigReloadsTotal.
+const (
+	ReloadApplied   = "applied"
+	ReloadUnchanged = "unchanged"
+	ReloadInvalid   = "invalid"
+	ReloadRejected  = "rejected"
+	ReloadFailed    = "failed"
+)
+
+// ErrNotReloadable is returned by Reloader.Reload when settings which need a restart have changed.
+var ErrNotReloadable = errors.New("settings changed which need a restart")
+
+// Reloader re-reads the configuration and applies the settings tagged `reload:"true"` to the running service.
+// A reload changing any other setting is rejected as a whole, and the running configuration is kept.
+type Reloader struct {
+	load func() (config.Config, error)
+
+	mu       sync.Mutex
+	current  atomic.Pointer[config.Config]
+	appliers []Applier
+	// lastProblem is the last reason a watched reload was not applied, so that it is logged once.
+	lastProblem string
+}
+
+// NewReloader starts from the running configuration current, and reads new ones with load.
+func NewReloader(current config.Config, load func() (config.Config, error)) *Reloader {
+	r := &Reloader{load: load}
+	r.current.Store(&current)
+	return r
+}
+
+// Current returns the configuration applied last.
+func (r *Reloader) Current() config.Config {
+	return *r.current.Load()
+}
+
+// Applier prepares a new configuration: it builds whatever the configuration needs without changing the running
+// service, and returns swap, which puts it in place and cannot fail.
+type Applier func(next config.Config) (swap func(), err error)
+
+// OnReload registers apply to be called with each new configuration, in the order of registration. The swaps are
+// only made once every applier has prepared its own, so that a reload failing part way leaves the service as it was.
+func (r *Reloader) OnReload(apply Applier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, apply)
+}
+
+// ReloadLogLevel returns the applier of LOG_LEVEL for r, which sets the log level only when LOG_LEVEL differs from
+// the configuration applied last, so that a level set through the admin server is kept across reloads of other
+// settings.
+func ReloadLogLevel(r *Reloader) Applier {
+	return func(next config.Config) (func(), error) {
+		if next.LogLevel == r.Current().LogLevel {
+			return func() {}, nil
+		}
+		level, err := elog.ParseLevel(next.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		return func() { zerolog.SetGlobalLevel(level) }, nil
+	}
+}
+
+// Reload reads the configuration and applies it if only reloadable settings have changed.
+func (r *Reloader) Reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reload(trigger, false)
+}
+
+// Watch reloads the configuration on every signal received, and re-reads it every interval to apply changes made
+// to the files it is read from, until ctx is done. An interval of 0 disables the polling.
+func (r *Reloader) Watch(ctx context.Context, signals <-chan os.Signal, interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			_ = r.Reload(ReloadTriggerSignal)
+		case <-tick:
+			r.mu.Lock()
+			_ = r.reload(ReloadTriggerFile, true)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// reload must be called with r.mu held. A watched reload is silent when nothing changed, and reports a problem only
+// the first time it is found.
+func (r *Reloader) reload(trigger string, watched bool) error {
+	logger := log.With().Str("trigger", trigger).Logger()
+	current := r.Current()
+
+	next, err := r.load()
+	if err != nil {
+		if r.report(watched, err) {
+			metrics.ConfigReloadsTotal.WithLabelValues(trigger, ReloadInvalid).Inc()
+			logger.Err(err).Msg("configuration reload failed: invalid configuration")
+		}
+		return err
+	}
+
+	reloadable, restart := config.Diff(current, next)
+	if len(restart) > 0 {
+		err := fmt.Errorf("%w: %s", ErrNotReloadable, strings.Join(restart, ", "))
+		if r.report(watched, err) {
+			metrics.ConfigReloadsTotal.WithLabelValues(trigger, ReloadRejected).Inc()
+			logger.Error().Strs("settings", restart).Msg("configuration reload rejected: restart to apply these settings")
+		}
+		return err
+	}
+	r.lastProblem = ""
+
+	if len(reloadable) == 0 {
+		if !watched {
+			metrics.ConfigReloadsTotal.WithLabelValues(trigger, ReloadUnchanged).Inc()
+			logger.Info().Msg("configuration reloaded: nothing changed")
+		}
+		return nil
+	}
+
+	swaps := make([]func(), 0, len(r.appliers))
+	for _, apply := range r.appliers {
+		swap, err := apply(next)
+		if err != nil {
+			metrics.ConfigReloadsTotal.WithLabelValues(trigger, ReloadFailed).Inc()
+			logger.Err(err).Strs("settings", reloadable).Msg("configuration reload failed while applying")
+			return err
+		}
+		swaps = append(swaps, swap)
+	}
+	for _, swap := range swaps {
+		swap()
+	}
+	r.current.Store(&next)
+
+	metrics.ConfigReloadsTotal.WithLabelValues(trigger, ReloadApplied).Inc()
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+	logger.Info().Strs("settings", reloadable).Msg("configuration reloaded")
+
+	return nil
+}
+
+// report reports whether a reload failing with err should be logged and counted, and remembers err.
+func (r *Reloader) report(watched bool, err error) bool {
+	report := !watched || err.Error() != r.lastProblem
+	r.lastProblem = err.Error()
+	return report
+}
+
+// SwappableMiddleware runs the middleware stored last, so that it can be replaced while requests are served.
+type SwappableMiddleware struct {
+	current atomic.Pointer[echo.MiddlewareFunc]
+}
+
+func NewSwappableMiddleware(mw echo.MiddlewareFunc) *SwappableMiddleware {
+	s := &SwappableMiddleware{}
+	s.Store(mw)
+	return s
+}
+
+// Store replaces the middleware for the requests served from now on.
+func (s *SwappableMiddleware) Store(mw echo.MiddlewareFunc) {
+	s.current.Store(&mw)
+}
+
+func (s *SwappableMiddleware) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return (*s.current.Load())(next)(c)
+		}
+	}
+}
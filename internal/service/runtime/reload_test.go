@@ -0,0 +1,165 @@
+package runtime_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// configSource stands in for the config files, returning whatever configuration was set last.
+type configSource struct {
+	mu  sync.Mutex
+	cfg config.Config
+	err error
+}
+
+func (s *configSource) set(cfg config.Config, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg, s.err = cfg, err
+}
+
+func (s *configSource) load() (config.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg, s.err
+}
+
+func reloadableConfig(apiKey string, origins ...string) config.Config {
+	cfg := config.Config{APIKey: apiKey, HTTPPort: "8080"}
+	cfg.CORSConfig.AllowOrigins = origins
+	cfg.CORSConfig.AllowMethods = []string{http.MethodGet}
+	return cfg
+}
+
+func TestReloader_SwapsAPIKeysAndCORSPolicy(t *testing.T) {
+	t.Parallel()
+
+	initial := reloadableConfig("old", "https://old.example.com")
+	source := &configSource{cfg: initial}
+	reloader := runtime.NewReloader(initial, source.load)
+
+	e, err := runtime.NewHTTPServer(initial, nil, stubDriftReporter{}, newChecker(t), reloader)
+	require.NoError(t, err)
+
+	serve := func(apiKey, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, runtime.HealthDetailsPath, nil)
+		req.Header.Set("X-API-Key", apiKey)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("old", "https://old.example.com")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://old.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+	source.set(reloadableConfig("new", "https://new.example.com"), nil)
+	require.NoError(t, reloader.Reload(runtime.ReloadTriggerSignal))
+	assert.Equal(t, "new", reloader.Current().APIKey)
+
+	assert.Equal(t, http.StatusUnauthorized, serve("old", "https://new.example.com").Code)
+
+	rec = serve("new", "https://new.example.com")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://new.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Empty(t, serve("new", "https://old.example.com").Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+	assert.Contains(t, scrapeMetrics(t), `ods_gateway_config_reloads_total{result="applied",trigger="signal"}`)
+}
+
+func TestReloader_RejectsSettingsNeedingRestart(t *testing.T) {
+	t.Parallel()
+
+	initial := reloadableConfig("old")
+	next := reloadableConfig("new")
+	next.HTTPPort = "9090"
+
+	reloader := runtime.NewReloader(initial, func() (config.Config, error) { return next, nil })
+	applied := false
+	reloader.OnReload(func(config.Config) (func(), error) {
+		return func() { applied = true }, nil
+	})
+
+	err := reloader.Reload(runtime.ReloadTriggerSignal)
+	require.ErrorIs(t, err, runtime.ErrNotReloadable)
+	assert.ErrorContains(t, err, "PORT")
+	assert.False(t, applied, "no part of a rejected reload is applied")
+	assert.Equal(t, "old", reloader.Current().APIKey)
+
+	assert.Contains(t, scrapeMetrics(t), `ods_gateway_config_reloads_total{result="rejected",trigger="signal"}`)
+}
+
+func TestReloader_KeepsConfigurationWhenInvalidOrNotApplied(t *testing.T) {
+	t.Parallel()
+
+	initial := reloadableConfig("old")
+	source := &configSource{}
+	reloader := runtime.NewReloader(initial, source.load)
+
+	source.set(config.Config{}, errors.New("API_KEY is required"))
+	require.ErrorContains(t, reloader.Reload(runtime.ReloadTriggerSignal), "API_KEY is required")
+	assert.Equal(t, "old", reloader.Current().APIKey)
+
+	applied := false
+	reloader.OnReload(func(config.Config) (func(), error) {
+		return func() { applied = true }, nil
+	})
+	reloader.OnReload(func(config.Config) (func(), error) { return nil, errors.New("cannot apply") })
+	source.set(reloadableConfig("new"), nil)
+	require.ErrorContains(t, reloader.Reload(runtime.ReloadTriggerSignal), "cannot apply")
+	assert.Equal(t, "old", reloader.Current().APIKey)
+	assert.False(t, applied, "appliers prepared before the one failing are not swapped in")
+}
+
+func TestReloader_WatchReloadsOnSignalAndOnFileChange(t *testing.T) {
+	t.Parallel()
+
+	initial := reloadableConfig("old")
+	source := &configSource{cfg: initial}
+
+	for name, tc := range map[string]struct {
+		interval time.Duration
+		signal   bool
+	}{
+		"signal":      {interval: 0, signal: true},
+		"file change": {interval: 10 * time.Millisecond},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			next := &configSource{cfg: reloadableConfig("new")}
+			reloader := runtime.NewReloader(initial, next.load)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+
+			signals := make(chan os.Signal, 1)
+			go reloader.Watch(ctx, signals, tc.interval)
+			if tc.signal {
+				signals <- syscall.SIGHUP
+			}
+
+			assert.Eventually(t, func() bool { return reloader.Current().APIKey == "new" }, time.Second, 5*time.Millisecond)
+		})
+	}
+
+	// an unchanged configuration is not reloaded.
+	reloader := runtime.NewReloader(initial, source.load)
+	require.NoError(t, reloader.Reload(runtime.ReloadTriggerSignal))
+	assert.Contains(t, scrapeMetrics(t), `ods_gateway_config_reloads_total{result="unchanged",trigger="signal"}`)
+}
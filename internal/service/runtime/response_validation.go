@@ -0,0 +1,169 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
+)
+
+const (
+	// ResponseValidationOff skips response validation.
+	ResponseValidationOff = "off"
+	// ResponseValidationLog logs contract violations and sends the response unchanged.
+	ResponseValidationLog = "log"
+	// ResponseValidationFail logs contract violations and replaces the response with a 500.
+	ResponseValidationFail = "fail"
+)
+
+const CodeContractViolation = "CONTRACT_VIOLATION"
+
+// ResponseValidatorMiddleware checks the status, headers and body of every response to an operation described by
+// spec against the contract, including error responses. It is meant for dev and staging: the whole response is
+// buffered in fail mode, and copied in log mode.
+func ResponseValidatorMiddleware(spec *openapi3.T, mode string) (echo.MiddlewareFunc, error) {
+	switch mode {
+	case ResponseValidationOff, "":
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }, nil
+	case ResponseValidationLog, ResponseValidationFail:
+	default:
+		return nil, fmt.Errorf("unknown response validation mode %q", mode)
+	}
+
+	router, err := newRouter(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+	buffer := mode == ResponseValidationFail
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			route, pathParams, err := router.FindRoute(req)
+			if err != nil {
+				return next(c)
+			}
+
+			res := c.Response()
+			recorder := &responseRecorder{ResponseWriter: res.Writer, buffer: buffer}
+			res.Writer = recorder
+			defer func() { res.Writer = recorder.ResponseWriter }()
+
+			// errors are rendered here rather than by echo, so that error responses are validated as well.
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			input := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+					Options:    options,
+				},
+				Status:  res.Status,
+				Header:  res.Header(),
+				Options: options,
+			}
+			input.SetBodyBytes(recorder.body.Bytes())
+
+			operationID := route.Operation.OperationID
+			metrics.ResponseValidationCheckedTotal.WithLabelValues(operationID).Inc()
+
+			violation := openapi3filter.ValidateResponse(req.Context(), input)
+			if violation != nil {
+				metrics.ResponseValidationViolationsTotal.WithLabelValues(operationID).Inc()
+				elog.Ctx(req.Context()).Error().Err(violation).Str("operation", operationID).Int("status", res.Status).
+					Msg("response violates the OpenAPI contract")
+			}
+
+			if !buffer {
+				return nil
+			}
+			if violation == nil {
+				return recorder.flush(res.Status)
+			}
+			return recorder.replace(res, http.StatusInternalServerError, svcHTTP.Error{
+				Code:    CodeContractViolation,
+				Message: "response violates the API contract",
+			})
+		}
+	}, nil
+}
+
+// newRouter matches requests to the operations of spec on path alone, whichever host the gateway is deployed on.
+func newRouter(spec *openapi3.T) (routers.Router, error) {
+	spec.Servers = nil
+
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OpenAPI router: %w", err)
+	}
+	return router, nil
+}
+
+// responseRecorder keeps a copy of the response body. When buffer is set nothing reaches the client until flush.
+type responseRecorder struct {
+	http.ResponseWriter
+	buffer bool
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.buffer {
+		r.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	if r.buffer {
+		return len(b), nil
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok && !r.buffer {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) flush(status int) error {
+	r.ResponseWriter.WriteHeader(status)
+	_, err := r.ResponseWriter.Write(r.body.Bytes())
+	return err
+}
+
+// replace discards the buffered response and sends body with status instead.
+func (r *responseRecorder) replace(res *echo.Response, status int, body svcHTTP.Error) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	r.body.Reset()
+	res.Status = status
+	res.Header().Del(echo.HeaderContentLength)
+	res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	r.ResponseWriter.WriteHeader(status)
+	_, err = r.ResponseWriter.Write(encoded)
+	return err
+}
@@ -0,0 +1,161 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to create an echo instance validating the responses of handler, served for an organisation lookup.
+func newResponseValidatedEcho(t *testing.T, mode string, handler echo.HandlerFunc) *echo.Echo {
+	t.Helper()
+
+	spec, err := svcHTTP.GetSwagger()
+	require.NoError(t, err)
+
+	validator, err := runtime.ResponseValidatorMiddleware(spec, mode)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(validator)
+	e.GET("/organisations/:odsCode", handler)
+
+	return e
+}
+
+func validOrganisation() svcHTTP.Organisation {
+	return svcHTTP.Organisation{
+		Id:          "RR8",
+		OdsCode:     "RR8",
+		Name:        "LEEDS TEACHING HOSPITALS NHS TRUST",
+		RecordClass: "HSCOrg",
+		IsActive:    true,
+		Metadata:    svcHTTP.OrganisationMetadata{LastUpdated: time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)},
+		OperationalPeriod: &svcHTTP.OperationalPeriod{
+			Start: openapi_types.Date{Time: time.Date(1998, 4, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Roles: &[]svcHTTP.OrganisationRole{{Code: "RO197", Display: "NHS TRUST", Primary: true, Status: svcHTTP.Active}},
+	}
+}
+
+func TestResponseValidatorMiddleware_PassesValidResponses(t *testing.T) {
+	t.Parallel()
+
+	e := newResponseValidatedEcho(t, runtime.ResponseValidationFail, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, validOrganisation())
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/organisations/RR8", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var org svcHTTP.Organisation
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &org))
+	assert.Equal(t, "RR8", org.OdsCode)
+}
+
+func TestResponseValidatorMiddleware_ValidatesErrorResponses(t *testing.T) {
+	t.Parallel()
+
+	e := newResponseValidatedEcho(t, runtime.ResponseValidationFail, func(_ echo.Context) error {
+		return apperrors.New(apperrors.ErrNotFound, "organisation not found in ODS API")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/organisations/UNKNOWN", nil))
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	var body svcHTTP.Error
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, runtime.CodeNotFound, body.Code)
+}
+
+func TestResponseValidatorMiddleware_FailsContractViolations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+	}{
+		{
+			name: "missing required property",
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusOK, map[string]any{"id": "RR8"})
+			},
+		},
+		{
+			name: "role status outside the enum",
+			handler: func(c echo.Context) error {
+				org := validOrganisation()
+				(*org.Roles)[0].Status = "ACTIVE"
+				return c.JSON(http.StatusOK, org)
+			},
+		},
+		{
+			name: "undocumented status",
+			handler: func(c echo.Context) error {
+				return c.JSON(http.StatusTeapot, svcHTTP.Error{Code: "TEAPOT", Message: "short and stout"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newResponseValidatedEcho(t, runtime.ResponseValidationFail, tt.handler)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/organisations/RR8", nil))
+
+			require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+			var body svcHTTP.Error
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, runtime.CodeContractViolation, body.Code)
+		})
+	}
+}
+
+func TestResponseValidatorMiddleware_LogModeSendsResponseUnchanged(t *testing.T) {
+	t.Parallel()
+
+	e := newResponseValidatedEcho(t, runtime.ResponseValidationLog, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]any{"id": "RR8"})
+	})
+
+	const series = `ods_gateway_response_validation_violations_total{operation="GetOrganisationByOdsCode"}`
+	before := scrapedValue(t, scrapeMetrics(t), series)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/organisations/RR8", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"RR8"}`, rec.Body.String())
+	assert.Greater(t, scrapedValue(t, scrapeMetrics(t), series), before)
+	assert.Contains(t, scrapeMetrics(t), `ods_gateway_response_validation_checked_total{operation="GetOrganisationByOdsCode"}`)
+}
+
+func TestResponseValidatorMiddleware_UnknownMode(t *testing.T) {
+	t.Parallel()
+
+	spec, err := svcHTTP.GetSwagger()
+	require.NoError(t, err)
+
+	_, err = runtime.ResponseValidatorMiddleware(spec, "strict")
+	require.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package runtime
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/metrics"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+)
+
+// NewTLSConfig returns the TLS config of the API server. The certificate, the key and the client CAs are read again
+// when their files change, checked at most once per ReloadInterval, so that they can be renewed without a restart;
+// when the new files cannot be used, the ones read before are kept.
+func NewTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both a certificate and a key are required to serve TLS")
+	}
+	if cfg.ClientAuth == config.TLSClientAuthRequire && cfg.ClientCAFile == "" {
+		return nil, errors.New("client CAs are required to require client certificates")
+	}
+
+	files := &tlsFiles{cfg: cfg}
+	if err := files.load(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: files.configForClient,
+	}, nil
+}
+
+// tlsFiles holds what was read last from the files of a TLSConfig.
+type tlsFiles struct {
+	cfg config.TLSConfig
+
+	mu       sync.Mutex
+	checked  time.Time
+	modTimes []time.Time
+	config   *tls.Config
+}
+
+func (f *tlsFiles) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now := time.Now(); now.Sub(f.checked) >= f.cfg.ReloadInterval {
+		f.checked = now
+		if modTimes := f.stat(); !slices.EqualFunc(modTimes, f.modTimes, time.Time.Equal) {
+			if err := f.read(modTimes); err != nil {
+				log.Warn().Err(err).Msg("error reloading TLS certificate, keeping the one loaded before")
+				// try again when the files change again, rather than on every handshake.
+				f.modTimes = modTimes
+			}
+		}
+	}
+
+	return f.config, nil
+}
+
+func (f *tlsFiles) load(now time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checked = now
+	return f.read(f.stat())
+}
+
+func (f *tlsFiles) files() []string {
+	files := []string{f.cfg.CertFile, f.cfg.KeyFile}
+	if f.cfg.ClientCAFile != "" {
+		files = append(files, f.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// stat returns the modification times of the files, zero for those which cannot be read.
+func (f *tlsFiles) stat() []time.Time {
+	files := f.files()
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+func (f *tlsFiles) read(modTimes []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(f.cfg.CertFile, f.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		// http.Server only adds these to the config it is given, not to the ones returned for each client.
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	if f.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(f.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("error reading client CAs: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CAs %s", f.cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if f.cfg.ClientAuth == config.TLSClientAuthRequire {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	f.config, f.modTimes = tlsConfig, modTimes
+
+	metrics.TLSCertificateExpiry.Set(float64(cert.Leaf.NotAfter.Unix()))
+	log.Info().
+		Str("subject", cert.Leaf.Subject.String()).
+		Time("notAfter", cert.Leaf.NotAfter).
+		Bool("clientCAs", tlsConfig.ClientCAs != nil).
+		Msg("TLS certificate loaded")
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package runtime_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// testCA issues certificates for TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// helper to create a self-signed CA.
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// helper to issue a certificate from template, returning the certificate and its key in PEM.
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// helper to issue a server certificate for 127.0.0.1 into the files of cfg, with a modification time of modTime.
+func (ca *testCA) issueServerCert(t *testing.T, cfg config.TLSConfig, serial int64, modTime time.Time) {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "ods-gateway"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	require.NoError(t, os.WriteFile(cfg.CertFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(cfg.KeyFile, keyPEM, 0o600))
+	require.NoError(t, os.Chtimes(cfg.CertFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(cfg.KeyFile, modTime, modTime))
+}
+
+// helper to serve handler over TLS with the TLS config of cfg, returning its URL.
+func serveTLS(t *testing.T, cfg config.TLSConfig, handler http.Handler) string {
+	t.Helper()
+
+	tlsConfig, err := runtime.NewTLSConfig(cfg)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: handler, TLSConfig: tlsConfig, ReadHeaderTimeout: time.Second}
+	go func() { _ = srv.ServeTLS(ln, "", "") }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return "https://" + ln.Addr().String()
+}
+
+// helper to create a TLS config whose files are in a temporary directory, checked for changes on every handshake.
+func newTLSConfig(t *testing.T) config.TLSConfig {
+	t.Helper()
+
+	dir := t.TempDir()
+	return config.TLSConfig{
+		CertFile:       filepath.Join(dir, "tls.crt"),
+		KeyFile:        filepath.Join(dir, "tls.key"),
+		ClientAuth:     config.TLSClientAuthOptional,
+		ReloadInterval: time.Nanosecond,
+	}
+}
+
+func TestTLSConfig_ReloadsRenewedCertificate(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	cfg := newTLSConfig(t)
+	ca.issueServerCert(t, cfg, 100, time.Now().Add(-time.Minute))
+
+	url := serveTLS(t, cfg, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	servedSerial := func() int64 {
+		// a new connection for each request, so that each one sees the certificate served at the time.
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}, DisableKeepAlives: true}}
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	assert.EqualValues(t, 100, servedSerial())
+
+	ca.issueServerCert(t, cfg, 101, time.Now())
+	assert.EqualValues(t, 101, servedSerial())
+
+	// a broken renewal keeps the certificate served before.
+	require.NoError(t, os.WriteFile(cfg.KeyFile, []byte("not a key"), 0o600))
+	require.NoError(t, os.Chtimes(cfg.KeyFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	assert.EqualValues(t, 101, servedSerial())
+
+	assert.Contains(t, scrapeMetrics(t), "ods_gateway_tls_certificate_expiry_timestamp_seconds")
+}
+
+func TestTLSConfig_MapsClientCertificatesToConsumers(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	cfg := newTLSConfig(t)
+	ca.issueServerCert(t, cfg, 100, time.Now())
+	cfg.ClientCAFile = filepath.Join(filepath.Dir(cfg.CertFile), "client-ca.crt")
+	require.NoError(t, os.WriteFile(cfg.ClientCAFile, ca.pem, 0o600))
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{
+		{Name: "gp-portal", Scopes: []string{auth.ScopeOrganisationsRead}, CertificateNames: []string{"gp-portal.hscn.example"}},
+		{Name: "tester", Scopes: []string{auth.ScopeAll}, Keys: []auth.KeyConfig{{SHA256: auth.HashKey("key")}}},
+	})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.AuthMiddleware(runtime.AuthConfig{Keys: store}))
+	e.GET("/whoami", func(c echo.Context) error {
+		consumer, _ := auth.ConsumerFromContext(c.Request().Context())
+		return c.String(http.StatusOK, consumer.Name)
+	})
+	url := serveTLS(t, cfg, e)
+
+	get := func(certificates []tls.Certificate, apiKey string) (int, string) {
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates}}}
+		req, err := http.NewRequest(http.MethodGet, url+"/whoami", nil)
+		require.NoError(t, err)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+	clientCert := func(template *x509.Certificate) []tls.Certificate {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		cert, err := tls.X509KeyPair(ca.issue(t, template))
+		require.NoError(t, err)
+		return []tls.Certificate{cert}
+	}
+
+	status, body := get(clientCert(&x509.Certificate{SerialNumber: big.NewInt(200), DNSNames: []string{"gp-portal.hscn.example"}}), "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "gp-portal", body)
+
+	status, _ = get(clientCert(&x509.Certificate{SerialNumber: big.NewInt(201), Subject: pkix.Name{CommonName: "stranger"}}), "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// client certificates are optional: clients without one use API keys.
+	status, body = get(nil, "key")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "tester", body)
+}
@@ -0,0 +1,67 @@
+package runtime
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+)
+
+// TracingMiddleware continues the trace of an incoming W3C traceparent header, or starts a new one, with a server
+// span per request named after its route. The x-correlation-id header is recorded on the span and carried in the
+// request context, so that it is sent on to the ODS API.
+func TracingMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			route := c.Path()
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			attrs := []attribute.KeyValue{
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.URL.Path),
+			}
+			if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+				attrs = append(attrs, attribute.String("request.id", id))
+			}
+			if id := req.Header.Get(tracing.CorrelationIDHeader); id != "" {
+				ctx = tracing.WithCorrelationID(ctx, id)
+				attrs = append(attrs, attribute.String("correlation.id", id))
+			}
+
+			ctx, span := tracing.Tracer().Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			c.SetRequest(req.WithContext(ctx))
+
+			// errors are rendered here so that the span records the status code sent.
+			if err := next(c); err != nil {
+				span.RecordError(err)
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return nil
+		}
+	}
+}
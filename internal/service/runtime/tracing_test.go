@@ -0,0 +1,80 @@
+package runtime_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+var (
+	spanRecorder     *tracetest.SpanRecorder
+	spanRecorderOnce sync.Once
+)
+
+// helper to record the spans of every test in the package with the global tracer provider.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return spanRecorder
+}
+
+func TestTracingMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	t.Parallel()
+
+	recorder := recordSpans(t)
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.TracingMiddleware())
+	e.GET("/tracing-test/:odsCode", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		assert.Equal(t, traceID, trace.SpanContextFromContext(ctx).TraceID().String())
+		assert.Equal(t, "corr-123", tracing.CorrelationIDFromContext(ctx))
+		return c.NoContent(http.StatusBadGateway)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/tracing-test/RR8", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	req.Header.Set(tracing.CorrelationIDHeader, "corr-123")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	var span sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID().String() == traceID {
+			span = s
+		}
+	}
+	require.NotNil(t, span, "a server span is recorded in the incoming trace")
+
+	assert.Equal(t, "GET /tracing-test/:odsCode", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, parentID, span.Parent().SpanID().String())
+	assert.Contains(t, span.Attributes(), attribute.String("correlation.id", "corr-123"))
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusBadGateway))
+	assert.Equal(t, "Error", span.Status().Code.String())
+}
@@ -0,0 +1,177 @@
+package runtime
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+)
+
+const UsagePath = "/diagnostics/usage"
+
+const (
+	defaultTopODSCodes = 20
+	// maxTrackedODSCodes bounds the ODS codes counted, so that memory does not grow with every code ever requested.
+	maxTrackedODSCodes = 10000
+	// maxTrackedKeys bounds the keys counted one by one: the requests of keys beyond it count for otherConsumers.
+	maxTrackedKeys = 10000
+)
+
+// KeyUsage counts the requests made with a key of a consumer, including those rejected by its limits.
+type KeyUsage struct {
+	Consumer    string `json:"consumer"`
+	KeyID       string `json:"keyId"`
+	Requests    int64  `json:"requests"`
+	RateLimited int64  `json:"rateLimited"`
+}
+
+// EndpointUsage counts the requests to a route.
+type EndpointUsage struct {
+	Method   string `json:"method"`
+	Route    string `json:"route"`
+	Requests int64  `json:"requests"`
+}
+
+// ODSCodeUsage counts the organisations found by an ODS code.
+type ODSCodeUsage struct {
+	ODSCode  string `json:"odsCode"`
+	Requests int64  `json:"requests"`
+}
+
+// UsageReport is the usage of the API by its consumers since the instance started.
+type UsageReport struct {
+	Since       time.Time       `json:"since"`
+	Keys        []KeyUsage      `json:"keys"`
+	Endpoints   []EndpointUsage `json:"endpoints"`
+	TopODSCodes []ODSCodeUsage  `json:"topOdsCodes"`
+	Quotas      []QuotaUsage    `json:"quotas"`
+}
+
+// UsageTracker counts the requests of consumers by key, by endpoint and by ODS code, in memory.
+type UsageTracker struct {
+	mu        sync.Mutex
+	since     time.Time
+	keys      map[[2]string]*KeyUsage
+	endpoints map[[2]string]int64
+	odsCodes  map[string]int64
+}
+
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		since:     time.Now(),
+		keys:      make(map[[2]string]*KeyUsage),
+		endpoints: make(map[[2]string]int64),
+		odsCodes:  make(map[string]int64),
+	}
+}
+
+// Record counts a request of consumer to the route, which responded with status. odsCode is the ODS code of the
+// organisation requested, if any: only organisations found are counted.
+func (t *UsageTracker) Record(consumer auth.Consumer, method, route, odsCode string, status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := [2]string{consumer.Name, consumer.KeyID}
+	usage, ok := t.keys[key]
+	if !ok && len(t.keys) >= maxTrackedKeys {
+		key = [2]string{otherConsumers, ""}
+		usage, ok = t.keys[key]
+	}
+	if !ok {
+		usage = &KeyUsage{Consumer: key[0], KeyID: key[1]}
+		t.keys[key] = usage
+	}
+	usage.Requests++
+	if status == http.StatusTooManyRequests {
+		usage.RateLimited++
+	}
+
+	t.endpoints[[2]string{method, route}]++
+
+	if odsCode != "" && status == http.StatusOK {
+		if _, ok := t.odsCodes[odsCode]; ok || len(t.odsCodes) < maxTrackedODSCodes {
+			t.odsCodes[odsCode]++
+		}
+	}
+}
+
+// Report returns the usage counted so far, with the top most requested ODS codes.
+func (t *UsageTracker) Report(top int) UsageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := UsageReport{
+		Since:       t.since,
+		Keys:        make([]KeyUsage, 0, len(t.keys)),
+		Endpoints:   make([]EndpointUsage, 0, len(t.endpoints)),
+		TopODSCodes: make([]ODSCodeUsage, 0, len(t.odsCodes)),
+	}
+	for _, usage := range t.keys {
+		report.Keys = append(report.Keys, *usage)
+	}
+	for endpoint, requests := range t.endpoints {
+		report.Endpoints = append(report.Endpoints, EndpointUsage{Method: endpoint[0], Route: endpoint[1], Requests: requests})
+	}
+	for odsCode, requests := range t.odsCodes {
+		report.TopODSCodes = append(report.TopODSCodes, ODSCodeUsage{ODSCode: odsCode, Requests: requests})
+	}
+
+	// most requested first, then by name so that the order is stable.
+	slices.SortFunc(report.Keys, func(a, b KeyUsage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.Consumer, b.Consumer), cmp.Compare(a.KeyID, b.KeyID))
+	})
+	slices.SortFunc(report.Endpoints, func(a, b EndpointUsage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.Route, b.Route), cmp.Compare(a.Method, b.Method))
+	})
+	slices.SortFunc(report.TopODSCodes, func(a, b ODSCodeUsage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.ODSCode, b.ODSCode))
+	})
+	report.TopODSCodes = report.TopODSCodes[:min(top, len(report.TopODSCodes))]
+
+	return report
+}
+
+// UsageMiddleware records the requests of authenticated consumers with tracker. Errors are left to the error
+// handler, the status recorded for them being the one it sends.
+func UsageMiddleware(tracker *UsageTracker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			if consumer, ok := auth.ConsumerFromContext(c.Request().Context()); ok {
+				status := c.Response().Status
+				if err != nil && !c.Response().Committed {
+					status, _ = errorResponse(err)
+				}
+				tracker.Record(consumer, c.Request().Method, c.Path(), c.Param("odsCode"), status)
+			}
+			return err
+		}
+	}
+}
+
+// UsageHandler serves the usage of the API as JSON, with the quotas used today. The query parameter top sets how
+// many of the most requested ODS codes are listed.
+func UsageHandler(tracker *UsageTracker, limiter *ConsumerLimiter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		top := defaultTopODSCodes
+		if raw := c.QueryParam("top"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				return apperrors.New(apperrors.ErrInvalidInput, "top must be a non-negative integer")
+			}
+			top = n
+		}
+
+		report := tracker.Report(top)
+		report.Quotas = limiter.QuotaUsage(time.Now())
+		return c.JSON(http.StatusOK, report)
+	}
+}
@@ -0,0 +1,97 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/auth"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+func TestUsageHandler_ReportsUsageByKeyEndpointAndODSCode(t *testing.T) {
+	t.Parallel()
+
+	store, err := auth.NewKeyStore([]auth.ConsumerConfig{
+		{Name: "gp-portal", Scopes: []string{auth.ScopeAll}, Keys: []auth.KeyConfig{{ID: "2026-10", SHA256: auth.HashKey("gp")}}},
+		{
+			Name:   "bulk-export",
+			Scopes: []string{auth.ScopeAll},
+			Keys:   []auth.KeyConfig{{ID: "2026-04", SHA256: auth.HashKey("bulk")}},
+			Limits: &auth.Limits{DailyQuota: 2},
+		},
+	})
+	require.NoError(t, err)
+
+	tracker := runtime.NewUsageTracker()
+	limiter := runtime.NewConsumerLimiter(auth.Limits{})
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(runtime.AuthMiddleware(runtime.AuthConfig{Keys: store}))
+	e.Use(runtime.UsageMiddleware(tracker))
+	e.Use(runtime.RateLimitMiddleware(limiter))
+	e.GET("/organisations/:odsCode", func(c echo.Context) error {
+		if c.Param("odsCode") == "MISSING" {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET(runtime.UsagePath, runtime.UsageHandler(tracker, limiter))
+
+	serve := func(key, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{"/organisations/RR8", "/organisations/RR8", "/organisations/X26", "/organisations/MISSING"} {
+		serve("gp", path)
+	}
+	for range 3 {
+		serve("bulk", "/organisations/X26")
+	}
+
+	rec := serve("gp", runtime.UsagePath+"?top=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var report runtime.UsageReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, []runtime.KeyUsage{
+		{Consumer: "gp-portal", KeyID: "2026-10", Requests: 4},
+		{Consumer: "bulk-export", KeyID: "2026-04", Requests: 3, RateLimited: 1},
+	}, report.Keys)
+	assert.Equal(t, []runtime.EndpointUsage{
+		{Method: http.MethodGet, Route: "/organisations/:odsCode", Requests: 7},
+	}, report.Endpoints)
+	assert.Equal(t, []runtime.ODSCodeUsage{{ODSCode: "X26", Requests: 3}}, report.TopODSCodes)
+	require.Len(t, report.Quotas, 2)
+	assert.Equal(t, runtime.QuotaUsage{Consumer: "bulk-export", Day: report.Quotas[0].Day, Used: 2, DailyQuota: 2}, report.Quotas[0])
+
+	assert.Equal(t, http.StatusBadRequest, serve("gp", runtime.UsagePath+"?top=many").Code)
+}
+
+func TestUsageTracker_BoundsTheKeysCounted(t *testing.T) {
+	t.Parallel()
+
+	tracker := runtime.NewUsageTracker()
+	for i := range 10002 {
+		consumer := auth.Consumer{Name: fmt.Sprintf("jwt:subject-%d", i), KeyID: "jwt:ec-1"}
+		tracker.Record(consumer, http.MethodGet, "/organisations/:odsCode", "RR8", http.StatusOK)
+	}
+	// the consumers tracked keep being counted apart.
+	tracker.Record(auth.Consumer{Name: "jwt:subject-0", KeyID: "jwt:ec-1"}, http.MethodGet, "/organisations", "", http.StatusOK)
+
+	report := tracker.Report(1)
+	assert.Len(t, report.Keys, 10001)
+	assert.Equal(t, runtime.KeyUsage{Consumer: "(other)", Requests: 2}, report.Keys[0])
+	assert.Equal(t, runtime.KeyUsage{Consumer: "jwt:subject-0", KeyID: "jwt:ec-1", Requests: 2}, report.Keys[1])
+}
@@ -0,0 +1,120 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/apperrors"
+)
+
+// FieldError describes one request parameter which failed validation.
+type FieldError struct {
+	Name   string `json:"name"`
+	In     string `json:"in"`
+	Reason string `json:"reason"`
+}
+
+// RequestValidatorMiddleware validates path, query and header parameters against spec before they reach a handler.
+// Invalid requests fail with an apperrors.ErrInvalidInput error listing every offending field under the "fields" detail.
+// Requests for paths the spec does not describe are passed through.
+func RequestValidatorMiddleware(spec *openapi3.T) (echo.MiddlewareFunc, error) {
+	router, err := newRouter(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			route, pathParams, err := router.FindRoute(req)
+			if err != nil {
+				// not described by the spec, e.g. probes: leave it to echo.
+				var routeErr *routers.RouteError
+				if errors.As(err, &routeErr) {
+					return next(c)
+				}
+				return err
+			}
+
+			err = openapi3filter.ValidateRequest(req.Context(), &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			})
+			if err != nil {
+				return apperrors.Wrap(apperrors.ErrInvalidInput, err, "invalid request parameters").
+					WithDetail("fields", fieldErrors(err))
+			}
+
+			return next(c)
+		}
+	}, nil
+}
+
+// fieldErrors flattens the errors of openapi3filter into one entry per offending parameter.
+func fieldErrors(err error) []FieldError {
+	fields := make([]FieldError, 0)
+	collectFieldErrors(err, &fields)
+
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+func collectFieldErrors(err error, fields *[]FieldError) {
+	// only unpack the top level: a MultiError wrapped in a RequestError belongs to that one parameter.
+	if multi, ok := err.(openapi3.MultiError); ok {
+		for _, e := range multi {
+			collectFieldErrors(e, fields)
+		}
+		return
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		*fields = append(*fields, FieldError{Reason: err.Error()})
+		return
+	}
+
+	field := FieldError{Reason: reason(reqErr)}
+	if reqErr.Parameter != nil {
+		field.Name = reqErr.Parameter.Name
+		field.In = reqErr.Parameter.In
+	} else if reqErr.RequestBody != nil {
+		field.In = "body"
+	}
+	*fields = append(*fields, field)
+}
+
+// reason prefers the short explanation of a schema or parse error over the verbose wrapped message.
+func reason(reqErr *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		return schemaErr.Reason
+	}
+
+	var parseErr *openapi3filter.ParseError
+	if errors.As(reqErr.Err, &parseErr) {
+		return parseErr.Error()
+	}
+
+	if reqErr.Err == nil {
+		return reqErr.Reason
+	}
+	if reqErr.Reason == "" {
+		return reqErr.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", reqErr.Reason, reqErr.Err)
+}
@@ -0,0 +1,119 @@
+package runtime_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	svcHTTP "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
+)
+
+// helper to create an echo instance validating requests against the gateway spec in front of stub handlers.
+func newValidatedEcho(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	spec, err := svcHTTP.GetSwagger()
+	require.NoError(t, err)
+
+	validator, err := runtime.RequestValidatorMiddleware(spec)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HTTPErrorHandler = runtime.HTTPErrorHandler
+	e.Use(validator)
+
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/organisations", ok)
+	e.GET("/organisations/:odsCode", ok)
+
+	return e
+}
+
+func TestRequestValidatorMiddleware_RejectsInvalidParameters(t *testing.T) {
+	t.Parallel()
+
+	e := newValidatedEcho(t)
+
+	tests := []struct {
+		name   string
+		target string
+		fields []string
+	}{
+		{name: "page size above maximum", target: "/organisations?page=1&pageSize=500", fields: []string{"pageSize"}},
+		{name: "page below minimum", target: "/organisations?page=0&pageSize=50", fields: []string{"page"}},
+		{name: "missing page", target: "/organisations?pageSize=50", fields: []string{"page"}},
+		{name: "name too short", target: "/organisations?name=ab&page=1&pageSize=50", fields: []string{"name"}},
+		{name: "name with forbidden characters", target: "/organisations?name=Leeds%3B%20DROP&page=1&pageSize=50", fields: []string{"name"}},
+		{name: "postcode too short", target: "/organisations?postcode=L&page=1&pageSize=50", fields: []string{"postcode"}},
+		{name: "invalid boolean", target: "/organisations/RR8?includeInactiveRoles=maybe", fields: []string{"includeInactiveRoles"}},
+		{
+			name:   "every offending field is listed",
+			target: "/organisations?name=ab&postcode=L&page=0&pageSize=500",
+			fields: []string{"name", "page", "pageSize", "postcode"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var body svcHTTP.Error
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, runtime.CodeInvalidInput, body.Code)
+			require.NotNil(t, body.Details)
+
+			var fields []runtime.FieldError
+			raw, err := json.Marshal((*body.Details)["fields"])
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(raw, &fields))
+
+			names := make([]string, 0, len(fields))
+			for _, f := range fields {
+				assert.Equal(t, "query", f.In)
+				assert.NotEmpty(t, f.Reason)
+				names = append(names, f.Name)
+			}
+			assert.Equal(t, tt.fields, names)
+		})
+	}
+}
+
+func TestRequestValidatorMiddleware_AcceptsValidRequests(t *testing.T) {
+	t.Parallel()
+
+	e := newValidatedEcho(t)
+
+	for _, target := range []string{
+		"/organisations?page=1&pageSize=50",
+		"/organisations?name=St%20Mary%27s%20(Leeds)%20%26%20Co.&postcode=LS1%201&page=2&pageSize=200",
+		"/organisations?lastUpdatedFrom=2025-01-01&page=1&pageSize=10",
+		"/organisations/RR8",
+		"/organisations/RR8?includeInactiveRoles=true",
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, target)
+	}
+}
+
+func TestRequestValidatorMiddleware_PassesUnknownRoutes(t *testing.T) {
+	t.Parallel()
+
+	e := newValidatedEcho(t)
+	e.GET("/liveness", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/liveness", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
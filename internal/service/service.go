@@ -3,62 +3,347 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/audit"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/elog"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/health"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/ports/http/server"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/common/tracing"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/config"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/cache"
 	odsAdapter "github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/adapters/ods-fhir"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app"
+	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/common"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/ods-gateway/app/queries"
 	"github.com/Cleo-Systems/ods-fhir-gateway/internal/service/runtime"
 	odsHTTP "github.com/Cleo-Systems/ods-fhir-gateway/pkg/ods-fhir-api/client"
 )
 
 type Service struct {
-	httpServer *http.Server
+	httpServer    *http.Server
+	metricsServer *http.Server
+	adminServer   *http.Server
+
+	checker    *health.Checker
+	drainDelay time.Duration
+
+	reloader      *runtime.Reloader
+	watchInterval time.Duration
+
+	// auditLog is nil when lookups are not audited.
+	auditLog *audit.Logger
+	// odsClient dispatches the calls to the ODS API until it is closed.
+	odsClient *odsAdapter.GovernedAPIClient
+
+	shutdownTracing func(context.Context) error
 }
 
-func NewService() (*Service, error) {
-	appConfig, err := config.NewConfigFromEnv()
+// NewService builds the service from appConfig, which was loaded from configFiles: they are read again when the
+// configuration is reloaded.
+func NewService(appConfig config.Config, configFiles []string) (*Service, error) {
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		OTLPEndpoint: appConfig.TracingConfig.OTLPEndpoint,
+		ServiceName:  appConfig.TracingConfig.ServiceName,
+		SampleRatio:  appConfig.TracingConfig.SampleRatio,
+	})
 	if err != nil {
-		log.Err(err).Msg("error creating config from env")
+		log.Err(err).Msg("error initialising tracing")
+		return nil, err
+	}
+
+	if err := initErrorReporting(appConfig); err != nil {
+		log.Err(err).Msg("error initialising error reporting")
 		return nil, err
 	}
 
-	odsAPIClient, err := odsHTTP.NewClientWithResponses(appConfig.ODSConfig.ServerURL)
+	odsHTTPClient, err := odsAdapter.NewHTTPClient(odsHTTPClientConfig(appConfig))
+	if err != nil {
+		log.Err(err).Msg("error creating ODS API HTTP client")
+		return nil, err
+	}
+
+	odsAPIClient, err := odsHTTP.NewClientWithResponses(appConfig.ODSConfig.ServerURL,
+		odsHTTP.WithHTTPClient(odsHTTPClient),
+		odsHTTP.WithRequestEditorFn(odsAdapter.PropagateTraceContext),
+	)
 	if err != nil {
 		log.Err(err).Msg("error creating ODS API client")
 		return nil, err
 	}
 
-	odsAPIAdapter := odsAdapter.NewClient(odsAPIClient)
+	// each attempt is measured and traced on its own.
+	instrumentedAPIClient := odsAdapter.NewMeasuredAPIClient(odsAdapter.NewTracedAPIClient(odsAPIClient))
+
+	// every retry of the resilient client takes its own turn with the governor.
+	governedAPIClient := odsAdapter.NewGovernedAPIClient(instrumentedAPIClient, odsAdapter.GovernorConfig{
+		RateLimit: appConfig.ODSConfig.RateLimit,
+		Burst:     appConfig.ODSConfig.RateBurst,
+		MaxQueue:  appConfig.ODSConfig.MaxQueue,
+	})
+
+	resilientAPIClient := odsAdapter.NewResilientAPIClient(governedAPIClient, odsAdapter.ResilienceConfig{
+		OrganisationTimeout: appConfig.ODSConfig.OrganisationTimeout,
+		SearchTimeout:       appConfig.ODSConfig.SearchTimeout,
+		DefaultTimeout:      appConfig.ODSConfig.DefaultTimeout,
+		MaxRetries:          appConfig.ODSConfig.MaxRetries,
+		RetryBaseDelay:      appConfig.ODSConfig.RetryBaseDelay,
+		RetryMaxDelay:       appConfig.ODSConfig.RetryMaxDelay,
+		FailureThreshold:    appConfig.ODSConfig.BreakerFailureThreshold,
+		OpenDuration:        appConfig.ODSConfig.BreakerOpenDuration,
+	})
+
+	odsSpec, err := odsHTTP.GetSwagger()
+	if err != nil {
+		log.Err(err).Msg("error loading ODS API spec")
+		return nil, err
+	}
+
+	driftDetector, err := odsAdapter.NewDriftDetector(odsSpec, odsAdapter.DriftConfig{
+		SampleRate:         appConfig.ODSConfig.DriftSampleRate,
+		KnownExtensionURLs: queries.ExtensionURLs(),
+	})
+	if err != nil {
+		log.Err(err).Msg("error creating ODS API drift detector")
+		return nil, err
+	}
+
+	var odsAPIAdapter common.OdsFHIRClient = odsAdapter.NewClient(
+		odsAdapter.NewDriftDetectingAPIClient(resilientAPIClient, driftDetector),
+	)
+
+	responseCache, err := newResponseCache(appConfig.CacheConfig)
+	if err != nil {
+		log.Err(err).Msg("error creating response cache")
+		return nil, err
+	}
+	if responseCache != nil {
+		odsAPIAdapter = odsAdapter.NewCachedClient(odsAPIAdapter, responseCache, odsAdapter.CacheTTLs{
+			Organisation:         appConfig.CacheConfig.OrganisationTTL,
+			Search:               appConfig.CacheConfig.SearchTTL,
+			NotFound:             appConfig.CacheConfig.NotFoundTTL,
+			StaleWhileRevalidate: appConfig.CacheConfig.StaleWhileRevalidate,
+			StaleIfError:         appConfig.CacheConfig.StaleIfError,
+			RefreshTimeout:       appConfig.RequestTimeout,
+		})
+	}
+
+	reloader := runtime.NewReloader(appConfig, func() (config.Config, error) { return config.Load(configFiles...) })
+	reloader.OnReload(runtime.ReloadLogLevel(reloader))
+	reloader.OnReload(func(cfg config.Config) (func(), error) {
+		return func() {
+			resilientAPIClient.SetTimeouts(cfg.ODSConfig.OrganisationTimeout, cfg.ODSConfig.SearchTimeout,
+				cfg.ODSConfig.DefaultTimeout)
+		}, nil
+	})
+
+	checker, err := newChecker(reloader, odsHTTPClientConfig(appConfig), governedAPIClient)
+	if err != nil {
+		log.Err(err).Msg("error creating health checks")
+		return nil, err
+	}
+
+	auditLog, err := newAuditLog(appConfig.AuditConfig)
+	if err != nil {
+		log.Err(err).Msg("error opening audit log")
+		return nil, err
+	}
+	var auditor audit.Recorder = audit.Discard
+	if auditLog != nil {
+		auditor = auditLog
+	}
 
 	odsGatewayServer, err := server.NewODSGateway(app.ODSGatewayApp{
 		Queries: app.Queries{
-			GetOrganisationByODSCode: queries.NewGetOrganisationByODSCodeQueryHandler(odsAPIAdapter),
-			SearchOrganisations:      queries.NewSearchOrganisationsQueryHandler(odsAPIAdapter),
+			GetOrganisationByODSCode: queries.NewMeasuredGetOrganisationByODSCodeQueryHandler(
+				queries.NewGetOrganisationByODSCodeQueryHandler(odsAPIAdapter),
+			),
+			SearchOrganisations: queries.NewMeasuredSearchOrganisationsQueryHandler(
+				queries.NewSearchOrganisationsQueryHandler(odsAPIAdapter),
+			),
 		},
-	})
+	}, auditor)
 	if err != nil {
 		log.Err(err).Msg("error creating ODS Gateway server")
 		return nil, err
 	}
 
-	handler := runtime.NewHTTPServer(appConfig, odsGatewayServer)
+	handler, err := runtime.NewHTTPServer(appConfig, odsGatewayServer, driftDetector, checker, reloader)
+	if err != nil {
+		log.Err(err).Msg("error creating HTTP server")
+		return nil, err
+	}
 
-	return &Service{
+	svc := &Service{
 		httpServer: &http.Server{
 			Addr:              ":" + appConfig.HTTPPort,
 			Handler:           handler,
 			ReadHeaderTimeout: appConfig.RequestTimeout,
 		},
-	}, nil
+		checker:         checker,
+		drainDelay:      appConfig.HealthConfig.DrainDelay,
+		reloader:        reloader,
+		watchInterval:   appConfig.ConfigWatchInterval,
+		auditLog:        auditLog,
+		odsClient:       governedAPIClient,
+		shutdownTracing: shutdownTracing,
+	}
+
+	if appConfig.TLSConfig.Enabled() {
+		svc.httpServer.TLSConfig, err = runtime.NewTLSConfig(appConfig.TLSConfig)
+		if err != nil {
+			log.Err(err).Msg("error creating TLS config")
+			return nil, err
+		}
+	}
+
+	if appConfig.MetricsPort != "" {
+		svc.metricsServer = &http.Server{
+			Addr:              ":" + appConfig.MetricsPort,
+			Handler:           runtime.NewMetricsServer(),
+			ReadHeaderTimeout: appConfig.RequestTimeout,
+		}
+	}
+
+	if appConfig.AdminPort != "" {
+		svc.adminServer = &http.Server{
+			Addr:              net.JoinHostPort(appConfig.AdminHost, appConfig.AdminPort),
+			Handler:           runtime.NewAdminServer(appConfig, checker, reloader),
+			ReadHeaderTimeout: appConfig.RequestTimeout,
+		}
+	}
+
+	return svc, nil
+}
+
+func odsHTTPClientConfig(cfg config.Config) odsAdapter.TransportConfig {
+	return odsAdapter.TransportConfig{
+		ProxyURL:              cfg.ODSConfig.ProxyURL,
+		CABundleFile:          cfg.ODSConfig.CABundleFile,
+		ClientCertFile:        cfg.ODSConfig.ClientCertFile,
+		ClientKeyFile:         cfg.ODSConfig.ClientKeyFile,
+		MaxIdleConns:          cfg.ODSConfig.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.ODSConfig.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.ODSConfig.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.ODSConfig.IdleConnTimeout,
+		DisableHTTP2:          cfg.ODSConfig.DisableHTTP2,
+		DialTimeout:           cfg.ODSConfig.DialTimeout,
+		TLSHandshakeTimeout:   cfg.ODSConfig.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ODSConfig.ResponseHeaderTimeout,
+	}
+}
+
+func newChecker(
+	reloader *runtime.Reloader,
+	transport odsAdapter.TransportConfig,
+	client odsHTTP.ClientWithResponsesInterface,
+) (*health.Checker, error) {
+	cfg := reloader.Current()
+	reachability, err := odsAdapter.NewReachabilityCheck(cfg.ODSConfig.ServerURL, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return health.NewChecker(
+		health.Check{
+			Name:     "config",
+			Critical: true,
+			CacheTTL: cfg.HealthConfig.CacheTTL,
+			Run:      func(context.Context) error { return reloader.Current().Validate() },
+		},
+		health.Check{
+			Name:     "ods_upstream_reachability",
+			Critical: true,
+			Timeout:  cfg.HealthConfig.ReachabilityTimeout,
+			CacheTTL: cfg.HealthConfig.CacheTTL,
+			Run:      reachability,
+		},
+		health.Check{
+			Name:     "ods_metadata",
+			Critical: true,
+			Timeout:  cfg.HealthConfig.MetadataTimeout,
+			CacheTTL: cfg.HealthConfig.CacheTTL,
+			Run:      odsAdapter.NewMetadataCheck(client),
+		},
+	)
+}
+
+func initErrorReporting(cfg config.Config) error {
+	if cfg.ErrorReportingConfig.SentryDSN == "" {
+		return nil
+	}
+
+	environment := cfg.ErrorReportingConfig.SentryEnvironment
+	if environment == "" {
+		environment = cfg.Account
+	}
+	hostname, _ := os.Hostname()
+
+	reporter, err := elog.NewSentryReporter(elog.SentryConfig{
+		DSN:         cfg.ErrorReportingConfig.SentryDSN,
+		Environment: environment,
+		Release:     cfg.ErrorReportingConfig.SentryRelease,
+		ServerName:  hostname,
+	})
+	if err != nil {
+		return err
+	}
+
+	elog.SetReporter(elog.Deduplicate(reporter, cfg.ErrorReportingConfig.DuplicateWindow))
+	return nil
+}
+
+func newResponseCache(cfg config.CacheConfig) (common.ResponseCache, error) {
+	switch cfg.Backend {
+	case config.CacheBackendNone:
+		return nil, nil
+	case config.CacheBackendMemory, "":
+		return cache.NewMemory(cfg.MaxEntries), nil
+	case config.CacheBackendRedis:
+		if cfg.RedisAddr == "" {
+			return nil, errors.New("CACHE_REDIS_ADDR is required for the redis cache backend")
+		}
+		return cache.NewRedis(redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Username: cfg.RedisUsername,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		}), cfg.RedisKeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+}
+
+// newAuditLog opens the audit log in the directory of cfg, or returns nil when it is not set.
+func newAuditLog(cfg config.AuditConfig) (*audit.Logger, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+
+	sink, err := audit.NewFileSink(audit.FileConfig{
+		Dir:            cfg.Dir,
+		MaxSize:        cfg.MaxFileSize,
+		RotateInterval: cfg.RotateInterval,
+		Retention:      cfg.Retention,
+	})
+	if err != nil {
+		return nil, err
+	}
+	auditLog, err := audit.NewLogger(sink, []byte(cfg.HMACKey))
+	if err != nil {
+		_ = sink.Close()
+		return nil, err
+	}
+	return auditLog, nil
 }
 
 func (s *Service) Start(ctx context.Context) error {
@@ -66,17 +351,83 @@ func (s *Service) Start(ctx context.Context) error {
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	defer func() {
+		// send the error reports still queued before the process exits.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = elog.CurrentReporter().Flush(flushCtx)
+	}()
+
+	// re-read the configuration on SIGHUP, and when its files change.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go s.reloader.Watch(ctx, hup, s.watchInterval)
+
+	// release the rest once the API has stopped, whether it was shut down or failed. The metrics server is kept up
+	// until the API has drained, so the drain itself is observed, and the audit log is closed after it, so that every
+	// lookup served is recorded.
+	if s.metricsServer != nil {
+		defer func() { _ = s.metricsServer.Close() }()
+	}
+	if s.adminServer != nil {
+		defer func() { _ = s.adminServer.Close() }()
+	}
+	defer s.odsClient.Close()
+	if s.auditLog != nil {
+		defer func() {
+			if err := s.auditLog.Close(); err != nil {
+				log.Err(err).Msg("error closing audit log")
+			}
+		}()
+	}
+	defer func() {
+		// its own deadline, as the shutdown of the API may have used up its own.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.shutdownTracing(flushCtx); err != nil {
+			log.Err(err).Msg("error flushing spans")
+		}
+	}()
+
 	serveErr := make(chan error, 1)
 
 	go func() {
-		log.Info().Msg("listening...")
-		err := s.httpServer.ListenAndServe()
+		var err error
+		if s.httpServer.TLSConfig != nil {
+			log.Info().Msg("listening with TLS...")
+			// the certificate comes from the TLS config, which reloads it.
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			log.Info().Msg("listening...")
+			err = s.httpServer.ListenAndServe()
+		}
 		if errors.Is(err, http.ErrServerClosed) {
 			err = nil
 		}
 		serveErr <- err
 	}()
 
+	if s.metricsServer != nil {
+		go func() {
+			log.Info().Str("addr", s.metricsServer.Addr).Msg("serving metrics...")
+			// metrics are not worth taking the API down for.
+			if err := s.metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Err(err).Msg("metrics server failed")
+			}
+		}()
+	}
+
+	if s.adminServer != nil {
+		go func() {
+			log.Info().Str("addr", s.adminServer.Addr).Msg("serving admin endpoints...")
+			if err := s.adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Err(err).Msg("admin server failed")
+			}
+		}()
+	}
+
 	// wait for either:
 	//  1) ctx cancellation (signal or parent ctx) => attempt graceful shutdown
 	//  2) server fails early/runtime => return that error
@@ -84,6 +435,13 @@ func (s *Service) Start(ctx context.Context) error {
 	case <-ctx.Done():
 		log.Info().Msg("shutdown requested")
 
+		// fail readiness first, so that load balancers stop sending requests before the listener closes.
+		s.checker.Drain()
+		if s.drainDelay > 0 {
+			log.Info().Dur("delay", s.drainDelay).Msg("draining...")
+			time.Sleep(s.drainDelay)
+		}
+
 		// give graceful shutdown its own deadline.
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
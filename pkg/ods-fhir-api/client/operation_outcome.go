@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// OperationOutcomeResourceType is the resourceType of FHIR OperationOutcome resources.
+const OperationOutcomeResourceType = "OperationOutcome"
+
+// OperationOutcome is the FHIR resource the ODS API returns for unsuccessful requests.
+// The published specification only documents it by example, so it is maintained by hand next to the generated client.
+type OperationOutcome struct {
+	Id           *string                 `json:"id,omitempty"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+	Meta         *Meta                   `json:"meta,omitempty"`
+	ResourceType string                  `json:"resourceType"`
+}
+
+// OperationOutcomeIssue describes a single problem reported in an OperationOutcome.
+type OperationOutcomeIssue struct {
+	// Code FHIR issue type, for example 'not-found' or 'invalid'.
+	Code string `json:"code"`
+
+	// Details ODS error code, for example NO_RECORD_FOUND or INVALID_PARAMETER.
+	Details     *CodeableConcept `json:"details,omitempty"`
+	Diagnostics *string          `json:"diagnostics,omitempty"`
+
+	// Severity One of fatal, error, warning or information.
+	Severity string `json:"severity"`
+}
+
+// CodeableConcept defines model for CodeableConcept.
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   *string  `json:"text,omitempty"`
+}
+
+// UnmarshalJSON accepts coding both as a list, as FHIR defines it, and as a single object, as the ODS API documents it.
+func (c *CodeableConcept) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Coding json.RawMessage `json:"coding"`
+		Text   *string         `json:"text"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	c.Text = raw.Text
+	c.Coding = nil
+
+	coding := bytes.TrimSpace(raw.Coding)
+	switch {
+	case len(coding) == 0 || bytes.Equal(coding, []byte("null")):
+		return nil
+	case coding[0] == '[':
+		return json.Unmarshal(coding, &c.Coding)
+	default:
+		var single Coding
+		if err := json.Unmarshal(coding, &single); err != nil {
+			return err
+		}
+		c.Coding = []Coding{single}
+		return nil
+	}
+}
+
+// DetailsCode returns the first ODS error code of the issue, e.g. NO_RECORD_FOUND.
+func (i OperationOutcomeIssue) DetailsCode() string {
+	if i.Details == nil {
+		return ""
+	}
+	for _, coding := range i.Details.Coding {
+		if coding.Code != nil {
+			return *coding.Code
+		}
+	}
+	return ""
+}
+
+// DetailsDisplay returns the human-readable description of the first ODS error code of the issue.
+func (i OperationOutcomeIssue) DetailsDisplay() string {
+	if i.Details == nil {
+		return ""
+	}
+	for _, coding := range i.Details.Coding {
+		if coding.Display != nil {
+			return *coding.Display
+		}
+	}
+	if i.Details.Text != nil {
+		return *i.Details.Text
+	}
+	return ""
+}
+
+// IssueCodes returns the ODS error codes of all issues, falling back to the FHIR issue type.
+func (o OperationOutcome) IssueCodes() []string {
+	codes := make([]string, 0, len(o.Issue))
+	for _, issue := range o.Issue {
+		code := issue.DetailsCode()
+		if code == "" {
+			code = issue.Code
+		}
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// ParseOperationOutcome decodes the body of an unsuccessful response. It reports false when the body is not an OperationOutcome.
+func ParseOperationOutcome(body []byte) (*OperationOutcome, bool) {
+	var outcome OperationOutcome
+	if err := json.Unmarshal(body, &outcome); err != nil {
+		return nil, false
+	}
+	if outcome.ResourceType != OperationOutcomeResourceType {
+		return nil, false
+	}
+	return &outcome, true
+}
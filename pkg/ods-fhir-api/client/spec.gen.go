@@ -0,0 +1,276 @@
+// Package http provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.5.0 DO NOT EDIT.
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+y963LbOrYg/Crr0z5VsatFWRffv0r1kSXGZm9Z0iGlZOfEqRgWIQsdilQToB33Tqqm",
+	"5ilm/s6/eY0+Lza1AJAiKEqWY+/b6XbtbEkksIC1sO64/VyZRPNFFNJQ8MrpzxU+mdE5kV/bvh9TLr8u",
+	"4mhBY8Go/DVh4gE/xcOCVk4rXMQsvK18q1YmURKKuPydz/D7RJS+DFhI8QUTdM5LS+gHJI7JA/5eRFyQ",
+	"oBP5tKT4snx081c6EVjhLAn9gPZY+HkVoZgGRLAoxO/0C5kvAgmNBtNKdbUrSRxgwWkUz4monFaSmFWq",
+	"23ShE/n4bpWe5VhImi0CUk5P/sAFnX9fR+wvgoZcI0x8nyHyJBjmeiXihFYrPuWTmC0UbSrnNKQxm8Cb",
+	"C8cFmsL4/2EaxTDoeiBmjAPjIB4WbEKC4AHaE8Hu6JDGLPIBS8W3JGR/l8R2o4DWrsJKtUANmu9cxhD/",
+	"FtNp5bTyw96SXfc0r+4t0SlhlK1Gq1q5I0FCz6IooCTM0ftGP0lLLIdwU4d0qbSSwv+xSrpUWslTXduO",
+	"uR2fhoJNGY1XuWtbRtHtbtniJRVkta2AcDFe+ERQ32gQn1iCzWlZs4s4mrKAfh8r5zlKiTjCMflW8qt6",
+	"CdHUYEKIKY+SeEI5xFQkcUh9uHkAMaOSo9tDp7bKoKmO24o5863ZsmYJkzJ/nVr8vHVDOQVX0sJcj9cm",
+	"CHJMv1UrKU1GEgYinMwrpx+0Cq18LBlDEQkSrFJ+hI8hTOY3NEbSz4mYzFh4uyQ7kjdTuScH1XWavwhZ",
+	"Dye+hB2tgKqQ8ETqnVeckngy41S82jVaqGQvStkrpn9LWIzM+8Gkgi788REOtFPWMDlmmgTBeDs9lLb6",
+	"FLZy0zqPSoibg14iI6WSATsoCVpId1fFgUgdX64zydKF2IRM6ml8q7609l8jWMzQl5ug5zTrE6UoJPNy",
+	"q75OvPLULxcyXWHFe3iCSVrlkM1sz/yKRqWM95eWragiV/U/wkmCgNwENHUvVjB84dHngsSirCOPGhZ8",
+	"xMJppAgcCqIcVzonLMAWFqw2JyG5pXMain8PZ7wWUpFS6rSCEiMlqj10wEsWiygWFe2LVGZCLPjp3p7P",
+	"bpkgQQ1rJ5/3fHpHAyTi3owGC4uEvsV1zW9FT+wqvErq9dbEZ3cwCQjnr68q4Yz71tyi88WMcMatm+gL",
+	"lDyzsl/q7f8nrDmJb1lo3URCRHPr8KoCJGbECsgNDV5fVS7Y7SxgtzNBfXBCRUoWhVcV2Qd6FQIArOkP",
+	"WXaDqNZvotinsXUTJNSEsAGKicGnT2xObil+XYWwhDJlt0lMi92RVcurLasu2ESsq/vpk367HsgSEJvf",
+	"Ao8nr6/koK+O+Q0LScwo30Meo6HYuyVBQOOHPTaJQr6HHFjjd7d/nkRBlMSvm63GtFnH8QnE66vKVQW4",
+	"eAjo6yvNt9aUidMpC4LHENxLcVhLvT1FvvIB2vPZnflmy4HTaJYPnaa9CUFYM0pQdVmaX4V1H8W+dRNT",
+	"8vmqAsx/fVXJGsm+tOpH9ZNpY3pVAZ8IYiVsQcTs9VXlnt5wJmhN9+QmiCafa2m1mm4r7Vs3ug/Ra4Uw",
+	"EmxCU9qtpxoS4Cnt6adXlSLSeRwf4dVi1ZvIf0grDQNKOIUbCsS/Y5z6IGZEqEANVdM9CwJ8K+h8EcUk",
+	"ZsEDJCG5I0zqaZjG0RwaByAiaJyARxeCSleuWW8e1OBNFAPhnHFBwgkFP0FtqoAvpGGowkJ1QKtQHSXG",
+	"UXI7kz72f/2ff/yPf/wvfCpjSxKAHaIpYpQDCX1w6d8SygWX5f43BppziEJZVWFPYBbT6eurdUqV0/iO",
+	"TSjfi6Rx5VJzWXKI9KuUVP0LD/pEBcLgjKCTcBHNaZyqbxhGsSCB5gGiKtVgFAGZTCjnslPYwSo8RMmr",
+	"IICQIr2j0wLbrJGg5R8+adSgF90CC0X0O8EUe4X/mjXwaEAnArzkZs4EEOgQvnzfqsEb5Bokx4QIehvJ",
+	"0QwYR/uhBy+gU2HNcIQ582VQhk/5JKY0rAJX8LtEEPA0WlXoRAE+ZlHIJUmah7IEp4Jnje9nnZPwkhvd",
+	"gwcY5KhiQJbu7W4G4cCAgJy5hvz39/c1bZ9reijSIUiHZMLnKc03s7fkG5PecD+jIWIwZ0IosUrZS8sU",
+	"DTnaKBGl9NIBK3ijMTBF5xTHwXRKpXBOGQ18iew7CmQRBdEt4xIwkPAB5fSOhoyWi3PtMTuw+rT4BK7C",
+	"f/ku//Jdfle+y39X78UlgkLA5kzw39ZzedxPGaUOCUp2JMCnnN2G1JeKacZuZ3AXBcmcQsLJLQULRJTq",
+	"PwK3UeRDgNETGhKthNEIA59FSeADYXOs8JnShQZwQ4PoHg4gThXwgsbA6SQKfeXWzKOYgk8FYQFHg7St",
+	"v7EM4m4T5lMuwzg/miQYJ0rVsxfTKY1Rw1qyyA8x5kb1MGlyxCsjl/kbqLZjCvdR/Bm1cxQCCycxJTy1",
+	"EROyIDhBkxpVTGGCM5X0mJE7CiQE+mVCF9oBCGmOzDRO3bNFIqpPxrkYuKb4pO5fUkAHR8VnfJJwjv2L",
+	"IZK94rXNLlPRWXoBk/TDDzC4Q86h91fhmNOliywyB49ATP1kQn0pKJwKJPGMkkDMpDXn0YSRACY4Pnkn",
+	"jAMLwQ5vAyyE/96RAB0ankxmQDiIOEEWjGI4H8IixnyadHiSbEw/POK9fNz5bqdwtybxlwKYZSzkeLGw",
+	"BOUqzCOOA0mnSSDZRsQk5ET5ZSvucBIE0i3JarNwEiSopOQjIDdRIpAQWAVx25kxLqL4YRfJkU7L8Rlb",
+	"SHpQTY2uBwO3i139uPMoU5IFsyZEkCC6Teh6QlhR7KMbeBW+jxKYkBBUqlgiyZaug+4zCY0x1oMVhRRS",
+	"7aHkj8WnV6EliYDTfPgdJ0kgUbMkSAb5EBNH+KmyqSDb1N+5ICLh+BInPREKvkYZx2f5XrziEEeBfL2I",
+	"2ZzED/L3Y6yWR5oEPFKqR9J6mbqPKU8CsZwmqSKUvyZcpEoUCMj5X414rqompIaQp3EYiVOFtckzqYDJ",
+	"+FNm9vmCTthUTyuGUcmIbMVF2FhuXMtHEcmgMtLY1XaYjR4wFIkkZH9LKKQ55ImqKt+XwZQRNwsFRXJz",
+	"uGdSXTygGicprfoXnrI7KgyKFpJ9pIhMojsao83SRbWXr63SBs1gmfWfoSP2Ip+rBymw3VqmNFBfKJ5I",
+	"Q+AgiO7VHM+KtizQpqqjHDkg1E9pk9H79CossjgsZakqhaYK0YKGUo6qMAkiTvV3KWaTGQlv1ROlo6Us",
+	"AZuWCJpkRDXuYMEsQn0TxTANiNDMXAVcqAAN/dnUn60qiOg+rC7lE5vSiyEQqrZ/CFZmkKugnasqCBrQ",
+	"xSwKVZ0p+YLlUWixcCrE+CqMQisv1JI3H1O3v5bKVDaVq/D86VEdw+yTIKHYNqxzsgovFtQ9UOTbTUHZ",
+	"c0O7Z4Z3LxrivViYdxNFny2UwO+M9WDvcXw3RXyPRn2/fOT3ewqkZEpW6gpBJ7MQ7SVE2rGV7mp0DyKC",
+	"m4QF6LBOxT2JqTJRPiWBNk+m94raR7m50rXlWUbuRcMi2SWMlJdNWWkHDRWkKqe9SBE/09WNnqb4Wdth",
+	"VMx4bpm3fWLgobVk9hRjj3ezSHpfSS7yyNlXfBWFwQPcYAQss8qgQyyp3n0asDsaP6wPSGq6IRedIeoj",
+	"VI4N5O01tiTdv1t2R3lmWCLldqzzM6T/puxLvoibRrmq8M7A7e7+AtYHLINmuWitzCBehWU4M67QjjVt",
+	"RJTBU8h5C7TyXRbTCXqVOQ8rjZiejBVHkJafgsxwms5YvAGptud0Tdc39GFOOSe3iA0N/UXEQpFmLrI0",
+	"8BoEdjyV+pasgS1p9wihxhHx52SR40LG4UMS+jSGmEp9gt6uTxcxVS7wx53vl/5iUkR1hPLdNOcsu5j2",
+	"WyZ7zJ4RuImj8O+ZMFdhTkmIRGEC32Pz6ZyHlA0lPnJKInhIk903GAAgoWdREnPYOSYyc3S4mO9W4TIK",
+	"ffKAv9/EDL/RL2kwe0PCzzCLAnysApzyJNIHnseAvyjFNAcpyNmojtAMYJr/oTCSru2NpknwnC5ESWwh",
+	"W4u0DUb5DzeEs4kVUy5UHxyhpGsSSbbNFMoHlJ0Xb13Jz20Q3ZAAuTn0SazTW0ovSoUvAyP6RQUHNfBy",
+	"sWVV88sN4cvpqovekRJ1bzRuwWUmbw4uKst6lAW2fiSTmHr+Dz6H0T3MZQQuA1UJSUSmjC91CZcJPhlZ",
+	"6zFST6WRVpori4YhTgLKa+CEsCCxYJMkICrhkK3ewhhJgZyQBZIYp4GrgIEuFpbCwEJoz2nMJkQlqhif",
+	"VSXR9Lo5uDbWm11L9K5Nq3yNrcqVN7km01YK0DBq0kDwK7+Wr9PYSz5CaHKY5CoghbxEQkVVVkYB7L+Y",
+	"JSpvrNJL1E8Vn6Sp5gYc0dxyTwSWLhe8j8lioWqREK6zVUjXoPxVbTdilXv9ELCbmGSTeF73Rw7ZlPlz",
+	"OLrIzRYRVjjjloYkmdvKGpcAuP+Z7yIzYepVOW8pH8mcw228ZM0ffoA+FZg51uakoEFTFFKux/pxSAVi",
+	"iZk7Za1QRHSBDxdLX8NTvkYHCZS2snPhdfpbJCeVaCp8JBhLen+hApOpMo9Okhgz29geScQsijXzFVQb",
+	"hiOWQvE5w8F1e/KV0d4PuRZQB/FdzGSwyUwaHS4F8J6megCrYqoI56IB+VyDojpfHwQy6bRYBDqX9JTq",
+	"NPRRkcRg4c8HmONkp6xH72iIDuMiphwDjPAqfEfhVk1E4zoPTiGHh1IyKIeL5CZgEyl9iBaVHrImvZ6f",
+	"IajOJzQOazBI4lUwJM63lFdjq9MryE06LFm6bnNlL3FOID+cEpTyiRIRxYzk7Nf3D3QKi+/lxxVNmDIw",
+	"+nXGiIMFDUHpEaXwFdGnTKqivHv5IVoWfUljL/upwO7mdJpKy8us2VdI1/rCV+guFw/CV8Al4hy+YhkL",
+	"sn/401i2mGVcdTVPQcvynDIqaHuFcuqNFsav8OGciYvkBmK6iJYEuGViltzUJtF8r3/hdRU5Nnj6UvGR",
+	"BdsTMaV77cuh1WzWDyzi+1aOEpaIpFnYVchIREpU9Vd4S2IWJRxyg5MrqPL9WnWGt6ZSxeofutGE/yZ6",
+	"XuLVv/CUgf0KY8EClEoRgU+X5h2LmElTVYHI7H3M6B31s9Q6IoTbqIzRCYganUUUTBr7jfpeOOMSxi5c",
+	"Xa1QIC1V0zVZlJXfY6FPv9RmYh7I7kv5seM4irlUR6iEMjftYjQapiEIspn0FTnqhvsZFTMqc+sq36xm",
+	"HGTKn6K9j2LUSzUYoQ7Mm3btCMTo6Um93KzXEWzz5ATYFFgOSH52CpvHVW66zD1R8fBCqP0huh/SK0E3",
+	"T/b0njAhuWeaxKq3k1Sb76tG97NGp4QFCIpOiNTDUyAwCRgNBVCkDjYiZ0QLZuFAATrYBCjUIKJQ6k8U",
+	"Ihoj6RXdl86jiICSyUwF/qjlZ9F9mFLM1rEkB52wqIIK/lzKF1HI0W9ONXSmnkDy/EtqOoRkKZ6wJE/s",
+	"wjQ1IJHGlKeK2Q7vWByFCE0JvKAcZVgpRONt4e8rnBFOYez2ODzrT6nV4p/RkvUif6qlMaex5ky5BhMR",
+	"hp1xe7QL8PU6HYaEiJry3qL4oSZzD8W1ahjZ7F1vwGkYR34iGaGkwPVywL+7Fd2SCp11qHB6FT4ZiXyk",
+	"suf+5f31VZifnyrOSqWmzNCX97OIU2Paz/3Le81lP4CzdKyXHJZOoKZTLuP2COiS41DYFjSWK1ilYLNV",
+	"IJl/Ed5EJJZLeQzHNuedVHXcQ2OqfDKIskqwiCMsA2p5bhJLzkj3eKSNdJZrMiTFZQCRy4yghxei5OMa",
+	"hEjKuXLtQhnU/jViSr1kMg2TaD5PQiYedK7jogjxZkOOdgudUVxdsqt2jwom5M6ux92HSrVyR2O1vaTS",
+	"ieaLBNW5CoAxzMMfupcgl33fPMC18pz4Hqfik65dWzxc454MHA8Ee1pp1eq1Ou6MImImt6zgfhvqyZ2X",
+	"ez8z/xs+u6Vidd9V+cKTLJEnIrilAoiMpAMKCBYUXBUzp9qc+uB0FUGyVJfjy027AiupXaCOLzsZkzkV",
+	"NOaV0w/F/mB61Olq2LnWJGSGJRDH5U4XuT9ouX9I7etRm3PM3dSDrtceOlZxB7DVKNmO8xFBKisjqdms",
+	"19ONODSURMyZxT0c3j/9lRe2b8viqBclkmqLdaVxXK/ktlVXhq5z2XbfQ6ft2jByx94IPGdkV75Vl1Ua",
+	"+0aV7uV4tUyraZS5sNu90QW0x6OLgeuM3sPORXvXqHBglHft81ddGPe7tgvDtjuCpuqRN8LJ/s4InZZ6",
+	"vv6R2dzAGzods0fHJ0YRb2h3nHYPVnvmDYt9O2lsV7VIhZZZbzhsgz3E3nvvvZF9mS/aNHs3cu326NLu",
+	"j6Bj90euAbV5eGyUvbS7Tsfp2+CNh8OeY7tG3/fNgegNOsuenw3abtcsbQ7tO7vnXcAjdepmdzZyzklJ",
+	"Z5b0s6Bnn7c77406zebGOthGecWjgyJne4O+CfrQKHE+eGu7fUn1ro1ch18NypvUwaBCIdq1XeetbZDl",
+	"wOTIN4Nxv9seOYO+qmL2tGX2tD26GPQG5++h1z4z2i+Qwn5r96C+D8OOCa9ukrnreCPX6YxKeL1bZPVG",
+	"o1DVtTuj3nu4bPfb5zaKpDOCne7l2KzWbG0cpHJyNo5XhN4Z9Ns9GLx543Rs2HEHuyb6j7NCvvy+qdqw",
+	"66jasgqGqJoIdAbj/ug94EfH6eULFnSB/ZPdGY+ctza0z+1+5z0M3cG52768NEW2frLCOXb/vNfud2FH",
+	"oW3Sc/+wBFPPdt8iXYbu4K2DmnGn5w3Nei2T6xyvZ8PgDY4e5LlbjurAbRfEc7/+xNqrEn7YWEEU1dLA",
+	"HWkCmWSpl6ibtufZl2c9o+RJfTPVV/qxf/yI2lup0tw/KbMhMHRtr+M6Z07/HDoDr0wjF2zoYDxCsl0M",
+	"xq63too5UO/avR/B6Zdp+wOzW2P3HAdB7/exL21XUUCq3S362mgdbzXI5RJbPyjo+n7HdgE52Bu55tgW",
+	"WFj272Jwae/1x66HHcQfW/X40KTAYHRhu1oROf2MvQZvZJbnbOw5fdvzDLk4MRVHG+1kR2njolAZODTK",
+	"9G1rVd8WUB1cXuYgaxbd6Ximrm0W7FOn1I40DkzmunR6So1J/hy1DeV0uGLy3qKUdu3+qN2DodvujIpu",
+	"0f5JiQhuIS4FQ9FrgwWX9sgdDAfYwT6kVsfQskcFz+WR8o3maiNOv2+70Bv0u6Y1b67YICwBZwN3MD6/",
+	"MKh0VObOOZ7dBRw6x/OcQR8Z8twdjIdG/+sFg+XZbbdzAajHu8gdg+GKyDSP1zkZ2swp/W9yhon3YDga",
+	"IK3e57j1caXULHF/tpO3Zom8eaP2aDwa5O0n7AwKLH3S2lrOyn22xuFGPW+WNYfR7o5VU8aAHZZ7QiWG",
+	"vXXyOG+W1TtqlMlc7z0M3vXtLtj9UdHVML1IzaClXWoclnEqeJ2LwaDngf3e1mHRBiXWKLSnpXpwhpXa",
+	"OKJGkwUF75R7QY1WYx1XLy3Hqs44MGWhP+jn+Aq198A9b/cdb2UcG/vmYPe84Wq0dbziwhV7fVBw4Acu",
+	"8nYfHNeWvljqIPe74A0kqZfRjAmo4M/JDqMzuqVlOT7ZyOdLLxKstabYlNJOz+k7qLX79ujdwP3RLNsq",
+	"M9slJZuHRQEc2f2u3YV2p2N7XjmDHbS2oqp2WfKEXQkmm4dHG9u/GJ+Zqup4S1VVZJaCT+FcXjrnrho3",
+	"174cvG33yjTjwcFmTJXdfMSINApKyRuhK33udLZJKBSSGOMzcDpnst2iyDQbjS3NXIGozcbxijuzoXBr",
+	"Q+HUN0Nnzax1UPS1Rx5026O2CjSl9nD6IzsdEzUWGHg6HdPinJRLQckYrHr8BfUq29dS68GbgZuDYrse",
+	"rMSnXa9TDFELubH15CjpTfOxGLGqGUy1v1sCwpQe2b/VUk3Ti/HszlgqubYje1nC94Xwy+sMRiPHu1ib",
+	"E2o2j8ornA9LvdBm82TFMLi256DjisrC6XsjZzReYfGitRqMXZPPVsL6vjdqn4177UKwUrCRaPc7NnTG",
+	"3mjQfQ/euEjCQ5Mg7wfj/jkOiy0zlrnubuWptYpe5aOIN4+3bN/EsvkdSq/Zeiq7NFuNshqdC6fXde3+",
+	"K0+GfqbD29iyZ9vQ82R/1QtFiwedQX+E3Dcw7Ve90N2cgVqRnYLdyKJao9CRSbHu4NLpOD0nzaubmB9u",
+	"QattsG4eHJVBKozTVpAKAYA39kZtp98+w9DzPULse28G7qUaH3ROUD1eOGaoVLAiqTZH61Nu+otm0f5p",
+	"jB6V57XHvRG49hvbdcs59OD4CTXLKGCO3opFO3PQky/NGzaP9tcnmtp9jaxrd3Cvmf2TfTnstc0cQyFN",
+	"7V1eDDtgQef98BLzy47twr5Z4bC0QruLyNrtEeLVdbyB27Vdz6x5tKGm5pfU/pnqpiAhMv4wShyvpkDQ",
+	"yr0HM2Yx6zTW1Skpaw7K20Fv3JdpECnTg17P7pZWa66p1na6a2qUJZucftceom7tj8rqnGzOS2PCy3ZL",
+	"0Spkk4fjodNbMuyK23S8v25SoQx2fW3hDWNy/Bj6G+qWxI4ju3PRV5MZHRylc1PWCmnQ/qBvXbadPqob",
+	"u7uprROzYrvT7tqXyG2DvjdwoWcm0ZqFxMSbsXTcoTRn0Dw5LGTcvI7d67X79mDsmSpuv6Dilmb/zViG",
+	"LWfvQZHw0dRqq5ALT3EfooZqHK616QUH2HN+Gl2g73qp1Z0pyAXTqGUe+axQzmSDC+fcIFe+NyZJ6s3S",
+	"cekM+m9td2QGjq1CWPrGte00t2GWOykF2u6NbBdD77c6yPZkqFDe1FFxLuit7XoZh+qYYYVBW/X9tV1M",
+	"2dNkicYavrSVU1HauUY50RqHVuNkHT4H6/tVShez9uH62o1DGA2gcWJWKFjY0bjrDMrHajMB1ghoq1F/",
+	"whCvBdJaQ0eF0fp6R2sMUddpn/cH3sjplLgdrcYj2YA1wU6rEH/ZPbsjEfTG7rlkxGKW4+jkkTUaZunG",
+	"+tIryYiT463jZ9OVaK5vpCSvVPBZ1lCmUXBQtvBVj8py1dv4uI36E6Ntc+a6QOOLtnvZ7rxHx6/7H+N2",
+	"UVybh401KZ813nPjuFnagJmNWjWeG3Oo9f21cU5pXq9AW8MLkDPA2s9dC6FZmLtKp6yeMo3RKFj6bNGF",
+	"Wei4vNBqCvro8FGk9joZNqsBYEEWO1gO3rY7HaefT1WZXk69nFuKwAuOx5nb7ncupFqwzXxFo5CmHQxH",
+	"jo47UvbzzPJHpeVXJ/XMnp7bfRsd0U0TiAXLWq6UmvvrVKwOlZ7CEfuNTe62yYCFCdHxWc/pbN3mx+ry",
+	"qOsPP6cL/LKTQnE5p0/5Z7C/UEHjSrUiaEAn0VyWTm+Z0EdkVysJx9q4gauS3SxRobJqbaZBZQdnf6vm",
+	"IMjjUNZBqLfqdWjVW7Bfb+1Xvn1Mey3XBcq7bAIqj/ieRIuHGPflIP3T7/Bf//Mf/xea9cYRrNm7ljtP",
+	"BDo0FDGtyakavUlFXm8yoyBiedSe3FiRnsyzPcRKVR1EjmtCmi2r3rIUJxorMduwXEOqDxFKz6JXfYij",
+	"gO7w3bT5KLeysmYeRY7rh42z/XHlJa4ExZ1efEZjrUQ0kpXiGfWVZU8q8lR1kfDKaXryf/Fc820Xai+B",
+	"7m1aHLpcstuotZq1ujqc3aTV4Ed9upTarZPr7rdqZf+nn1bX3bazXRKTSRLH1AfC9cYtrjdQye0oCEqu",
+	"2mecJ7TG6R2VG+GyJxNVIPutd7Vlz8veaRGVC+vNNf2rq/zzPxDUph0Bm3YK7Nf3c3sQFO6QexJGwppG",
+	"Sejr3/3BJ5XH+SRDaaNsP4KYTiK5sxprfL0K5SH6PJnj+URq1bFaOq+lGt8aK/OfsSB6w/ldTJ4dtWYZ",
+	"dNlFGHyb9dCFI9acLlhqTzvu7A6RcZjg2W6B7LRFEsSU+A+yVHZ010QeXDWJQs58GufO+HuFJJMtcaaQ",
+	"0dzyaok8C7mgxK8tF2P/LaHxw1LYP8nl2GXLr93Redm9By+Art6jkFERGAfO5iwgMY7W9SfmX8NNIpbH",
+	"y9EvuHibieBB77jSO2GX/JLtypCN6621CRdwQ+EaTyPLKzu5L+i6hkcNvOLLLeAIQm7bQ6NABLuRe+UU",
+	"7TRd5OJy1BgShLW8KWnJyO2U3ZYjle8nKnune5ozUKkWxFXpqbpz/L3STn/FUflWlV2IErF1L+ThAIo8",
+	"+aYltG/lnJG78STPIFsxBNqq9OD44kYZwo3zDGswCIOHdMcHX174E011Eb2Xf4Y77kNUu1NB1VjjsTPp",
+	"QW5Lai9iOmVf4PpWXMPObUyJKk5CeRVNqRDkb6Eql4ZbgT6AVd+36o1txUJeJmIa20xGsGW1s1m3UYXr",
+	"HqU+v4Z7eQStpAO8ks+gM6NcQCdgIZu8kqIRRgJeeVEiZqCLyJckgDbnlHO5g0iffvKqpk9+0yxBwge1",
+	"SZuF67qmDoK7xq+n6dme1zVYwtC6lH4hE6H6mq8iH1/LZvsRyjGnwGnIGZp/+dhTYOgX3GzO5RlLKK6n",
+	"2eF3LAQCcxayeTJHGrZgMiMxmaDWVfv5YU6+pG8b9XruPQKRh7TklcVyj/Wy4Cm0rf8EYv0d6taJXoCx",
+	"s6vuDviT9am2d/rv8MpbkAl9tVaDyo9yppEjsw23tMMHeRzHL8AsSKqnMco/xYhlXP3coUNBV1Kwsn9a",
+	"kq3z356SEvt1ZLTtrgcju925UEuaVYLDg2WaYgsav9ObuUtEQ3uV6EEIFgTyQBYZZKA2WqfvszCkrMu4",
+	"Ia1SXbma7OkqPj3b8xHtmxbT+lOfaWOp60rxxRMVcAmAX0AdNzexHAkWMxIm8oCgIuty5Ci+lqNWe7+O",
+	"s7zGC2hWY4z+eLR5TIvtd//zGTrMIE7nj0WYjUrJa8ATKLNJxuW5+br5R+Q8X7QgqvjqO6VcVv0Dyjf2",
+	"e83wdGl88/ACsr0yNn8k2jwm2VsTaY1srxCn80chzCbJ7tru2fvvzFqoc++NU/wwY5EvJcvko/maPu69",
+	"JKUkkxfy+oz0iH8S6nO4C4oCZTwPIIt37yjMk0CwRaBStxx2AvaZAgnhVbvffaWPqIzi3arh7aX5Dgsr",
+	"XYMkZi7bksEUbE65EVfIPOb1uT2CpxzPkU+T/Tnf9uujQ+VXGg8bR0fX21NAUm8KMo1KghU6DFyDDKgb",
+	"iTxXggCniLOI4l8UxcbJUbVxcnydHuU4Jw/Y/g0LqR4lGYFhPxW2lK9mlNIhkSD5mkwOUmktPxh0yKd4",
+	"dP/SpNGz8lYZ+5elr7Yh49ZZ/K84rfmcPNdSUncaJ0fwWgYc8jKLInXWJsDyw/y0FFgarxDVjRLzBEzl",
+	"RPPn89fgUics5YnNLEQl+9ckVEfoICXKpDuT63XBTlpcN+UWsVlqzykJ+HahT3/lkg8R6TsdatClU5IE",
+	"+lzkKYu5gGZdB2p8bQpOHtO5pmPbaPQhuU1vEMm6AI11rS3ILX1GY65EtXh/yepd5VXQDKyJkwoWmYiE",
+	"BKoCXU+TlM2Njuq7nxW9Pv5SR6HoK+s/5K4ir3yvwtyrtzptt2JeVZ5eAq7Of8ld+y09Q505kLOyiepK",
+	"RS8/URPWImY461x5h8c7vh+4P3oXjmtX5A34FOkj1+xB09qHd47bzrY944KSH5FsKlboqHnwntc4hEP7",
+	"rHCj9IefCxOV+RS9UmgiTuRNA106ZSGT2GYXTFtavbUlqurma6uRap/lTdgv32KXCDkRu2zNU+xxWhks",
+	"j7WWE/j6xutKs97ASWVMb0uG/1UQD33V8jHOaMtdEb8qLXr09hEqlHVH2wMJqpNdpf69+62qy2UMT5Gv",
+	"rabCcwNpan/Z9zOt36UILkuS3KD9oXj1kY7/7nlNdUCvlShWV6Ik679ER8uY5aO8u/+0kmrr3ATg6c+r",
+	"XLrNdKWxDKcjN3ZU5lRIQ5afc0sHZt9qnIzq9VP535/k/3G6PY6mLKBPRboEVUQzM68qNy0NSKdzDjsX",
+	"/7G7uo4lXzvzDKS0m3JfOM7L63hMLiraSLZtJFm6TR28v0UK9LdsmJpTur9/OJ1ah/4xtfZPmhPruHVM",
+	"rEa9Xj86OTokVC7bDVj4WfJ3eqsZ8hgNpiuLcJ4cBiEZXweU+lwFecqFe93Qv9DFet2QC7VyTUt/8Ddq",
+	"OyC/VtMnB8W2Q/rlV2q7KSV5k5QdWPXGqFE/bTZO6wdayr6tcP5ZEvrSWIgIV3idVk4Ocq6xDLq4XIiX",
+	"c07/LaZTXJGzhysoopCGeAyifMsNRDTsknVZb0nA/Oy4XpkGSM/izGsX0Pf16eVEspyaBJX34cnewSRm",
+	"gsaMwI5eD0LkfTj13VpukdcT/WIpesfNydHBwXHdahzVm9b+4Qm1CJ00rP1WvbV/s388rcv9r3IJV/4w",
+	"QRbeIXpy4Z5cqVOqSpz+23bP6X4att32pY0rpY0FuQrGMu57qpKRV7tY8mTfQfyOyKAEXyt/oeIzchtG",
+	"XLAJx30goV7CE9/KI3c1veXtk9iwXtxWOa3I9WEG632n2lb9y0z/IBGTaE6tRgmLFguVMdQ/07I92U5+",
+	"2R58LS7bg6/msj34un7ZHnwtWbb3FU+m3gRfpmo0p+PvlJ07g679SR3smJVNmTmfaHp+I2/bvbEN5Y1I",
+	"d+R5bajN528c283QydpY+kwvgk6uqRSpsqYUUts0tGwDIN9QpmtglW7LzPGzmjBHRb1rh8DCRSJgymjg",
+	"w4xwICGkEBRa8nR9wQEtD5QsHPXWLu5cXUP63JN14zTvow/XzbeWrUDMn68rb1uWnLe7Zo2pJyEV3MyX",
+	"XmD67LN4yxeDvkyq6ZfOBHmjGvylfWl7rzzI7WvMzoerVs7szo/2aATeyLXtUUl26ASORm/+iZNDjZOT",
+	"Y7328fvSIut3Kf0r//H84fi9ZA5c9/jF8wYI85GsQcNqNX7lrMEjK9oeSSKU7YKaki/5bEmj0YLmfhMO",
+	"908Oyzc7FYq2oIWnU3/7uCFJ0SgmKQbx7S+Ro/iekDDdX/FdQSF/4aDwXzt/fuWdP8YwatSV+/ZWKjMq",
+	"XvRShBRoqc+2xlGTdTgVXlp0u6sRmJ81krZa+0WuRjCE8EV8tS/zwGxW3UecEe/LPAj561f6FvBZcFSL",
+	"4ltZ9ZUsmd71nLryr1891u9Xxs3JzDfACPpFGA+UwUthq9vjBPVNIKqQUW9vBVISBymYtTowY8SnIZHE",
+	"gdGU3pyYNtfAe0DMGrqEUQsZJEfEkv2ZSsZkF0xwWHUD2ZS78yjN0OKmVdJcfb1lVsMyRqVst2haM7dl",
+	"1KyalTTq683FaynhhILGUlDVVi0YUTJ/BHttfM0xQXApUFYAKiiZp9uOCwOFFUw+4hkY3IVc4AROi2y4",
+	"2pW9MpxzWiUF317qsNKdvood5L3vpdt9CyO3bKAwAOk2aN3s6r7o4990X7SJRtbdAhLzRVSgvV4uZzzT",
+	"CZvH9MATfKGCVMlK5miX9WOvrMOZ+lFP0VfJ6+vSy2wkFPyqWzEdzpdx9j5+Kz0DoFQyC65v6W79TdKX",
+	"bd7ftE//+HezTz9VkiX79J8nuxUdbm3hBWzc0p8zGU/Z2p92vnRjP5pWGfalbzKzXPn2aAC6tYktbPKv",
+	"1+pPDBse8QSVH/ivgOC3CwiUSZYTjBgFYPjvE0GeHQB0yCJVSZ4gQt7FXoN26XN5xzX2AaVwkhZAMZW3",
+	"b6r73eVdmWms+ZCt3iSyagYpmqZr/tTlmjDV6zqJbDKKi6XTKABf+ZTLr7oqK94hX3qDW4ZPhk7lhbK1",
+	"E7oQel6wcloJo8rmU2B0+g6x+PxdR8Bw7tdCDU0De9GjYDaf+mKqwoJyVwfTFXUDalpUJ/rqXLmZ3Yhv",
+	"iSDqNl1UOLkGYJAv1sViemcu6nwckbeZ1sMLBFEPKhOEee7S+K26ZlAxZWfw0XnCpIewzMKm1yimKhqv",
+	"Lcd03Z6sHHJBQsGIoDxfa9WbWGXEVL2XvLIaCPwzk4u1VBsTatix9RVzKT+53EVfC/tSCcDngyyk+p8F",
+	"8rF5hJcFXpb0fdEGxkO3/+JAc5n1bx+f4OCs1Z1Kwc1VMlVpY2NVs1zpEfpM6aqOPHqiciqX1Vfzb7pU",
+	"HjmFylOkd5PKkydyZVxK/M0lVBo8g2/OTJTO0KSZpeIUQdkWgW/VravJmR9zfqGQDyodr8yiPmSWz1JJ",
+	"WivLqllqK42ezpV6R9BYXZOdX1kTI7VyB5tpODIJ9u1jTjP8AopBNn6hUs/ZaKgODBGPvFVUx+3o3JyI",
+	"PtNQ9rr8HBJdDH8apdKtEvq92vRglFhuHFjXlHG6y7pC8mMllbh8v/T61wAo7PZcD6hs1/dqaT8bjI1j",
+	"tyQ9stDa+cHsvLDy/T4rOL1s68OlrJV1wpyvNPuy3PRRmFhS5+UU9I4OlJTIhpGlf2tp+a201UY5/rid",
+	"TBn3wOt0exDdysNNxm4vC+mMs+g0nVFVrbDYkrBGlaeRFedp6SRRy+IQsZirVP43PYX7IkfhpQHRn7cL",
+	"hV3lilJfEaQs2Mkc0lxcU3ZG27JuprZVi8ogls2DbLhzPnfhee27iVEp2Q62vHx+YxPbgf/47f8NAMVV",
+	"fd32uAAA",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}